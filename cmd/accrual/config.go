@@ -0,0 +1,80 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+type Config struct {
+	endpoint        string
+	rateLimit       int
+	processingDelay time.Duration
+	logLevel        string
+	env             string
+	callbackURL     string
+	callbackSecret  string
+}
+
+func NewConfig() Config {
+	var (
+		endpoint        string
+		rateLimit       int
+		processingDelay time.Duration
+		logLevel        string
+		env             string
+	)
+
+	flag.StringVar(&endpoint, "a", "localhost:8080", "address and port to run server")
+	flag.IntVar(&rateLimit, "l", 0, "maximum number of order requests per minute, 0 disables the limit")
+	flag.DurationVar(&processingDelay, "p", time.Second, "delay between order status transitions")
+	flag.Parse()
+
+	if address := os.Getenv("RUN_ADDRESS"); address != "" {
+		endpoint = address
+	}
+
+	if l := os.Getenv("RATE_LIMIT"); l != "" {
+		value, err := strconv.Atoi(l)
+
+		if err != nil {
+			log.Fatalf("RATE_LIMIT has to be an integer: %s", err)
+		}
+
+		rateLimit = value
+	}
+
+	if p := os.Getenv("PROCESSING_DELAY"); p != "" {
+		value, err := time.ParseDuration(p)
+
+		if err != nil {
+			log.Fatalf("PROCESSING_DELAY has to be a duration: %s", err)
+		}
+
+		processingDelay = value
+	}
+
+	if l := os.Getenv("LOG_LEVEL"); l != "" {
+		logLevel = l
+	} else {
+		logLevel = "error"
+	}
+
+	if e := os.Getenv("ENV"); e != "" {
+		env = e
+	} else {
+		env = "production"
+	}
+
+	return Config{
+		endpoint,
+		rateLimit,
+		processingDelay,
+		logLevel,
+		env,
+		os.Getenv("CALLBACK_URL"),
+		os.Getenv("CALLBACK_SECRET"),
+	}
+}
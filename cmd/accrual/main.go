@@ -0,0 +1,29 @@
+package main
+
+import (
+	"log"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/accrual"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/logger"
+)
+
+func main() {
+	config := NewConfig()
+
+	if err := logger.Initialize(config.logLevel, config.env); err != nil {
+		log.Fatalf("Logger wasn't initialized due to %s", err)
+	}
+
+	var notifier *accrual.Notifier
+
+	if config.callbackURL != "" {
+		notifier = accrual.NewNotifier(config.callbackURL, config.callbackSecret)
+	}
+
+	log.Printf("Running accrual server on %s\n", config.endpoint)
+
+	accrual.NewRouter(
+		accrual.Config{Endpoint: config.endpoint, RateLimit: config.rateLimit},
+		accrual.NewService(accrual.NewStorage(), config.processingDelay, notifier),
+	).Run()
+}
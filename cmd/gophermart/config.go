@@ -1,29 +1,119 @@
 package main
 
 import (
-	"crypto/rand"
-	"encoding/base64"
+	"errors"
 	"flag"
+	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"time"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/services"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/utils"
 )
 
 type Config struct {
-	endpoint        string
-	accrualEndpoint string
-	dsn             string
-	logLevel        string
-	env             string
-	authSecretKey   string
+	endpoint          string
+	accrualEndpoint   string
+	dsn               string
+	logLevel          string
+	env               string
+	authSecretKey     string
+	authKeysDir       string
+	authKeysReload    time.Duration
+	authKeysRotation  services.KeyRotation
+	accessTokenTTL    time.Duration
+	refreshTokenTTL   time.Duration
+	revocationTTL     time.Duration
+	sessionCookies    bool
+	retryPolicy       services.RetryPolicy
+	circuitBreaker    services.CircuitBreakerConfig
+	accrualRPS        float64
+	pollInterval      time.Duration
+	callbackSecret    string
+	idempotencyKeyTTL time.Duration
+	eventsRetention   time.Duration
+	eventsHeartbeat   time.Duration
+	webhookRetry      services.RetryPolicy
+	webhookWorkers    int
+	command           string
+	commandArgs       []string
+}
+
+// getEnvSecret reads the secret from the variable, the file named by NAME_FILE or SECRETS_DIR/NAME.
+func getEnvSecret(name string) string {
+	value, err := utils.ReadSecret(name, os.Getenv("SECRETS_DIR"))
+
+	if err != nil {
+		log.Fatalf("%s wasn't read: %s", name, err)
+	}
+
+	return value
+}
+
+func getEnvInt(name string, defaultValue int) int {
+	value := os.Getenv(name)
+
+	if value == "" {
+		return defaultValue
+	}
+
+	result, err := strconv.Atoi(value)
+
+	if err != nil {
+		log.Fatalf("%s has to be an integer: %s", name, err)
+	}
+
+	return result
 }
 
-func generateRandomString(length int) string {
-	b := make([]byte, length)
-	_, err := rand.Read(b)
+func getEnvFloat(name string, defaultValue float64) float64 {
+	value := os.Getenv(name)
+
+	if value == "" {
+		return defaultValue
+	}
+
+	result, err := strconv.ParseFloat(value, 64)
+
+	if err != nil {
+		log.Fatalf("%s has to be a number: %s", name, err)
+	}
+
+	return result
+}
+
+func getEnvBool(name string, defaultValue bool) bool {
+	value := os.Getenv(name)
+
+	if value == "" {
+		return defaultValue
+	}
+
+	result, err := strconv.ParseBool(value)
+
+	if err != nil {
+		log.Fatalf("%s has to be a boolean: %s", name, err)
+	}
+
+	return result
+}
+
+func getEnvDuration(name string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(name)
+
+	if value == "" {
+		return defaultValue
+	}
+
+	result, err := time.ParseDuration(value)
+
 	if err != nil {
-		panic(err)
+		log.Fatalf("%s has to be a duration: %s", name, err)
 	}
-	return base64.StdEncoding.EncodeToString(b)
+
+	return result
 }
 
 func NewConfig() Config {
@@ -65,16 +155,55 @@ func NewConfig() Config {
 		env = "production"
 	}
 
-	if secret := os.Getenv("AUTH_SECRET_KEY"); secret != "" {
+	// Tokens are signed with the keys from AUTH_KEYS_DIR when it is set, and with AUTH_SECRET_KEY otherwise.
+	authKeysDir := os.Getenv("AUTH_KEYS_DIR")
+
+	if secret := getEnvSecret("AUTH_SECRET_KEY"); secret != "" {
 		authSecretKey = secret
-	} else {
-		if env == "production" {
-			authSecretKey = generateRandomString(10)
-			log.Printf("WARNING: AUTH_SECRET_KEY has to be defined for production environment\n")
-		} else {
-			authSecretKey = "development-key"
-		}
+	} else if authKeysDir == "" && env != "production" {
+		authSecretKey = "development-key"
+	}
+
+	authKeysReload := getEnvDuration("AUTH_KEYS_RELOAD_INTERVAL", time.Minute)
+	accessTokenTTL := getEnvDuration("ACCESS_TOKEN_TTL", 15*time.Minute)
+
+	// Keys are generated in AUTH_KEYS_DIR every AUTH_KEYS_ROTATION_INTERVAL, rotation is off by default.
+	authKeysRotation := services.KeyRotation{
+		Interval:  getEnvDuration("AUTH_KEYS_ROTATION_INTERVAL", 0),
+		Grace:     getEnvDuration("AUTH_KEYS_ROTATION_GRACE", time.Hour),
+		Retention: getEnvDuration("AUTH_KEYS_RETENTION", 24*time.Hour),
 	}
+	refreshTokenTTL := getEnvDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour)
+	revocationTTL := getEnvDuration("TOKEN_REVOCATION_CACHE_TTL", 30*time.Second)
+	sessionCookies := getEnvBool("SESSION_COOKIES", false)
+
+	retryPolicy := services.DefaultRetryPolicy()
+	retryPolicy.MaxAttempts = getEnvInt("ACCRUAL_RETRY_MAX_ATTEMPTS", retryPolicy.MaxAttempts)
+	retryPolicy.InitialInterval = getEnvDuration("ACCRUAL_RETRY_INITIAL_INTERVAL", retryPolicy.InitialInterval)
+	retryPolicy.MaxInterval = getEnvDuration("ACCRUAL_RETRY_MAX_INTERVAL", retryPolicy.MaxInterval)
+
+	circuitBreaker := services.DefaultCircuitBreakerConfig()
+	circuitBreaker.FailureThreshold = getEnvInt("ACCRUAL_BREAKER_FAILURE_THRESHOLD", circuitBreaker.FailureThreshold)
+	circuitBreaker.SuccessThreshold = getEnvInt("ACCRUAL_BREAKER_SUCCESS_THRESHOLD", circuitBreaker.SuccessThreshold)
+	circuitBreaker.OpenTimeout = getEnvDuration("ACCRUAL_BREAKER_OPEN_TIMEOUT", circuitBreaker.OpenTimeout)
+
+	accrualRPS := getEnvFloat("ACCRUAL_RATE_LIMIT", 0)
+
+	if os.Getenv("ADMIN_LOGINS") != "" {
+		log.Printf("WARNING: ADMIN_LOGINS is not supported anymore, grant the admin role with the create-admin command\n")
+	}
+
+	pollInterval := getEnvDuration("ACCRUAL_POLL_INTERVAL", time.Minute)
+	callbackSecret := getEnvSecret("ACCRUAL_CALLBACK_SECRET")
+	idempotencyKeyTTL := getEnvDuration("IDEMPOTENCY_KEY_TTL", 24*time.Hour)
+	eventsRetention := getEnvDuration("EVENTS_RETENTION", 24*time.Hour)
+	eventsHeartbeat := getEnvDuration("EVENTS_HEARTBEAT", 15*time.Second)
+
+	webhookRetry := services.DefaultRetryPolicy()
+	webhookRetry.MaxAttempts = getEnvInt("WEBHOOK_RETRY_MAX_ATTEMPTS", webhookRetry.MaxAttempts)
+	webhookRetry.InitialInterval = getEnvDuration("WEBHOOK_RETRY_INITIAL_INTERVAL", webhookRetry.InitialInterval)
+	webhookRetry.MaxInterval = getEnvDuration("WEBHOOK_RETRY_MAX_INTERVAL", webhookRetry.MaxInterval)
+	webhookWorkers := getEnvInt("WEBHOOK_WORKERS", 4)
 
 	return Config{
 		endpoint,
@@ -83,5 +212,62 @@ func NewConfig() Config {
 		logLevel,
 		env,
 		authSecretKey,
+		authKeysDir,
+		authKeysReload,
+		authKeysRotation,
+		accessTokenTTL,
+		refreshTokenTTL,
+		revocationTTL,
+		sessionCookies,
+		retryPolicy,
+		circuitBreaker,
+		accrualRPS,
+		pollInterval,
+		callbackSecret,
+		idempotencyKeyTTL,
+		eventsRetention,
+		eventsHeartbeat,
+		webhookRetry,
+		webhookWorkers,
+		flag.Arg(0),
+		commandArgs(),
 	}
 }
+
+func commandArgs() []string {
+	if flag.NArg() < 2 {
+		return nil
+	}
+
+	return flag.Args()[1:]
+}
+
+// Validate refuses a configuration that would break sessions across restarts and replicas,
+// i.e. a missing or short secret key in production or a key rotation that drops keys too early.
+func (c Config) Validate() error {
+	if c.authKeysDir != "" && c.authKeysRotation.Interval > 0 {
+		rotation := c.authKeysRotation
+
+		if rotation.Grace < c.authKeysReload || rotation.Grace >= rotation.Interval {
+			return errors.New("AUTH_KEYS_ROTATION_GRACE has to be at least AUTH_KEYS_RELOAD_INTERVAL and less than AUTH_KEYS_ROTATION_INTERVAL")
+		}
+
+		if rotation.Retention < c.accessTokenTTL {
+			return errors.New("AUTH_KEYS_RETENTION has to be at least ACCESS_TOKEN_TTL")
+		}
+	}
+
+	if c.env != "production" || c.authKeysDir != "" {
+		return nil
+	}
+
+	if c.authSecretKey == "" {
+		return errors.New("AUTH_SECRET_KEY, AUTH_SECRET_KEY_FILE, SECRETS_DIR or AUTH_KEYS_DIR has to be defined for production environment")
+	}
+
+	if len(c.authSecretKey) < utils.MinSecretKeyLength {
+		return fmt.Errorf("AUTH_SECRET_KEY has to be at least %d characters long, run the keygen command to generate one", utils.MinSecretKeyLength)
+	}
+
+	return nil
+}
@@ -2,19 +2,33 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"time"
 
 	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/database"
 	router "github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/http"
 	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/logger"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
 	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/services"
 	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/utils"
+	"go.uber.org/zap"
 )
 
 func main() {
 	ctx := context.Background()
 	config := NewConfig()
 
+	if config.command == "keygen" {
+		keygen(config.commandArgs)
+		return
+	}
+
+	if err := config.Validate(); err != nil {
+		log.Fatalf("Configuration is invalid: %s", err)
+	}
+
 	if err := logger.Initialize(config.logLevel, config.env); err != nil {
 		log.Fatalf("Logger wasn't initialized due to %s", err)
 	}
@@ -29,25 +43,153 @@ func main() {
 		log.Fatalf("Migrations weren't run due to %s", err)
 	}
 
-	log.Printf("Running server on %s\n", config.endpoint)
+	switch config.command {
+	case "":
+	case "rebuild-balances":
+		rebuilt, err := db.RebuildBalances(ctx)
 
-	jobQueueService := services.NewJobQueueService(ctx, 100, 2)
-	accrualService := services.NewAccrualService(db, jobQueueService, config.accrualEndpoint)
+		if err != nil {
+			log.Fatalf("Balances weren't rebuilt due to %s", err)
+		}
 
-	if err := accrualService.StartCalculationAccruals(ctx); err != nil {
-		log.Fatalf("Starting calculation accruals was failed due to %s", err)
+		log.Printf("Rebuilt %d balances\n", rebuilt)
+		return
+	case "create-admin":
+		createAdmin(ctx, services.NewAuthService(db), config.commandArgs)
+		return
+	default:
+		log.Fatalf("Unknown command %q", config.command)
 	}
 
+	log.Printf("Running server on %s\n", config.endpoint)
+
+	jobQueueService := services.NewJobQueueService(ctx, db, 2, time.Second)
+	accrualClient := services.NewAccrualClient(
+		config.accrualEndpoint,
+		services.NewCircuitBreaker(config.circuitBreaker),
+		services.NewRateLimiter(config.accrualRPS, 1),
+	)
+	accrualService := services.NewAccrualService(db, jobQueueService, accrualClient, config.retryPolicy, config.pollInterval)
+
+	jobQueueService.SetStandby(true)
+	leaderElectionService := services.NewLeaderElectionService(db, services.AccrualLeaderLockKey, 5*time.Second)
+	leaderElectionService.Start(
+		ctx,
+		func(ctx context.Context) {
+			jobQueueService.SetStandby(false)
+
+			if err := accrualService.StartCalculationAccruals(ctx); err != nil {
+				logger.Log.Error("Starting calculation accruals was failed", zap.Error(err))
+			}
+		},
+		func() {
+			jobQueueService.SetStandby(true)
+		},
+	)
+
+	eventService := services.NewEventService(db, config.eventsRetention)
+	eventService.Start(ctx)
+
+	webhookService := services.NewWebhookService(db, config.webhookRetry, time.Second)
+	webhookService.Start(ctx, config.webhookWorkers)
+
+	jwtKeys, shutdownJWTKeys := newJWTKeys(ctx, config)
+
 	utils.HandleTerminationProcess(func() {
+		shutdownJWTKeys()
+		webhookService.Shutdown()
+		eventService.Shutdown()
+		leaderElectionService.Shutdown()
 		jobQueueService.Shutdown()
 	})
 
 	router.New(
-		router.Config{Endpoint: config.endpoint},
+		router.Config{
+			Endpoint:        config.endpoint,
+			CallbackSecret:  config.callbackSecret,
+			CallbackMaxSkew: 5 * time.Minute,
+			EventsHeartbeat: config.eventsHeartbeat,
+			SessionCookies:  config.sessionCookies,
+		},
 		services.NewAuthService(db),
-		services.NewJWTService(config.authSecretKey),
+		services.NewJWTService(jwtKeys, config.accessTokenTTL, services.NewTokenRevocationService(db, config.revocationTTL)),
 		services.NewOrderService(db),
 		accrualService,
 		services.NewBalanceService(db),
+		services.NewIdempotencyService(db, config.idempotencyKeyTTL),
+		eventService,
+		webhookService,
+		services.NewRefreshTokenService(db, config.refreshTokenTTL),
+		services.NewAPIKeyService(db),
 	).Run()
 }
+
+func newJWTKeys(ctx context.Context, config Config) (services.JWTKeys, func()) {
+	if config.authKeysDir == "" {
+		return services.NewSecretKeys(config.authSecretKey), func() {}
+	}
+
+	keySet, err := services.LoadKeySet(config.authKeysDir, config.authKeysRotation)
+
+	if err != nil {
+		log.Fatalf("JWT keys weren't loaded due to %s", err)
+	}
+
+	keySet.StartReload(ctx, config.authKeysReload)
+
+	return keySet, keySet.Shutdown
+}
+
+// keygen writes a new secret key to the file, e.g. for AUTH_SECRET_KEY_FILE, or prints it when no file is given.
+func keygen(args []string) {
+	if len(args) == 0 {
+		key, err := utils.GenerateSecretKey()
+
+		if err != nil {
+			log.Fatalf("Secret key wasn't generated due to %s", err)
+		}
+
+		fmt.Println(key)
+		return
+	}
+
+	if err := utils.WriteSecretKey(args[0]); err != nil {
+		log.Fatalf("Secret key wasn't written due to %s", err)
+	}
+
+	log.Printf("Secret key was written to %s\n", args[0])
+}
+
+// createAdmin grants the admin role to the user. A missing user is registered first
+// with the password from ADMIN_PASSWORD, ADMIN_PASSWORD_FILE or SECRETS_DIR.
+func createAdmin(ctx context.Context, authService *services.AuthService, args []string) {
+	if len(args) == 0 {
+		log.Fatalf("Usage: create-admin <login>")
+	}
+
+	login := args[0]
+
+	if _, err := authService.GetUser(ctx, login); err != nil {
+		if !errors.Is(err, services.ErrUserIsNotExist) {
+			log.Fatalf("User wasn't found due to %s", err)
+		}
+
+		password := getEnvSecret("ADMIN_PASSWORD")
+
+		if password == "" {
+			log.Fatalf("ADMIN_PASSWORD has to be defined to register %s", login)
+		}
+
+		if err := authService.Register(ctx, models.UnknownUser{Login: &login, Password: &password}); err != nil {
+			log.Fatalf("User wasn't registered due to %s", err)
+		}
+
+		log.Printf("Registered %s\n", login)
+	}
+
+	if err := authService.SetUserRole(ctx, login, models.RoleAdmin); err != nil {
+		log.Fatalf("Admin role wasn't granted due to %s", err)
+	}
+
+	log.Printf("Granted admin role to %s\n", login)
+}
@@ -0,0 +1,64 @@
+package accrual
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/logger"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/middlewares"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/utils"
+	"go.uber.org/zap"
+)
+
+// Notifier pushes order status changes to the loyalty system callback endpoint.
+type Notifier struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+func NewNotifier(url, secret string) *Notifier {
+	return &Notifier{
+		url:    url,
+		secret: secret,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (n *Notifier) Notify(order Order) {
+	body, err := json.Marshal(orderResponse{ID: order.ID, Status: order.Status, Accrual: order.Accrual})
+
+	if err != nil {
+		logger.Log.Error("failed to encode notification", zap.String("orderID", order.ID), zap.Error(err))
+		return
+	}
+
+	req, err := http.NewRequest(http.MethodPost, n.url, bytes.NewReader(body))
+
+	if err != nil {
+		logger.Log.Error("failed to create notification request", zap.String("orderID", order.ID), zap.Error(err))
+		return
+	}
+
+	timestamp := time.Now().Unix()
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(middlewares.SignatureTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(middlewares.SignatureHeader, utils.SignPayload(n.secret, timestamp, body))
+
+	res, err := n.client.Do(req)
+
+	if err != nil {
+		logger.Log.Error("failed to send notification", zap.String("orderID", order.ID), zap.Error(err))
+		return
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		logger.Log.Error("notification was rejected", zap.String("orderID", order.ID), zap.Int("status", res.StatusCode))
+	}
+}
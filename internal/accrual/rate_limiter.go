@@ -0,0 +1,60 @@
+package accrual
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type RateLimiter struct {
+	mu          sync.Mutex
+	limit       int
+	windowStart time.Time
+	count       int
+}
+
+func NewRateLimiter(limit int) *RateLimiter {
+	return &RateLimiter{limit: limit}
+}
+
+func (rl *RateLimiter) allow() (bool, time.Duration) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := time.Now()
+
+	if now.Sub(rl.windowStart) >= time.Minute {
+		rl.windowStart = now
+		rl.count = 0
+	}
+
+	if rl.count >= rl.limit {
+		return false, time.Minute - now.Sub(rl.windowStart)
+	}
+
+	rl.count++
+
+	return true, 0
+}
+
+func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rl.limit <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ok, retryAfter := rl.allow()
+
+		if !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			http.Error(w, fmt.Sprintf("No more than %d requests per minute allowed", rl.limit), http.StatusTooManyRequests)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
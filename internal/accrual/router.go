@@ -0,0 +1,122 @@
+package accrual
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/logger"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/middlewares"
+	"github.com/go-chi/chi/v5"
+)
+
+type Config struct {
+	Endpoint  string
+	RateLimit int
+}
+
+type Router struct {
+	config  Config
+	service *Service
+}
+
+type orderRegistration struct {
+	ID    *string `json:"order"`
+	Goods []Goods `json:"goods"`
+}
+
+type orderResponse struct {
+	ID      string      `json:"order"`
+	Status  OrderStatus `json:"status"`
+	Accrual *float64    `json:"accrual,omitempty"`
+}
+
+func NewRouter(config Config, service *Service) *Router {
+	return &Router{config, service}
+}
+
+func (router *Router) get() chi.Router {
+	r := chi.NewRouter()
+
+	r.Use(logger.RequestLogger)
+
+	r.Route("/api", func(r chi.Router) {
+		r.With(NewRateLimiter(router.config.RateLimit).Middleware).Get("/orders/{number}", router.getOrder)
+		r.With(middlewares.JSONMiddleware[orderRegistration]).Post("/orders", router.registerOrder)
+		r.With(middlewares.JSONMiddleware[RewardRule]).Post("/goods", router.registerRewardRule)
+	})
+
+	return r
+}
+
+func (router *Router) Run() {
+	log.Fatal(http.ListenAndServe(router.config.Endpoint, router.get()))
+}
+
+func (router *Router) getOrder(w http.ResponseWriter, r *http.Request) {
+	order, err := router.service.GetOrder(chi.URLParam(r, "number"))
+
+	if err != nil {
+		if errors.Is(err, ErrOrderIsNotExist) {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		http.Error(w, fmt.Sprintf("Error occurred during getting order: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	middlewares.EncodeJSONResponse(w, orderResponse{
+		ID:      order.ID,
+		Status:  order.Status,
+		Accrual: order.Accrual,
+	})
+}
+
+func (router *Router) registerOrder(w http.ResponseWriter, r *http.Request) {
+	data := middlewares.GetParsedJSONData[orderRegistration](w, r)
+
+	if data.ID == nil {
+		http.Error(w, "Request doesn't contain order", http.StatusBadRequest)
+		return
+	}
+
+	if err := router.service.RegisterOrder(*data.ID, data.Goods); err != nil {
+		if errors.Is(err, ErrInvalidOrderID) || errors.Is(err, ErrInvalidGoods) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if errors.Is(err, ErrDuplicateOrder) {
+			http.Error(w, "Order is already registered", http.StatusConflict)
+			return
+		}
+
+		http.Error(w, fmt.Sprintf("Error occurred during registering order: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (router *Router) registerRewardRule(w http.ResponseWriter, r *http.Request) {
+	data := middlewares.GetParsedJSONData[RewardRule](w, r)
+
+	if err := router.service.RegisterRewardRule(data); err != nil {
+		if errors.Is(err, ErrInvalidRule) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if errors.Is(err, ErrDuplicateGoods) {
+			http.Error(w, "Reward rule is already registered", http.StatusConflict)
+			return
+		}
+
+		http.Error(w, fmt.Sprintf("Error occurred during registering reward rule: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
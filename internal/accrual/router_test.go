@@ -0,0 +1,158 @@
+package accrual
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccrualRoutes(t *testing.T) {
+	testServer := httptest.NewServer(
+		NewRouter(Config{RateLimit: 100}, NewService(NewStorage(), 10*time.Millisecond, nil)).get(),
+	)
+	defer testServer.Close()
+
+	testCases := []struct {
+		testName        string
+		methodName      string
+		targetURL       string
+		body            func() io.Reader
+		wait            time.Duration
+		expectedCode    int
+		expectedMessage string
+	}{
+		{
+			testName:     "Should return no content for unknown order",
+			methodName:   "GET",
+			targetURL:    "/api/orders/12345678903",
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			testName:   "Should reject invalid reward rule",
+			methodName: "POST",
+			targetURL:  "/api/goods",
+			body: func() io.Reader {
+				data, _ := json.Marshal(RewardRule{Match: "Bork", Reward: 10, RewardType: "unknown"})
+				return bytes.NewBuffer(data)
+			},
+			expectedCode:    http.StatusBadRequest,
+			expectedMessage: "reward rule is invalid\n",
+		},
+		{
+			testName:   "Should register reward rule",
+			methodName: "POST",
+			targetURL:  "/api/goods",
+			body: func() io.Reader {
+				data, _ := json.Marshal(RewardRule{Match: "Bork", Reward: 10, RewardType: RewardTypePercent})
+				return bytes.NewBuffer(data)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			testName:   "Should reject duplicated reward rule",
+			methodName: "POST",
+			targetURL:  "/api/goods",
+			body: func() io.Reader {
+				data, _ := json.Marshal(RewardRule{Match: "Bork", Reward: 5, RewardType: RewardTypePoints})
+				return bytes.NewBuffer(data)
+			},
+			expectedCode:    http.StatusConflict,
+			expectedMessage: "Reward rule is already registered\n",
+		},
+		{
+			testName:   "Should reject order with invalid number",
+			methodName: "POST",
+			targetURL:  "/api/orders",
+			body: func() io.Reader {
+				return bytes.NewBufferString(`{"order":"12345678900","goods":[{"description":"Чайник Bork","price":7000}]}`)
+			},
+			expectedCode:    http.StatusBadRequest,
+			expectedMessage: "order id is invalid\n",
+		},
+		{
+			testName:   "Should register order",
+			methodName: "POST",
+			targetURL:  "/api/orders",
+			body: func() io.Reader {
+				return bytes.NewBufferString(`{"order":"12345678903","goods":[{"description":"Чайник Bork","price":7000}]}`)
+			},
+			expectedCode: http.StatusAccepted,
+		},
+		{
+			testName:   "Should reject duplicated order",
+			methodName: "POST",
+			targetURL:  "/api/orders",
+			body: func() io.Reader {
+				return bytes.NewBufferString(`{"order":"12345678903","goods":[]}`)
+			},
+			expectedCode:    http.StatusConflict,
+			expectedMessage: "Order is already registered\n",
+		},
+		{
+			testName:        "Should return registered order",
+			methodName:      "GET",
+			targetURL:       "/api/orders/12345678903",
+			expectedCode:    http.StatusOK,
+			expectedMessage: `{"order":"12345678903","status":"REGISTERED"}`,
+		},
+		{
+			testName:        "Should return processed order with accrual",
+			methodName:      "GET",
+			targetURL:       "/api/orders/12345678903",
+			wait:            100 * time.Millisecond,
+			expectedCode:    http.StatusOK,
+			expectedMessage: `{"order":"12345678903","status":"PROCESSED","accrual":700}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			var body io.Reader
+
+			if tc.body != nil {
+				body = tc.body()
+			}
+
+			time.Sleep(tc.wait)
+
+			res, mes := utils.TestRequest(
+				t,
+				testServer,
+				tc.methodName,
+				tc.targetURL,
+				map[string]string{"Content-Type": "application/json"},
+				body,
+			)
+			res.Body.Close()
+
+			assert.Equal(t, tc.expectedCode, res.StatusCode)
+			assert.Equal(t, tc.expectedMessage, mes)
+		})
+	}
+}
+
+func TestAccrualRateLimit(t *testing.T) {
+	testServer := httptest.NewServer(
+		NewRouter(Config{RateLimit: 1}, NewService(NewStorage(), time.Second, nil)).get(),
+	)
+	defer testServer.Close()
+
+	res, _ := utils.TestRequest(t, testServer, "GET", "/api/orders/12345678903", nil, nil)
+	res.Body.Close()
+
+	assert.Equal(t, http.StatusNoContent, res.StatusCode)
+
+	res, mes := utils.TestRequest(t, testServer, "GET", "/api/orders/12345678903", nil, nil)
+	res.Body.Close()
+
+	assert.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+	assert.Equal(t, "No more than 1 requests per minute allowed\n", mes)
+	assert.NotEmpty(t, res.Header.Get("Retry-After"))
+}
@@ -0,0 +1,140 @@
+package accrual
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/logger"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/utils"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrInvalidOrderID  = errors.New("order id is invalid")
+	ErrInvalidGoods    = errors.New("goods are invalid")
+	ErrInvalidRule     = errors.New("reward rule is invalid")
+	ErrOrderIsNotExist = errors.New("order is not exist")
+)
+
+type Service struct {
+	storage         *Storage
+	processingDelay time.Duration
+	notifier        *Notifier
+}
+
+func NewService(storage *Storage, processingDelay time.Duration, notifier *Notifier) *Service {
+	return &Service{
+		storage:         storage,
+		processingDelay: processingDelay,
+		notifier:        notifier,
+	}
+}
+
+func (s *Service) RegisterOrder(orderID string, goods []Goods) error {
+	if !utils.IsLuhnValid(orderID) {
+		return ErrInvalidOrderID
+	}
+
+	for _, item := range goods {
+		if item.Price < 0 {
+			return ErrInvalidGoods
+		}
+	}
+
+	if err := s.storage.CreateOrder(Order{ID: orderID, Goods: goods, Status: StatusRegistered}); err != nil {
+		return err
+	}
+
+	time.AfterFunc(s.processingDelay, func() {
+		s.process(orderID)
+	})
+
+	return nil
+}
+
+func (s *Service) RegisterRewardRule(rule RewardRule) error {
+	if rule.Match == "" || rule.Reward < 0 {
+		return ErrInvalidRule
+	}
+
+	if rule.RewardType != RewardTypePercent && rule.RewardType != RewardTypePoints {
+		return ErrInvalidRule
+	}
+
+	return s.storage.CreateRewardRule(rule)
+}
+
+func (s *Service) GetOrder(orderID string) (*Order, error) {
+	order := s.storage.FindOrder(orderID)
+
+	if order == nil {
+		return nil, ErrOrderIsNotExist
+	}
+
+	return order, nil
+}
+
+func (s *Service) process(orderID string) {
+	order := s.storage.FindOrder(orderID)
+
+	if order == nil {
+		return
+	}
+
+	if len(order.Goods) == 0 {
+		s.updateOrder(orderID, StatusInvalid, nil)
+		logger.Log.Info("order is invalid", zap.String("orderID", orderID))
+		return
+	}
+
+	s.updateOrder(orderID, StatusProcessing, nil)
+	logger.Log.Info("order is processing", zap.String("orderID", orderID))
+
+	time.AfterFunc(s.processingDelay, func() {
+		s.updateOrder(orderID, StatusProcessed, s.calculate(order.Goods))
+		logger.Log.Info("order is processed", zap.String("orderID", orderID))
+	})
+}
+
+func (s *Service) updateOrder(orderID string, status OrderStatus, accrual *float64) {
+	s.storage.UpdateOrder(orderID, status, accrual)
+
+	if s.notifier != nil {
+		go s.notifier.Notify(Order{ID: orderID, Status: status, Accrual: accrual})
+	}
+}
+
+func (s *Service) calculate(goods []Goods) *float64 {
+	rules := s.storage.FindRewardRules()
+
+	var total float64
+	var matched bool
+
+	for _, item := range goods {
+		for _, rule := range rules {
+			if !strings.Contains(strings.ToLower(item.Description), strings.ToLower(rule.Match)) {
+				continue
+			}
+
+			matched = true
+
+			if rule.RewardType == RewardTypePercent {
+				total += item.Price * rule.Reward / 100
+			} else {
+				total += rule.Reward
+			}
+
+			break
+		}
+	}
+
+	if !matched {
+		return nil
+	}
+
+	total = math.Round(total*100) / 100
+
+	return &total
+}
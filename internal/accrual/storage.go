@@ -0,0 +1,124 @@
+package accrual
+
+import (
+	"errors"
+	"sync"
+)
+
+var (
+	ErrDuplicateOrder = errors.New("order is duplicated")
+	ErrDuplicateGoods = errors.New("goods match is duplicated")
+)
+
+type OrderStatus string
+
+const (
+	StatusRegistered OrderStatus = "REGISTERED"
+	StatusInvalid    OrderStatus = "INVALID"
+	StatusProcessing OrderStatus = "PROCESSING"
+	StatusProcessed  OrderStatus = "PROCESSED"
+)
+
+type RewardType string
+
+const (
+	RewardTypePercent RewardType = "%"
+	RewardTypePoints  RewardType = "pt"
+)
+
+type Goods struct {
+	Description string  `json:"description"`
+	Price       float64 `json:"price"`
+}
+
+type Order struct {
+	ID      string
+	Goods   []Goods
+	Status  OrderStatus
+	Accrual *float64
+}
+
+type RewardRule struct {
+	Match      string     `json:"match"`
+	Reward     float64    `json:"reward"`
+	RewardType RewardType `json:"reward_type"`
+}
+
+type Storage struct {
+	mu     sync.RWMutex
+	orders map[string]*Order
+	rules  []RewardRule
+}
+
+func NewStorage() *Storage {
+	return &Storage{
+		orders: make(map[string]*Order),
+	}
+}
+
+func (s *Storage) CreateOrder(order Order) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.orders[order.ID]; ok {
+		return ErrDuplicateOrder
+	}
+
+	s.orders[order.ID] = &order
+
+	return nil
+}
+
+func (s *Storage) FindOrder(orderID string) *Order {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	order, ok := s.orders[orderID]
+
+	if !ok {
+		return nil
+	}
+
+	result := *order
+
+	return &result
+}
+
+func (s *Storage) UpdateOrder(orderID string, status OrderStatus, accrual *float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, ok := s.orders[orderID]
+
+	if !ok {
+		return
+	}
+
+	order.Status = status
+	order.Accrual = accrual
+}
+
+func (s *Storage) CreateRewardRule(rule RewardRule) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, r := range s.rules {
+		if r.Match == rule.Match {
+			return ErrDuplicateGoods
+		}
+	}
+
+	s.rules = append(s.rules, rule)
+
+	return nil
+}
+
+func (s *Storage) FindRewardRules() []RewardRule {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]RewardRule, len(s.rules))
+	copy(result, s.rules)
+
+	return result
+}
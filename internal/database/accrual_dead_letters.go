@@ -0,0 +1,155 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	UpdateAccrualGaveUpQuery = `
+		UPDATE
+			orders
+		SET
+			accrual_gave_up_at = current_timestamp,
+			accrual_last_error = $2
+		WHERE
+		    id = $1
+		RETURNING
+			accrual_attempts
+	`
+	UpsertDeadLetterQuery = `
+		INSERT INTO
+			accrual_dead_letters (order_id, last_error, attempts)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (order_id) DO UPDATE
+		SET
+			last_error = EXCLUDED.last_error,
+			attempts = EXCLUDED.attempts,
+			updated_at = current_timestamp
+	`
+	SelectDeadLettersQuery = `
+		SELECT
+			order_id,
+			last_error,
+			attempts,
+			created_at,
+			updated_at
+		FROM
+		    accrual_dead_letters
+		ORDER BY
+		    created_at
+	`
+	DeleteDeadLetterQuery = `
+		DELETE FROM
+			accrual_dead_letters
+		WHERE
+		    order_id = $1
+	`
+	ResetAccrualGaveUpQuery = `
+		UPDATE
+			orders
+		SET
+			accrual_attempts = 0,
+			accrual_last_error = NULL,
+			accrual_gave_up_at = NULL
+		WHERE
+		    id = $1
+	`
+)
+
+type DeadLetterDB struct {
+	OrderID   string
+	LastError string
+	Attempts  int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// MarkAccrualGaveUp stops polling the order and records it in the dead-letter store.
+func (d *Database) MarkAccrualGaveUp(ctx context.Context, orderID, lastError string) error {
+	tx, err := d.db.Begin(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	var attempts int
+
+	if err := tx.QueryRow(ctx, UpdateAccrualGaveUpQuery, orderID, lastError).Scan(&attempts); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, UpsertDeadLetterQuery, orderID, lastError, attempts); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (d *Database) FindDeadLetters(ctx context.Context) (*[]DeadLetterDB, error) {
+	var result []DeadLetterDB
+
+	rows, err := d.db.Query(ctx, SelectDeadLettersQuery)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var item DeadLetterDB
+
+		if err := rows.Scan(&item.OrderID, &item.LastError, &item.Attempts, &item.CreatedAt, &item.UpdatedAt); err != nil {
+			return nil, err
+		}
+
+		result = append(result, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// ReviveDeadLetter removes the dead letter and makes the order eligible for polling again.
+// It reports false when there is no dead letter for the order.
+func (d *Database) ReviveDeadLetter(ctx context.Context, orderID string) (bool, error) {
+	tx, err := d.db.Begin(ctx)
+
+	if err != nil {
+		return false, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, DeleteDeadLetterQuery, orderID)
+
+	if err != nil {
+		return false, err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	if _, err := tx.Exec(ctx, ResetAccrualGaveUpQuery, orderID); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit(ctx)
+}
+
+func (d *Database) DeleteDeadLetter(ctx context.Context, orderID string) (bool, error) {
+	tag, err := d.db.Exec(ctx, DeleteDeadLetterQuery, orderID)
+
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
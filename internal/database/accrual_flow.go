@@ -2,7 +2,10 @@ package database
 
 import (
 	"context"
-	"time"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/utils"
+	"github.com/jackc/pgx/v5"
 )
 
 const (
@@ -10,58 +13,48 @@ const (
 		INSERT INTO
 			accrual_flow (order_id, amount)
 		VALUES ($1, $2)
-	`
-	SelectAccrualFlowQuery = `
-		SELECT
-		    order_id,
-			amount,
-		    processed_at
-		FROM
-		    accrual_flow af
-			LEFT JOIN orders o ON af.order_id = o.id
-		WHERE
-		    user_id = $1
+		ON CONFLICT (order_id) DO NOTHING
 	`
 )
 
-type AccrualFlowItemDB struct {
-	OrderID     string
-	Amount      float64
-	ProcessedAt time.Time
-}
+// CreateAccrual stores the accrual once per order and adds it to the balance of the order's owner.
+func (d *Database) CreateAccrual(ctx context.Context, orderID string, amount utils.Money) error {
+	tx, err := d.db.Begin(ctx)
 
-func (d *Database) CreateAccrual(ctx context.Context, orderID string, amount float64) error {
-	if _, err := d.db.Exec(ctx, InsertAccrualQuery, orderID, amount); err != nil {
+	if err != nil {
 		return err
 	}
 
-	return nil
-}
+	defer tx.Rollback(ctx)
+
+	if err := createAccrual(ctx, tx, orderID, amount); err != nil {
+		return err
+	}
 
-func (d *Database) FindAccrualFlow(ctx context.Context, userID string) (*[]AccrualFlowItemDB, error) {
-	var result []AccrualFlowItemDB
+	return tx.Commit(ctx)
+}
 
-	rows, err := d.db.Query(ctx, SelectAccrualFlowQuery, userID)
+func createAccrual(ctx context.Context, tx pgx.Tx, orderID string, amount utils.Money) error {
+	tag, err := tx.Exec(ctx, InsertAccrualQuery, orderID, amount)
 
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	defer rows.Close()
-
-	for rows.Next() {
-		var item AccrualFlowItemDB
+	if tag.RowsAffected() == 0 {
+		return nil
+	}
 
-		if err := rows.Scan(&item.OrderID, &item.Amount, &item.ProcessedAt); err != nil {
-			return nil, err
-		}
+	var userID string
+	var balance models.Balance
 
-		result = append(result, item)
+	if err := tx.QueryRow(ctx, AddAccrualToBalanceQuery, orderID, amount).Scan(&userID, &balance.Current, &balance.Withdrawn); err != nil {
+		return err
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, err
+	if err := createUserEvent(ctx, tx, userID, UserEventBalanceChanged, balance); err != nil {
+		return err
 	}
 
-	return &result, nil
+	return createWebhookDeliveries(ctx, tx, userID, models.WebhookEventOrderAccrued, models.OrderAccruedEvent{OrderID: orderID, Accrual: amount})
 }
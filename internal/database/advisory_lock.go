@@ -0,0 +1,55 @@
+package database
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	TryAdvisoryLockQuery = `SELECT pg_try_advisory_lock($1)`
+	AdvisoryUnlockQuery  = `SELECT pg_advisory_unlock($1)`
+)
+
+// AdvisoryLock is a session level advisory lock bound to a dedicated connection.
+// Postgres releases the lock by itself when the connection is lost.
+type AdvisoryLock struct {
+	conn *pgxpool.Conn
+	key  int64
+}
+
+func (d *Database) TryAdvisoryLock(ctx context.Context, key int64) (*AdvisoryLock, error) {
+	conn, err := d.db.Acquire(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var acquired bool
+
+	if err := conn.QueryRow(ctx, TryAdvisoryLockQuery, key).Scan(&acquired); err != nil {
+		conn.Release()
+		return nil, err
+	}
+
+	if !acquired {
+		conn.Release()
+		return nil, nil
+	}
+
+	return &AdvisoryLock{conn, key}, nil
+}
+
+func (l *AdvisoryLock) Ping(ctx context.Context) error {
+	return l.conn.Ping(ctx)
+}
+
+func (l *AdvisoryLock) Release(ctx context.Context) error {
+	defer l.conn.Release()
+
+	if _, err := l.conn.Exec(ctx, AdvisoryUnlockQuery, l.key); err != nil {
+		return l.conn.Conn().Close(ctx)
+	}
+
+	return nil
+}
@@ -0,0 +1,32 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdvisoryLockIsExclusive(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+	key := time.Now().UnixNano()
+
+	lock, err := db.TryAdvisoryLock(ctx, key)
+	require.NoError(t, err)
+	require.NotNil(t, lock)
+
+	other, err := db.TryAdvisoryLock(ctx, key)
+	require.NoError(t, err)
+	assert.Nil(t, other)
+
+	require.NoError(t, lock.Ping(ctx))
+	require.NoError(t, lock.Release(ctx))
+
+	other, err = db.TryAdvisoryLock(ctx, key)
+	require.NoError(t, err)
+	require.NotNil(t, other)
+	require.NoError(t, other.Release(ctx))
+}
@@ -0,0 +1,184 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	InsertAPIKeyQuery = `
+		INSERT INTO
+			api_keys (user_id, name, prefix, key_hash, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING
+			id,
+			created_at
+	`
+	SelectAPIKeysQuery = `
+		SELECT
+			id,
+			user_id,
+			name,
+			prefix,
+			scopes,
+			expires_at,
+			last_used_at,
+			created_at
+		FROM
+			api_keys
+		WHERE
+			user_id = $1
+			AND revoked_at IS NULL
+		ORDER BY
+			created_at
+	`
+	RevokeAPIKeyQuery = `
+		UPDATE
+			api_keys
+		SET
+			revoked_at = current_timestamp
+		WHERE
+			id = $1
+			AND user_id = $2
+			AND revoked_at IS NULL
+	`
+	UseAPIKeyQuery = `
+		UPDATE
+			api_keys k
+		SET
+			last_used_at = CASE
+				WHEN k.expires_at IS NULL OR k.expires_at > current_timestamp THEN current_timestamp
+				ELSE k.last_used_at
+			END
+		FROM
+			users u
+		WHERE
+			u.id = k.user_id
+			AND k.key_hash = $1
+			AND k.revoked_at IS NULL
+		RETURNING
+			k.id,
+			k.user_id,
+			u.login,
+			k.scopes,
+			k.expires_at
+	`
+)
+
+type APIKeyDB struct {
+	ID         string
+	UserID     string
+	Name       string
+	Prefix     string
+	Scopes     []string
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+}
+
+// UsedAPIKeyDB is an active API key together with the login of its owner.
+type UsedAPIKeyDB struct {
+	ID        string
+	UserID    string
+	Login     string
+	Scopes    []string
+	ExpiresAt *time.Time
+}
+
+func (d *Database) CreateAPIKey(
+	ctx context.Context,
+	userID, name, prefix, keyHash string,
+	scopes []string,
+	expiresAt *time.Time,
+) (*APIKeyDB, error) {
+	// Timestamp columns keep no time zone, so the expiry is stored in UTC.
+	if expiresAt != nil {
+		utc := expiresAt.UTC()
+		expiresAt = &utc
+	}
+
+	apiKey := &APIKeyDB{UserID: userID, Name: name, Prefix: prefix, Scopes: scopes, ExpiresAt: expiresAt}
+
+	if err := d.db.QueryRow(ctx, InsertAPIKeyQuery, userID, name, prefix, keyHash, scopes, expiresAt).Scan(&apiKey.ID, &apiKey.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	return apiKey, nil
+}
+
+// FindAPIKeys returns the API keys of the user that aren't revoked, including expired ones.
+func (d *Database) FindAPIKeys(ctx context.Context, userID string) (*[]APIKeyDB, error) {
+	var result []APIKeyDB
+
+	rows, err := d.db.Query(ctx, SelectAPIKeysQuery, userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var item APIKeyDB
+
+		if err := rows.Scan(
+			&item.ID,
+			&item.UserID,
+			&item.Name,
+			&item.Prefix,
+			&item.Scopes,
+			&item.ExpiresAt,
+			&item.LastUsedAt,
+			&item.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		result = append(result, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// RevokeAPIKey returns false when the user has no such active key.
+func (d *Database) RevokeAPIKey(ctx context.Context, keyID, userID string) (bool, error) {
+	tag, err := d.db.Exec(ctx, RevokeAPIKeyQuery, keyID, userID)
+
+	if err != nil {
+		if isInvalidID(err) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// UseAPIKey finds the active key by its hash and updates its last usage unless it is expired.
+func (d *Database) UseAPIKey(ctx context.Context, keyHash string) (*UsedAPIKeyDB, error) {
+	apiKey := &UsedAPIKeyDB{}
+
+	if err := d.db.QueryRow(ctx, UseAPIKeyQuery, keyHash).Scan(
+		&apiKey.ID,
+		&apiKey.UserID,
+		&apiKey.Login,
+		&apiKey.Scopes,
+		&apiKey.ExpiresAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return apiKey, nil
+}
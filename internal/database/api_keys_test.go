@@ -0,0 +1,40 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateAPIKeyStoresExpiryInUTC(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+	login := fmt.Sprintf("api-key-%d", time.Now().UnixNano())
+
+	require.NoError(t, db.CreateUser(ctx, UserDB{User: models.User{Login: login, Hash: "hash"}}))
+
+	user, err := db.FindUser(ctx, login)
+	require.NoError(t, err)
+
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second).In(time.FixedZone("UTC+3", 3*60*60))
+	keyHash := fmt.Sprintf("hash-%d", time.Now().UnixNano())
+
+	_, err = db.CreateAPIKey(ctx, user.ID, "ci", "gmk_test", keyHash, []string{"orders:read"}, &expiresAt)
+	require.NoError(t, err)
+
+	apiKeys, err := db.FindAPIKeys(ctx, user.ID)
+	require.NoError(t, err)
+	require.Len(t, *apiKeys, 1)
+	require.NotNil(t, (*apiKeys)[0].ExpiresAt)
+
+	assert.True(t, expiresAt.Equal(*(*apiKeys)[0].ExpiresAt), "stored %s, expected %s", (*apiKeys)[0].ExpiresAt, expiresAt)
+
+	used, err := db.UseAPIKey(ctx, keyHash)
+	require.NoError(t, err)
+	require.NotNil(t, used)
+}
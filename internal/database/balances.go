@@ -0,0 +1,148 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/utils"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	SelectBalanceQuery = `
+		SELECT
+			current,
+			withdrawn
+		FROM
+			balances
+		WHERE
+			user_id = $1
+	`
+	EnsureBalanceQuery = `
+		INSERT INTO
+			balances (user_id)
+		VALUES ($1)
+		ON CONFLICT (user_id) DO NOTHING
+	`
+	LockBalanceQuery = `
+		SELECT
+			current
+		FROM
+			balances
+		WHERE
+			user_id = $1
+		FOR UPDATE
+	`
+	AddAccrualToBalanceQuery = `
+		INSERT INTO
+			balances (user_id, current)
+		SELECT
+			user_id,
+			$2
+		FROM
+			orders
+		WHERE
+			id = $1
+		ON CONFLICT (user_id) DO UPDATE
+		SET
+			current = balances.current + EXCLUDED.current,
+			updated_at = current_timestamp
+		RETURNING
+			user_id,
+			current,
+			withdrawn
+	`
+	AddWithdrawalToBalanceQuery = `
+		UPDATE
+			balances
+		SET
+			current = current - $2,
+			withdrawn = withdrawn + $2,
+			updated_at = current_timestamp
+		WHERE
+			user_id = $1
+		RETURNING
+			current,
+			withdrawn
+	`
+	LockBalancesQuery = `
+		LOCK TABLE balances IN SHARE ROW EXCLUSIVE MODE
+	`
+	RebuildBalancesQuery = `
+		INSERT INTO
+			balances (user_id, current, withdrawn)
+		SELECT
+			u.id,
+			COALESCE(a.amount, 0) - COALESCE(w.amount, 0),
+			COALESCE(w.amount, 0)
+		FROM
+			users u
+			LEFT JOIN (
+				SELECT
+					o.user_id,
+					SUM(af.amount) AS amount
+				FROM
+					accrual_flow af
+					JOIN orders o ON af.order_id = o.id
+				GROUP BY
+					o.user_id
+			) a ON a.user_id = u.id
+			LEFT JOIN (
+				SELECT
+					user_id,
+					SUM(amount) AS amount
+				FROM
+					withdrawal_flow
+				GROUP BY
+					user_id
+			) w ON w.user_id = u.id
+		ON CONFLICT (user_id) DO UPDATE
+		SET
+			current = EXCLUDED.current,
+			withdrawn = EXCLUDED.withdrawn,
+			updated_at = current_timestamp
+	`
+)
+
+type BalanceDB struct {
+	Current   utils.Money
+	Withdrawn utils.Money
+}
+
+func (d *Database) FindBalance(ctx context.Context, userID string) (*BalanceDB, error) {
+	balance := &BalanceDB{}
+
+	if err := d.db.QueryRow(ctx, SelectBalanceQuery, userID).Scan(&balance.Current, &balance.Withdrawn); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return balance, nil
+}
+
+// RebuildBalances recomputes every stored balance from the accrual and withdrawal flows.
+// It returns the number of rebuilt balances.
+func (d *Database) RebuildBalances(ctx context.Context) (int64, error) {
+	tx, err := d.db.Begin(ctx)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, LockBalancesQuery); err != nil {
+		return 0, err
+	}
+
+	tag, err := tx.Exec(ctx, RebuildBalancesQuery)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return tag.RowsAffected(), tx.Commit(ctx)
+}
@@ -0,0 +1,65 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func assertBalanceMatchesFlows(t *testing.T, db *Database, userID string) {
+	ctx := context.Background()
+
+	var accrued utils.Money
+
+	require.NoError(t, db.db.QueryRow(ctx, `
+		SELECT
+			COALESCE(SUM(af.amount), 0)
+		FROM
+			accrual_flow af
+			JOIN orders o ON af.order_id = o.id
+		WHERE
+			o.user_id = $1
+	`, userID).Scan(&accrued))
+
+	withdrawalFlow, err := db.FindWithdrawalFlow(ctx, userID, models.ListFilter{})
+	require.NoError(t, err)
+
+	var withdrawn utils.Money
+
+	for _, item := range *withdrawalFlow {
+		withdrawn += item.Amount
+	}
+
+	balance, err := db.FindBalance(ctx, userID)
+	require.NoError(t, err)
+	require.NotNil(t, balance)
+
+	assert.Equal(t, accrued-withdrawn, balance.Current)
+	assert.Equal(t, withdrawn, balance.Withdrawn)
+}
+
+func TestBalanceMatchesFlows(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+	userID := createTestUserWithAccrual(t, db, utils.Money(10050))
+
+	assertBalanceMatchesFlows(t, db, userID)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, db.CreateWithdrawal(ctx, fmt.Sprintf("withdrawal-%s-%d", userID, i), userID, utils.Money(1234)))
+		assertBalanceMatchesFlows(t, db, userID)
+	}
+
+	_, err := db.db.Exec(ctx, `UPDATE balances SET current = 0, withdrawn = 0 WHERE user_id = $1`, userID)
+	require.NoError(t, err)
+
+	_, err = db.RebuildBalances(ctx)
+	require.NoError(t, err)
+
+	assertBalanceMatchesFlows(t, db, userID)
+}
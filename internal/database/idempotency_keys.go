@@ -0,0 +1,129 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	DeleteExpiredIdempotencyKeysQuery = `
+		DELETE FROM
+			idempotency_keys
+		WHERE
+			user_id = $1
+			AND expires_at < current_timestamp
+	`
+	InsertIdempotencyKeyQuery = `
+		INSERT INTO
+			idempotency_keys (user_id, key, request_hash, expires_at)
+		VALUES ($1, $2, $3, current_timestamp + $4::bigint * interval '1 millisecond')
+		ON CONFLICT (user_id, key) DO NOTHING
+	`
+	SelectIdempotencyKeyQuery = `
+		SELECT
+			request_hash,
+			status_code,
+			content_type,
+			response_body
+		FROM
+			idempotency_keys
+		WHERE
+			user_id = $1
+			AND key = $2
+	`
+	UpdateIdempotencyKeyResponseQuery = `
+		UPDATE
+			idempotency_keys
+		SET
+			status_code = $3,
+			content_type = $4,
+			response_body = $5,
+			expires_at = current_timestamp + $6::bigint * interval '1 millisecond'
+		WHERE
+			user_id = $1
+			AND key = $2
+	`
+	DeleteIdempotencyKeyQuery = `
+		DELETE FROM
+			idempotency_keys
+		WHERE
+			user_id = $1
+			AND key = $2
+	`
+)
+
+type IdempotencyKeyDB struct {
+	RequestHash  string
+	StatusCode   *int
+	ContentType  *string
+	ResponseBody []byte
+}
+
+// ClaimIdempotencyKey reserves the key for the user until lockTimeout passes.
+// It returns nil when the key has been reserved, otherwise the already stored key.
+func (d *Database) ClaimIdempotencyKey(ctx context.Context, userID, key, requestHash string, lockTimeout time.Duration) (*IdempotencyKeyDB, error) {
+	if _, err := d.db.Exec(ctx, DeleteExpiredIdempotencyKeysQuery, userID); err != nil {
+		return nil, err
+	}
+
+	item, err := d.claimIdempotencyKey(ctx, userID, key, requestHash, lockTimeout)
+
+	// The key can be released or expire between the insert and the select,
+	// in which case it's free again and is claimed once more.
+	if errors.Is(err, pgx.ErrNoRows) {
+		item, err = d.claimIdempotencyKey(ctx, userID, key, requestHash, lockTimeout)
+	}
+
+	return item, err
+}
+
+func (d *Database) claimIdempotencyKey(ctx context.Context, userID, key, requestHash string, lockTimeout time.Duration) (*IdempotencyKeyDB, error) {
+	tag, err := d.db.Exec(ctx, InsertIdempotencyKeyQuery, userID, key, requestHash, lockTimeout.Milliseconds())
+
+	if err != nil {
+		return nil, err
+	}
+
+	if tag.RowsAffected() == 1 {
+		return nil, nil
+	}
+
+	item := &IdempotencyKeyDB{}
+
+	if err := d.db.QueryRow(ctx, SelectIdempotencyKeyQuery, userID, key).Scan(
+		&item.RequestHash,
+		&item.StatusCode,
+		&item.ContentType,
+		&item.ResponseBody,
+	); err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+func (d *Database) SaveIdempotencyKeyResponse(
+	ctx context.Context,
+	userID, key string,
+	statusCode int,
+	contentType string,
+	body []byte,
+	ttl time.Duration,
+) error {
+	if _, err := d.db.Exec(ctx, UpdateIdempotencyKeyResponseQuery, userID, key, statusCode, contentType, body, ttl.Milliseconds()); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (d *Database) DeleteIdempotencyKey(ctx context.Context, userID, key string) error {
+	if _, err := d.db.Exec(ctx, DeleteIdempotencyKeyQuery, userID, key); err != nil {
+		return err
+	}
+
+	return nil
+}
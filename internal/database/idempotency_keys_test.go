@@ -0,0 +1,63 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClaimIdempotencyKey(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+	login := fmt.Sprintf("idempotency-%d", time.Now().UnixNano())
+
+	require.NoError(t, db.CreateUser(ctx, UserDB{User: models.User{Login: login, Hash: "hash"}}))
+
+	user, err := db.FindUser(ctx, login)
+	require.NoError(t, err)
+
+	item, err := db.ClaimIdempotencyKey(ctx, user.ID, "key", "hash", time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, item)
+
+	item, err = db.ClaimIdempotencyKey(ctx, user.ID, "key", "hash", time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, item)
+	assert.Equal(t, "hash", item.RequestHash)
+	assert.Nil(t, item.StatusCode)
+
+	require.NoError(t, db.DeleteIdempotencyKey(ctx, user.ID, "key"))
+
+	// Keys that are released while others claim them are either claimed or returned, never missing.
+	const workers = 10
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 20; j++ {
+				item, err := db.ClaimIdempotencyKey(ctx, user.ID, "race", "hash", time.Minute)
+
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				if item == nil {
+					assert.NoError(t, db.DeleteIdempotencyKey(ctx, user.ID, "race"))
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,100 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	InsertJobQuery = `
+		INSERT INTO
+			jobs (kind, payload, run_at)
+		SELECT $1, $2, current_timestamp + $3::bigint * interval '1 millisecond'
+		WHERE NOT EXISTS (
+			SELECT
+				1
+			FROM
+				jobs
+			WHERE
+				kind = $1
+				AND payload = $2
+				AND (locked_until IS NULL OR locked_until < current_timestamp)
+		)
+	`
+	ClaimJobQuery = `
+		UPDATE
+			jobs
+		SET
+			locked_until = current_timestamp + $2::bigint * interval '1 millisecond',
+			attempts = attempts + 1
+		WHERE
+			id = (
+				SELECT
+					id
+				FROM
+					jobs
+				WHERE
+					kind = ANY($1)
+					AND run_at <= current_timestamp
+					AND (locked_until IS NULL OR locked_until < current_timestamp)
+				ORDER BY
+					run_at
+				LIMIT 1
+				FOR UPDATE SKIP LOCKED
+			)
+		RETURNING
+			id,
+			kind,
+			payload,
+			attempts
+	`
+	DeleteJobQuery = `
+		DELETE FROM
+			jobs
+		WHERE
+			id = $1
+	`
+)
+
+type JobDB struct {
+	ID       string
+	Kind     string
+	Payload  string
+	Attempts int
+}
+
+// CreateJob schedules the job unless the same job is already waiting to run. The waiting job
+// keeps its run time, so re-enqueuing everything on a restart doesn't cut a backoff short.
+// A job that is being run doesn't count, so it can schedule its own next run.
+func (d *Database) CreateJob(ctx context.Context, kind, payload string, delay time.Duration) error {
+	if _, err := d.db.Exec(ctx, InsertJobQuery, kind, payload, delay.Milliseconds()); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (d *Database) ClaimJob(ctx context.Context, kinds []string, lease time.Duration) (*JobDB, error) {
+	job := &JobDB{}
+
+	if err := d.db.QueryRow(ctx, ClaimJobQuery, kinds, lease.Milliseconds()).Scan(&job.ID, &job.Kind, &job.Payload, &job.Attempts); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return job, nil
+}
+
+func (d *Database) DeleteJob(ctx context.Context, jobID string) error {
+	if _, err := d.db.Exec(ctx, DeleteJobQuery, jobID); err != nil {
+		return err
+	}
+
+	return nil
+}
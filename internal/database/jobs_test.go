@@ -0,0 +1,120 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestJobKind() string {
+	return fmt.Sprintf("test-%d", time.Now().UnixNano())
+}
+
+func TestClaimJobWithLease(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+	kind := newTestJobKind()
+
+	require.NoError(t, db.CreateJob(ctx, kind, "payload", 0))
+
+	job, err := db.ClaimJob(ctx, []string{kind}, time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, job)
+
+	assert.Equal(t, kind, job.Kind)
+	assert.Equal(t, "payload", job.Payload)
+	assert.Equal(t, 1, job.Attempts)
+
+	// The job is leased, so nobody else can claim it.
+	other, err := db.ClaimJob(ctx, []string{kind}, time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, other)
+}
+
+func TestClaimJobAfterLeaseExpires(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+	kind := newTestJobKind()
+
+	require.NoError(t, db.CreateJob(ctx, kind, "payload", 0))
+
+	job, err := db.ClaimJob(ctx, []string{kind}, time.Millisecond)
+	require.NoError(t, err)
+	require.NotNil(t, job)
+
+	time.Sleep(10 * time.Millisecond)
+
+	reclaimed, err := db.ClaimJob(ctx, []string{kind}, time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, reclaimed)
+
+	assert.Equal(t, job.ID, reclaimed.ID)
+	assert.Equal(t, 2, reclaimed.Attempts)
+}
+
+func TestDeleteJobAfterSuccess(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+	kind := newTestJobKind()
+
+	require.NoError(t, db.CreateJob(ctx, kind, "payload", 0))
+
+	job, err := db.ClaimJob(ctx, []string{kind}, time.Millisecond)
+	require.NoError(t, err)
+	require.NotNil(t, job)
+
+	require.NoError(t, db.DeleteJob(ctx, job.ID))
+
+	time.Sleep(10 * time.Millisecond)
+
+	job, err = db.ClaimJob(ctx, []string{kind}, time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, job)
+}
+
+func TestCreateJobWhileClaimed(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+	kind := newTestJobKind()
+
+	require.NoError(t, db.CreateJob(ctx, kind, "payload", 0))
+
+	claimed, err := db.ClaimJob(ctx, []string{kind}, time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, claimed)
+
+	// A handler re-schedules its own job: the claimed row is about to be deleted,
+	// so a new row has to be inserted instead of moving the claimed one.
+	require.NoError(t, db.CreateJob(ctx, kind, "payload", 0))
+	require.NoError(t, db.DeleteJob(ctx, claimed.ID))
+
+	rescheduled, err := db.ClaimJob(ctx, []string{kind}, time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, rescheduled)
+
+	assert.NotEqual(t, claimed.ID, rescheduled.ID)
+	assert.Equal(t, 1, rescheduled.Attempts)
+}
+
+func TestCreateJobKeepsPendingDuplicate(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+	kind := newTestJobKind()
+
+	require.NoError(t, db.CreateJob(ctx, kind, "payload", time.Hour))
+	require.NoError(t, db.CreateJob(ctx, kind, "payload", 0))
+
+	// The job that is backing off is neither duplicated nor moved to now.
+	job, err := db.ClaimJob(ctx, []string{kind}, time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, job)
+
+	var count int
+
+	require.NoError(t, db.db.QueryRow(ctx, `SELECT COUNT(*) FROM jobs WHERE kind = $1`, kind).Scan(&count))
+	assert.Equal(t, 1, count)
+}
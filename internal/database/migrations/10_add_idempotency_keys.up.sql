@@ -0,0 +1,11 @@
+CREATE TABLE idempotency_keys (
+    user_id       uuid REFERENCES users NOT NULL,
+    key           text NOT NULL,
+    request_hash  text NOT NULL,
+    status_code   integer,
+    content_type  text,
+    response_body bytea,
+    created_at    timestamp NOT NULL DEFAULT current_timestamp,
+    expires_at    timestamp NOT NULL,
+    PRIMARY KEY (user_id, key)
+);
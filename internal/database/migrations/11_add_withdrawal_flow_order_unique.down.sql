@@ -0,0 +1,2 @@
+DROP INDEX withdrawal_flow_order_id_idx;
+DROP TABLE withdrawal_flow_duplicates;
@@ -0,0 +1,49 @@
+-- Concurrent requests could withdraw the same order number twice. The earliest withdrawal
+-- of every order is kept, the rest are refunded and kept in withdrawal_flow_duplicates
+-- so that they can be reviewed.
+CREATE TABLE withdrawal_flow_duplicates (
+    id           uuid PRIMARY KEY,
+    order_id     text NOT NULL,
+    user_id      uuid REFERENCES users NOT NULL,
+    amount       numeric(15, 2) NOT NULL,
+    processed_at timestamp NOT NULL,
+    removed_at   timestamp NOT NULL DEFAULT current_timestamp
+);
+
+WITH duplicates AS (
+    DELETE FROM
+        withdrawal_flow a
+    USING
+        withdrawal_flow b
+    WHERE
+        a.order_id = b.order_id
+        AND (a.processed_at, a.id) > (b.processed_at, b.id)
+    RETURNING
+        a.id, a.order_id, a.user_id, a.amount, a.processed_at
+)
+INSERT INTO withdrawal_flow_duplicates (id, order_id, user_id, amount, processed_at)
+SELECT id, order_id, user_id, amount, processed_at FROM duplicates;
+
+-- Balances of the affected users are rebuilt from the flows, like in 9_add_balances.
+UPDATE
+    balances b
+SET
+    current = COALESCE(a.amount, 0) - COALESCE(w.amount, 0),
+    withdrawn = COALESCE(w.amount, 0),
+    updated_at = current_timestamp
+FROM
+    (SELECT DISTINCT user_id FROM withdrawal_flow_duplicates) d
+    LEFT JOIN (
+        SELECT o.user_id, SUM(af.amount) AS amount
+        FROM accrual_flow af JOIN orders o ON af.order_id = o.id
+        GROUP BY o.user_id
+    ) a ON a.user_id = d.user_id
+    LEFT JOIN (
+        SELECT user_id, SUM(amount) AS amount
+        FROM withdrawal_flow
+        GROUP BY user_id
+    ) w ON w.user_id = d.user_id
+WHERE
+    b.user_id = d.user_id;
+
+CREATE UNIQUE INDEX withdrawal_flow_order_id_idx ON withdrawal_flow (order_id);
@@ -0,0 +1,2 @@
+DROP INDEX withdrawal_flow_user_id_processed_at_idx;
+DROP INDEX orders_user_id_uploaded_at_idx;
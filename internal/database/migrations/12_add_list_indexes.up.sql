@@ -0,0 +1,2 @@
+CREATE INDEX orders_user_id_uploaded_at_idx ON orders (user_id, uploaded_at, id);
+CREATE INDEX withdrawal_flow_user_id_processed_at_idx ON withdrawal_flow (user_id, processed_at, order_id);
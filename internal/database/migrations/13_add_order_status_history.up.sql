@@ -0,0 +1,16 @@
+CREATE TABLE order_status_history (
+    id          uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    order_id    text REFERENCES orders NOT NULL,
+    from_status order_status,
+    to_status   order_status NOT NULL,
+    source      text NOT NULL,
+    created_at  timestamp NOT NULL DEFAULT current_timestamp
+);
+
+CREATE INDEX order_status_history_order_id_idx ON order_status_history (order_id, created_at);
+
+INSERT INTO order_status_history (order_id, from_status, to_status, source, created_at)
+SELECT id, NULL, 'NEW', 'upload', uploaded_at FROM orders;
+
+INSERT INTO order_status_history (order_id, from_status, to_status, source)
+SELECT id, 'NEW', status, 'backfill' FROM orders WHERE status <> 'NEW';
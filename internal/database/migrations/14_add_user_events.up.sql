@@ -0,0 +1,10 @@
+CREATE TABLE user_events (
+    id         bigserial PRIMARY KEY,
+    user_id    uuid REFERENCES users NOT NULL,
+    type       text NOT NULL,
+    payload    jsonb NOT NULL,
+    created_at timestamp NOT NULL DEFAULT current_timestamp
+);
+
+CREATE INDEX user_events_user_id_idx ON user_events (user_id, id);
+CREATE INDEX user_events_created_at_idx ON user_events (created_at);
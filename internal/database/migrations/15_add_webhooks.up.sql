@@ -0,0 +1,29 @@
+CREATE TABLE webhooks (
+    id         uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id    uuid REFERENCES users NOT NULL,
+    url        text NOT NULL,
+    secret     text NOT NULL,
+    events     text[] NOT NULL,
+    created_at timestamp NOT NULL DEFAULT current_timestamp
+);
+
+CREATE INDEX webhooks_user_id_idx ON webhooks (user_id);
+
+CREATE TABLE webhook_deliveries (
+    id              uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    webhook_id      uuid REFERENCES webhooks ON DELETE CASCADE NOT NULL,
+    event           text NOT NULL,
+    payload         jsonb NOT NULL,
+    status          text NOT NULL DEFAULT 'pending',
+    attempts        integer NOT NULL DEFAULT 0,
+    next_attempt_at timestamp NOT NULL DEFAULT current_timestamp,
+    locked_until    timestamp,
+    response_code   integer,
+    last_error      text,
+    created_at      timestamp NOT NULL DEFAULT current_timestamp,
+    delivered_at    timestamp
+);
+
+CREATE INDEX webhook_deliveries_pending_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+
+CREATE INDEX webhook_deliveries_webhook_id_idx ON webhook_deliveries (webhook_id, created_at);
@@ -0,0 +1,12 @@
+CREATE TABLE refresh_tokens (
+    id         uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id    uuid REFERENCES users NOT NULL,
+    family_id  uuid NOT NULL,
+    token_hash text NOT NULL UNIQUE,
+    expires_at timestamp NOT NULL,
+    used_at    timestamp,
+    revoked_at timestamp,
+    created_at timestamp NOT NULL DEFAULT current_timestamp
+);
+
+CREATE INDEX refresh_tokens_family_id_idx ON refresh_tokens (family_id);
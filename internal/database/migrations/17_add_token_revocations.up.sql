@@ -0,0 +1,11 @@
+CREATE TABLE revoked_tokens (
+    jti        text PRIMARY KEY,
+    expires_at timestamp NOT NULL
+);
+
+CREATE INDEX revoked_tokens_expires_at_idx ON revoked_tokens (expires_at);
+
+CREATE TABLE user_token_revocations (
+    user_id        uuid PRIMARY KEY REFERENCES users,
+    revoked_before timestamp NOT NULL
+);
@@ -0,0 +1,14 @@
+CREATE TABLE api_keys (
+    id           uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id      uuid REFERENCES users NOT NULL,
+    name         text NOT NULL,
+    prefix       text NOT NULL,
+    key_hash     text NOT NULL UNIQUE,
+    scopes       text[] NOT NULL,
+    expires_at   timestamp,
+    last_used_at timestamp,
+    revoked_at   timestamp,
+    created_at   timestamp NOT NULL DEFAULT current_timestamp
+);
+
+CREATE INDEX api_keys_user_id_idx ON api_keys (user_id);
@@ -0,0 +1,13 @@
+CREATE TABLE jobs (
+    id           uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    kind         text NOT NULL,
+    payload      text NOT NULL,
+    run_at       timestamp NOT NULL DEFAULT current_timestamp,
+    attempts     integer NOT NULL DEFAULT 0,
+    locked_until timestamp,
+    created_at   timestamp NOT NULL DEFAULT current_timestamp
+);
+
+CREATE INDEX jobs_run_at_idx ON jobs (run_at);
+
+CREATE INDEX jobs_kind_payload_idx ON jobs (kind, payload);
@@ -0,0 +1,4 @@
+ALTER TABLE orders
+DROP COLUMN accrual_attempts,
+DROP COLUMN accrual_last_error,
+DROP COLUMN accrual_gave_up_at;
@@ -0,0 +1,4 @@
+ALTER TABLE orders
+ADD COLUMN accrual_attempts integer NOT NULL DEFAULT 0,
+ADD COLUMN accrual_last_error text,
+ADD COLUMN accrual_gave_up_at timestamp;
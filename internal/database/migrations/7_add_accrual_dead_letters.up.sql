@@ -0,0 +1,7 @@
+CREATE TABLE accrual_dead_letters (
+    order_id   text PRIMARY KEY REFERENCES orders,
+    last_error text NOT NULL,
+    attempts   integer NOT NULL DEFAULT 0,
+    created_at timestamp NOT NULL DEFAULT current_timestamp,
+    updated_at timestamp NOT NULL DEFAULT current_timestamp
+);
@@ -0,0 +1 @@
+DROP INDEX accrual_flow_order_id_idx;
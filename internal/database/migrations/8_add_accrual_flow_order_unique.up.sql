@@ -0,0 +1,11 @@
+-- Replicas could insert the accrual of an order twice, so the duplicates are removed
+-- before the index is created. The earliest row of every order is kept.
+DELETE FROM
+    accrual_flow a
+USING
+    accrual_flow b
+WHERE
+    a.order_id = b.order_id
+    AND (a.processed_at, a.id) > (b.processed_at, b.id);
+
+CREATE UNIQUE INDEX accrual_flow_order_id_idx ON accrual_flow (order_id);
@@ -0,0 +1,24 @@
+CREATE TABLE balances (
+    user_id    uuid PRIMARY KEY REFERENCES users,
+    current    numeric(15, 2) NOT NULL DEFAULT 0,
+    withdrawn  numeric(15, 2) NOT NULL DEFAULT 0,
+    updated_at timestamp NOT NULL DEFAULT current_timestamp
+);
+
+INSERT INTO balances (user_id, current, withdrawn)
+SELECT
+    u.id,
+    COALESCE(a.amount, 0) - COALESCE(w.amount, 0),
+    COALESCE(w.amount, 0)
+FROM
+    users u
+    LEFT JOIN (
+        SELECT o.user_id, SUM(af.amount) AS amount
+        FROM accrual_flow af JOIN orders o ON af.order_id = o.id
+        GROUP BY o.user_id
+    ) a ON a.user_id = u.id
+    LEFT JOIN (
+        SELECT user_id, SUM(amount) AS amount
+        FROM withdrawal_flow
+        GROUP BY user_id
+    ) w ON w.user_id = u.id;
@@ -0,0 +1,65 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
+)
+
+const (
+	InsertOrderStatusHistoryQuery = `
+		INSERT INTO
+			order_status_history (order_id, from_status, to_status, source)
+		VALUES ($1, $2, $3, $4)
+	`
+	SelectOrderStatusHistoryQuery = `
+		SELECT
+			from_status,
+			to_status,
+			source,
+			created_at
+		FROM
+			order_status_history
+		WHERE
+			order_id = $1
+		ORDER BY
+			created_at,
+			id
+	`
+)
+
+type OrderStatusChangeDB struct {
+	From      *OrderStatusDB
+	To        OrderStatusDB
+	Source    models.OrderStatusSource
+	CreatedAt time.Time
+}
+
+func (d *Database) FindOrderStatusHistory(ctx context.Context, orderID string) (*[]OrderStatusChangeDB, error) {
+	var result []OrderStatusChangeDB
+
+	rows, err := d.db.Query(ctx, SelectOrderStatusHistoryQuery, orderID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var item OrderStatusChangeDB
+
+		if err := rows.Scan(&item.From, &item.To, &item.Source, &item.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		result = append(result, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
@@ -8,13 +8,15 @@ import (
 	"time"
 
 	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/utils"
 	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 )
 
 var (
-	ErrDuplicateOrder = errors.New("order is duplicated")
+	ErrDuplicateOrder          = errors.New("order is duplicated")
+	ErrInvalidStatusTransition = errors.New("order status transition is invalid")
 )
 
 const (
@@ -46,8 +48,44 @@ const (
 			LEFT JOIN accrual_flow af ON o.id = af.order_id
 		WHERE
 		    user_id = $1
+			AND ($2::text[] IS NULL OR status::text = ANY($2::text[]))
+			AND ($3::timestamp IS NULL OR uploaded_at >= $3::timestamp)
+			AND ($4::timestamp IS NULL OR uploaded_at < $4::timestamp)
+			AND ($5::timestamp IS NULL OR (uploaded_at, o.id) %[1]s ($5::timestamp, $6::text))
 		GROUP BY 
 		    o.id
+		ORDER BY
+			uploaded_at %[2]s,
+			o.id %[2]s
+		LIMIT $7
+	`
+	SelectOrderWithOwnerQuery = `
+		SELECT
+			o.id,
+			o.user_id,
+			u.login,
+			o.status,
+			o.uploaded_at,
+			SUM(coalesce(af.amount, 0))
+		FROM
+			orders o
+			JOIN users u ON u.id = o.user_id
+			LEFT JOIN accrual_flow af ON o.id = af.order_id
+		WHERE
+			o.id = $1
+		GROUP BY
+			o.id,
+			u.login
+	`
+	LockOrderStatusQuery = `
+		SELECT
+			status,
+			user_id
+		FROM
+			orders
+		WHERE
+			id = $1
+		FOR UPDATE
 	`
 	UpdateOrderStatusQuery = `
 		UPDATE
@@ -67,6 +105,28 @@ const (
 		    orders
 		WHERE
 		    status NOT IN ('INVALID', 'PROCESSED')
+			AND accrual_gave_up_at IS NULL
+	`
+	IncrementAccrualAttemptsQuery = `
+		UPDATE
+			orders
+		SET
+			accrual_attempts = accrual_attempts + 1,
+			accrual_last_error = $2
+		WHERE
+		    id = $1
+		RETURNING
+			accrual_attempts
+	`
+	ResetAccrualAttemptsQuery = `
+		UPDATE
+			orders
+		SET
+			accrual_attempts = 0,
+			accrual_last_error = NULL
+		WHERE
+		    id = $1
+			AND accrual_attempts > 0
 	`
 )
 
@@ -79,7 +139,13 @@ type OrderDB struct {
 
 type OrderWithAccrualDB struct {
 	OrderDB
-	Accrual float64
+	Accrual utils.Money
+}
+
+// OrderWithOwnerDB is an order of any user together with the login of its owner.
+type OrderWithOwnerDB struct {
+	OrderWithAccrualDB
+	Login string
 }
 
 type OrderStatusDB struct {
@@ -103,7 +169,15 @@ func (s OrderStatusDB) Value() (driver.Value, error) {
 }
 
 func (d *Database) CreateOrder(ctx context.Context, orderID, userID string) error {
-	if _, err := d.db.Exec(ctx, InsertOrderQuery, orderID, userID); err != nil {
+	tx, err := d.db.Begin(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, InsertOrderQuery, orderID, userID); err != nil {
 		var e *pgconn.PgError
 		if errors.As(err, &e) && e.Code == pgerrcode.UniqueViolation {
 			return ErrDuplicateOrder
@@ -112,7 +186,11 @@ func (d *Database) CreateOrder(ctx context.Context, orderID, userID string) erro
 		return err
 	}
 
-	return nil
+	if _, err := tx.Exec(ctx, InsertOrderStatusHistoryQuery, orderID, nil, OrderStatusDB{models.StatusNew}, models.StatusSourceUpload); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
 }
 
 func (d *Database) FindOrder(ctx context.Context, orderID string) (*OrderDB, error) {
@@ -129,10 +207,47 @@ func (d *Database) FindOrder(ctx context.Context, orderID string) (*OrderDB, err
 	return order, nil
 }
 
-func (d *Database) FindOrdersWithAccrual(ctx context.Context, userID string) (*[]OrderWithAccrualDB, error) {
+func (d *Database) FindOrderWithOwner(ctx context.Context, orderID string) (*OrderWithOwnerDB, error) {
+	order := &OrderWithOwnerDB{}
+
+	if err := d.db.QueryRow(ctx, SelectOrderWithOwnerQuery, orderID).Scan(
+		&order.ID,
+		&order.UserID,
+		&order.Login,
+		&order.Status,
+		&order.UploadedAt,
+		&order.Accrual,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return order, nil
+}
+
+func (d *Database) FindOrdersWithAccrual(ctx context.Context, userID string, filter models.ListFilter) (*[]OrderWithAccrualDB, error) {
 	var result []OrderWithAccrualDB
+	var statuses []string
+
+	for _, status := range filter.Statuses {
+		statuses = append(statuses, string(status))
+	}
 
-	rows, err := d.db.Query(ctx, SelectOrdersWithAccrualQuery, userID)
+	args := newPageArgs(filter)
+	rows, err := d.db.Query(
+		ctx,
+		pageQuery(SelectOrdersWithAccrualQuery, filter),
+		userID,
+		statuses,
+		args.from,
+		args.to,
+		args.cursorTime,
+		args.cursorID,
+		args.limit,
+	)
 
 	if err != nil {
 		return nil, err
@@ -157,12 +272,60 @@ func (d *Database) FindOrdersWithAccrual(ctx context.Context, userID string) (*[
 	return &result, nil
 }
 
-func (d *Database) UpdateOrderStatus(ctx context.Context, orderID string, status OrderStatusDB) error {
-	if _, err := d.db.Exec(ctx, UpdateOrderStatusQuery, orderID, status); err != nil {
+// UpdateOrderStatus moves the order to the status and records the transition in its history.
+// The accrual, when it is set, is stored in the same transaction, so an order never becomes
+// PROCESSED without its accrual. Setting the current status again only stores the accrual,
+// transitions the state machine forbids return ErrInvalidStatusTransition.
+func (d *Database) UpdateOrderStatus(ctx context.Context, orderID string, status OrderStatusDB, accrual *utils.Money, source models.OrderStatusSource) error {
+	tx, err := d.db.Begin(ctx)
+
+	if err != nil {
 		return err
 	}
 
-	return nil
+	defer tx.Rollback(ctx)
+
+	if err := updateOrderStatus(ctx, tx, orderID, status, source); err != nil {
+		return err
+	}
+
+	if accrual != nil {
+		if err := createAccrual(ctx, tx, orderID, *accrual); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func updateOrderStatus(ctx context.Context, tx pgx.Tx, orderID string, status OrderStatusDB, source models.OrderStatusSource) error {
+	var current OrderStatusDB
+	var userID string
+
+	if err := tx.QueryRow(ctx, LockOrderStatusQuery, orderID).Scan(&current, &userID); err != nil {
+		return err
+	}
+
+	if current.OrderStatus == status.OrderStatus {
+		return nil
+	}
+
+	if !current.CanTransitionTo(status.OrderStatus) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidStatusTransition, current.OrderStatus, status.OrderStatus)
+	}
+
+	if _, err := tx.Exec(ctx, UpdateOrderStatusQuery, orderID, status); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, InsertOrderStatusHistoryQuery, orderID, current, status, source); err != nil {
+		return err
+	}
+
+	return createUserEvent(ctx, tx, userID, UserEventOrderStatusChanged, models.OrderStatusChangedEvent{
+		OrderID: orderID,
+		Status:  status.OrderStatus,
+	})
 }
 
 func (d *Database) FindAllUnprocessedOrders(ctx context.Context) (*[]OrderDB, error) {
@@ -192,3 +355,21 @@ func (d *Database) FindAllUnprocessedOrders(ctx context.Context) (*[]OrderDB, er
 
 	return &result, nil
 }
+
+func (d *Database) IncrementAccrualAttempts(ctx context.Context, orderID, lastError string) (int, error) {
+	var attempts int
+
+	if err := d.db.QueryRow(ctx, IncrementAccrualAttemptsQuery, orderID, lastError).Scan(&attempts); err != nil {
+		return 0, err
+	}
+
+	return attempts, nil
+}
+
+func (d *Database) ResetAccrualAttempts(ctx context.Context, orderID string) error {
+	if _, err := d.db.Exec(ctx, ResetAccrualAttemptsQuery, orderID); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
+)
+
+// pageQuery fills in the cursor comparison and the sort direction of a paged list query.
+func pageQuery(query string, filter models.ListFilter) string {
+	if filter.Sort == models.SortDesc {
+		return fmt.Sprintf(query, "<", "DESC")
+	}
+
+	return fmt.Sprintf(query, ">", "ASC")
+}
+
+type pageArgs struct {
+	from       *time.Time
+	to         *time.Time
+	cursorTime *time.Time
+	cursorID   string
+	limit      *int
+}
+
+// newPageArgs converts the filter into query arguments, where nil stands for "not set".
+// Timestamp columns keep no time zone, so times are compared in UTC.
+func newPageArgs(filter models.ListFilter) pageArgs {
+	args := pageArgs{}
+
+	if filter.From != nil {
+		from := filter.From.UTC()
+		args.from = &from
+	}
+
+	if filter.To != nil {
+		to := filter.To.UTC()
+		args.to = &to
+	}
+
+	if filter.After != nil {
+		cursorTime := filter.After.Time.UTC()
+		args.cursorTime = &cursorTime
+		args.cursorID = filter.After.ID
+	}
+
+	if filter.Limit > 0 {
+		limit := filter.Limit
+		args.limit = &limit
+	}
+
+	return args
+}
@@ -0,0 +1,206 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrRefreshTokenIsNotExist = errors.New("refresh token is not exist")
+	ErrRefreshTokenIsExpired  = errors.New("refresh token is expired")
+	ErrRefreshTokenIsReused   = errors.New("refresh token is reused")
+)
+
+const (
+	InsertRefreshTokenQuery = `
+		INSERT INTO
+			refresh_tokens (user_id, family_id, token_hash, expires_at)
+		SELECT
+			id,
+			uuid_generate_v4(),
+			$2,
+			current_timestamp + $3::bigint * interval '1 millisecond'
+		FROM
+			users
+		WHERE
+			login = $1
+	`
+	InsertRotatedRefreshTokenQuery = `
+		INSERT INTO
+			refresh_tokens (user_id, family_id, token_hash, expires_at)
+		VALUES ($1, $2, $3, current_timestamp + $4::bigint * interval '1 millisecond')
+	`
+	LockRefreshTokenQuery = `
+		SELECT
+			rt.id,
+			rt.user_id,
+			rt.family_id,
+			u.login,
+			rt.expires_at < current_timestamp,
+			rt.used_at IS NOT NULL,
+			rt.revoked_at IS NOT NULL
+		FROM
+			refresh_tokens rt
+			JOIN users u ON u.id = rt.user_id
+		WHERE
+			rt.token_hash = $1
+		FOR UPDATE OF rt
+	`
+	MarkRefreshTokenUsedQuery = `
+		UPDATE
+			refresh_tokens
+		SET
+			used_at = current_timestamp
+		WHERE
+			id = $1
+	`
+	RevokeRefreshTokenFamilyQuery = `
+		UPDATE
+			refresh_tokens
+		SET
+			revoked_at = current_timestamp
+		WHERE
+			family_id = $1
+			AND revoked_at IS NULL
+	`
+	RevokeRefreshTokenFamilyByTokenQuery = `
+		UPDATE
+			refresh_tokens
+		SET
+			revoked_at = current_timestamp
+		WHERE
+			revoked_at IS NULL
+			AND family_id = (
+				SELECT
+					rt.family_id
+				FROM
+					refresh_tokens rt
+					JOIN users u ON u.id = rt.user_id
+				WHERE
+					rt.token_hash = $2
+					AND u.login = $1
+			)
+	`
+	RevokeUserRefreshTokensQuery = `
+		UPDATE
+			refresh_tokens
+		SET
+			revoked_at = current_timestamp
+		WHERE
+			revoked_at IS NULL
+			AND user_id = (
+				SELECT
+					id
+				FROM
+					users
+				WHERE
+					login = $1
+			)
+	`
+)
+
+type RefreshTokenDB struct {
+	ID        string
+	UserID    string
+	FamilyID  string
+	Login     string
+	IsExpired bool
+	IsUsed    bool
+	IsRevoked bool
+}
+
+// CreateRefreshToken starts a new token family for the user with the given login.
+func (d *Database) CreateRefreshToken(ctx context.Context, login, tokenHash string, ttl time.Duration) error {
+	tag, err := d.db.Exec(ctx, InsertRefreshTokenQuery, login, tokenHash, ttl.Milliseconds())
+
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrUserIsNotExist
+	}
+
+	return nil
+}
+
+// RotateRefreshToken marks the token as used and stores its successor in the same family.
+// A token that has been used already revokes the whole family and returns ErrRefreshTokenIsReused.
+// It returns the login of the token owner.
+func (d *Database) RotateRefreshToken(ctx context.Context, tokenHash, newTokenHash string, ttl time.Duration) (string, error) {
+	tx, err := d.db.Begin(ctx)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer tx.Rollback(ctx)
+
+	token := &RefreshTokenDB{}
+
+	if err := tx.QueryRow(ctx, LockRefreshTokenQuery, tokenHash).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.FamilyID,
+		&token.Login,
+		&token.IsExpired,
+		&token.IsUsed,
+		&token.IsRevoked,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrRefreshTokenIsNotExist
+		}
+
+		return "", err
+	}
+
+	if token.IsUsed {
+		if _, err := tx.Exec(ctx, RevokeRefreshTokenFamilyQuery, token.FamilyID); err != nil {
+			return "", err
+		}
+
+		if err := tx.Commit(ctx); err != nil {
+			return "", err
+		}
+
+		return "", ErrRefreshTokenIsReused
+	}
+
+	if token.IsRevoked {
+		return "", ErrRefreshTokenIsNotExist
+	}
+
+	if token.IsExpired {
+		return "", ErrRefreshTokenIsExpired
+	}
+
+	if _, err := tx.Exec(ctx, MarkRefreshTokenUsedQuery, token.ID); err != nil {
+		return "", err
+	}
+
+	if _, err := tx.Exec(ctx, InsertRotatedRefreshTokenQuery, token.UserID, token.FamilyID, newTokenHash, ttl.Milliseconds()); err != nil {
+		return "", err
+	}
+
+	return token.Login, tx.Commit(ctx)
+}
+
+// RevokeRefreshTokenFamily revokes the family of the user's token. Tokens of other users are ignored.
+func (d *Database) RevokeRefreshTokenFamily(ctx context.Context, login, tokenHash string) error {
+	if _, err := d.db.Exec(ctx, RevokeRefreshTokenFamilyByTokenQuery, login, tokenHash); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (d *Database) RevokeUserRefreshTokens(ctx context.Context, login string) error {
+	if _, err := d.db.Exec(ctx, RevokeUserRefreshTokensQuery, login); err != nil {
+		return err
+	}
+
+	return nil
+}
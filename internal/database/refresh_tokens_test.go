@@ -0,0 +1,46 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotateRefreshTokenRevokesFamilyOnReuse(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+	login := fmt.Sprintf("user-%d", time.Now().UnixNano())
+
+	require.NoError(t, db.CreateUser(ctx, UserDB{User: models.User{Login: login, Hash: "hash"}}))
+	require.NoError(t, db.CreateRefreshToken(ctx, login, login+"-1", time.Hour))
+
+	rotatedLogin, err := db.RotateRefreshToken(ctx, login+"-1", login+"-2", time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, login, rotatedLogin)
+
+	_, err = db.RotateRefreshToken(ctx, login+"-1", login+"-3", time.Hour)
+	assert.ErrorIs(t, err, ErrRefreshTokenIsReused)
+
+	_, err = db.RotateRefreshToken(ctx, login+"-2", login+"-4", time.Hour)
+	assert.ErrorIs(t, err, ErrRefreshTokenIsNotExist)
+
+	_, err = db.RotateRefreshToken(ctx, login+"-3", login+"-5", time.Hour)
+	assert.ErrorIs(t, err, ErrRefreshTokenIsNotExist)
+}
+
+func TestRotateRefreshTokenRejectsExpiredToken(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+	login := fmt.Sprintf("user-%d", time.Now().UnixNano())
+
+	require.NoError(t, db.CreateUser(ctx, UserDB{User: models.User{Login: login, Hash: "hash"}}))
+	require.NoError(t, db.CreateRefreshToken(ctx, login, login+"-1", -time.Minute))
+
+	_, err := db.RotateRefreshToken(ctx, login+"-1", login+"-2", time.Hour)
+	assert.ErrorIs(t, err, ErrRefreshTokenIsExpired)
+}
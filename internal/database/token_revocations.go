@@ -0,0 +1,97 @@
+package database
+
+import (
+	"context"
+)
+
+const (
+	SelectTokenRevocationQuery = `
+		SELECT
+			EXISTS (
+				SELECT
+					1
+				FROM
+					revoked_tokens
+				WHERE
+					jti = $2
+			)
+			OR EXISTS (
+				SELECT
+					1
+				FROM
+					user_token_revocations r
+					JOIN users u ON u.id = r.user_id
+				WHERE
+					u.login = $1
+					AND r.revoked_before > to_timestamp($3)::timestamp
+			)
+	`
+	InsertRevokedTokenQuery = `
+		INSERT INTO
+			revoked_tokens (jti, expires_at)
+		VALUES ($1, to_timestamp($2)::timestamp)
+		ON CONFLICT (jti) DO NOTHING
+	`
+	DeleteExpiredRevokedTokensQuery = `
+		DELETE FROM
+			revoked_tokens
+		WHERE
+			expires_at < current_timestamp
+	`
+	UpsertUserTokenRevocationQuery = `
+		INSERT INTO
+			user_token_revocations (user_id, revoked_before)
+		SELECT
+			id,
+			date_trunc('second', current_timestamp)
+		FROM
+			users
+		WHERE
+			login = $1
+		ON CONFLICT (user_id) DO UPDATE
+		SET
+			revoked_before = EXCLUDED.revoked_before
+	`
+)
+
+// IsTokenRevoked reports whether the token with the given id is revoked or was issued
+// (unix seconds) before all tokens of the user were revoked.
+func (d *Database) IsTokenRevoked(ctx context.Context, login, tokenID string, issuedAt int64) (bool, error) {
+	var revoked bool
+
+	if err := d.db.QueryRow(ctx, SelectTokenRevocationQuery, login, tokenID, issuedAt).Scan(&revoked); err != nil {
+		return false, err
+	}
+
+	return revoked, nil
+}
+
+// RevokeToken keeps the token id until the token expires (unix seconds).
+func (d *Database) RevokeToken(ctx context.Context, tokenID string, expiresAt int64) error {
+	if _, err := d.db.Exec(ctx, DeleteExpiredRevokedTokensQuery); err != nil {
+		return err
+	}
+
+	if _, err := d.db.Exec(ctx, InsertRevokedTokenQuery, tokenID, expiresAt); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RevokeUserTokens revokes every token of the user issued before the current second.
+// Tokens carry their issue time in whole seconds, so a token issued right after the revocation
+// within the same second must stay valid, e.g. after logging in again.
+func (d *Database) RevokeUserTokens(ctx context.Context, login string) error {
+	tag, err := d.db.Exec(ctx, UpsertUserTokenRevocationQuery, login)
+
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrUserIsNotExist
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevokeUserTokens(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+	login := fmt.Sprintf("revoke-%d", time.Now().UnixNano())
+
+	require.NoError(t, db.CreateUser(ctx, UserDB{User: models.User{Login: login, Hash: "hash"}}))
+	require.NoError(t, db.RevokeUserTokens(ctx, login))
+
+	var revokedBefore time.Time
+
+	require.NoError(t, db.db.QueryRow(ctx, `
+		SELECT
+			r.revoked_before
+		FROM
+			user_token_revocations r
+			JOIN users u ON u.id = r.user_id
+		WHERE
+			u.login = $1
+	`, login).Scan(&revokedBefore))
+
+	assert.Equal(t, revokedBefore.Truncate(time.Second), revokedBefore)
+
+	testCases := []struct {
+		testName string
+		issuedAt int64
+		expected bool
+	}{
+		{testName: "Should revoke token issued before", issuedAt: revokedBefore.Unix() - 1, expected: true},
+		{testName: "Should keep token issued within the same second", issuedAt: revokedBefore.Unix(), expected: false},
+		{testName: "Should keep token issued after", issuedAt: revokedBefore.Unix() + 1, expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			revoked, err := db.IsTokenRevoked(ctx, login, "token-id", tc.issuedAt)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expected, revoked)
+		})
+	}
+
+	assert.ErrorIs(t, db.RevokeUserTokens(ctx, login+"-missing"), ErrUserIsNotExist)
+}
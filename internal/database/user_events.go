@@ -0,0 +1,178 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// UserEventsChannel is the LISTEN/NOTIFY channel that carries "userID:eventID" of new user events.
+const UserEventsChannel = "user_events"
+
+const (
+	InsertUserEventQuery = `
+		WITH event AS (
+			INSERT INTO
+				user_events (user_id, type, payload)
+			VALUES ($1, $2, $3)
+			RETURNING
+				id,
+				user_id
+		)
+		SELECT
+			pg_notify('` + UserEventsChannel + `', user_id || ':' || id)
+		FROM
+			event
+	`
+	SelectUserEventsQuery = `
+		SELECT
+			id,
+			type,
+			payload,
+			created_at
+		FROM
+			user_events
+		WHERE
+			user_id = $1
+			AND id > $2
+		ORDER BY
+			id
+		LIMIT $3
+	`
+	SelectLastUserEventIDQuery = `
+		SELECT
+			COALESCE(MAX(id), 0)
+		FROM
+			user_events
+		WHERE
+			user_id = $1
+	`
+	DeleteUserEventsQuery = `
+		DELETE FROM
+			user_events
+		WHERE
+			created_at < current_timestamp - $1::bigint * interval '1 millisecond'
+	`
+	ListenUserEventsQuery = `LISTEN ` + UserEventsChannel
+)
+
+const (
+	UserEventOrderStatusChanged = "order_status_changed"
+	UserEventBalanceChanged     = "balance_changed"
+)
+
+type UserEventDB struct {
+	ID        int64
+	Type      string
+	Payload   []byte
+	CreatedAt time.Time
+}
+
+// createUserEvent stores the event within the transaction. Listeners are notified
+// only when the transaction commits.
+//
+// Readers page through events by id, so the ids of a user must become visible in order.
+// The balance row of the user is locked before the id is taken: a concurrent transaction
+// waits for this one to commit and only then gets a greater id.
+func createUserEvent(ctx context.Context, tx pgx.Tx, userID, eventType string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, EnsureBalanceQuery, userID); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, LockBalanceQuery, userID); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, InsertUserEventQuery, userID, eventType, data); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (d *Database) FindUserEvents(ctx context.Context, userID string, afterID int64, limit int) (*[]UserEventDB, error) {
+	var result []UserEventDB
+
+	rows, err := d.db.Query(ctx, SelectUserEventsQuery, userID, afterID, limit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var item UserEventDB
+
+		if err := rows.Scan(&item.ID, &item.Type, &item.Payload, &item.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		result = append(result, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (d *Database) FindLastUserEventID(ctx context.Context, userID string) (int64, error) {
+	var id int64
+
+	if err := d.db.QueryRow(ctx, SelectLastUserEventIDQuery, userID).Scan(&id); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (d *Database) DeleteUserEvents(ctx context.Context, olderThan time.Duration) (int64, error) {
+	tag, err := d.db.Exec(ctx, DeleteUserEventsQuery, olderThan.Milliseconds())
+
+	if err != nil {
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// ListenUserEvents holds a dedicated connection and calls notify with the user of every new event
+// until the context is done or the connection fails.
+func (d *Database) ListenUserEvents(ctx context.Context, notify func(userID string)) error {
+	conn, err := d.db.Acquire(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	// The connection keeps listening after LISTEN, so it must not go back to the pool.
+	listener := conn.Hijack()
+	defer listener.Close(context.Background())
+
+	if _, err := listener.Exec(ctx, ListenUserEventsQuery); err != nil {
+		return err
+	}
+
+	for {
+		notification, err := listener.WaitForNotification(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		if userID, _, ok := strings.Cut(notification.Payload, ":"); ok {
+			notify(userID)
+		}
+	}
+}
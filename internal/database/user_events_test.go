@@ -0,0 +1,65 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserEventsBecomeVisibleInOrder(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+	userID := createTestUserWithAccrual(t, db, utils.Money(100000))
+
+	lastID, err := db.FindLastUserEventID(ctx, userID)
+	require.NoError(t, err)
+
+	const writers = 20
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			orderID := fmt.Sprintf("%d-%d", lastID, i)
+			assert.NoError(t, db.CreateWithdrawal(ctx, orderID, userID, utils.Money(100)))
+		}(i)
+	}
+
+	// A reader that pages by id must see every event even while writers are committing.
+	seen := 0
+	done := make(chan struct{})
+
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	for finished := false; !finished; {
+		select {
+		case <-done:
+			finished = true
+		default:
+		}
+
+		events, err := db.FindUserEvents(ctx, userID, lastID, 100)
+		require.NoError(t, err)
+
+		for _, event := range *events {
+			assert.Greater(t, event.ID, lastID)
+
+			lastID = event.ID
+			seen++
+		}
+	}
+
+	assert.Equal(t, writers, seen)
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"errors"
+	"strings"
 
 	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
 	"github.com/jackc/pgerrcode"
@@ -11,33 +12,67 @@ import (
 )
 
 var (
-	ErrDuplicateUser = errors.New("user is duplicated")
+	ErrDuplicateUser  = errors.New("user is duplicated")
+	ErrUserIsNotExist = errors.New("user is not exist")
 )
 
+// likePatternReplacer escapes the wildcards of the LIKE patterns.
+var likePatternReplacer = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 const (
 	InsertUserQuery = `
 		INSERT INTO
-			users (login, hash)
-		VALUES ($1, $2)
+			users (login, hash, role)
+		VALUES ($1, $2, $3)
 	`
 	SelectUserQuery = `
 		SELECT
 		    id,
 			login,
-			hash
+			hash,
+			role
 		FROM
 		    users
 		WHERE
 		    login = $1
 	`
+	SelectUsersQuery = `
+		SELECT
+			id,
+			login,
+			role
+		FROM
+			users
+		WHERE
+			($1::text IS NULL OR login ILIKE '%' || $1::text || '%')
+			AND ($2::text IS NULL OR login > $2::text)
+		ORDER BY
+			login
+		LIMIT $3
+	`
+	UpdateUserRoleQuery = `
+		UPDATE
+			users
+		SET
+			role = $2
+		WHERE
+			login = $1
+	`
 )
 
 type UserDB struct {
 	models.User
 }
 
+// CreateUser creates the user with the user role unless another one is given.
 func (d *Database) CreateUser(ctx context.Context, user UserDB) error {
-	if _, err := d.db.Exec(ctx, InsertUserQuery, user.Login, user.Hash); err != nil {
+	role := user.Role
+
+	if role == "" {
+		role = models.RoleUser
+	}
+
+	if _, err := d.db.Exec(ctx, InsertUserQuery, user.Login, user.Hash, role); err != nil {
 		var e *pgconn.PgError
 		if errors.As(err, &e) && e.Code == pgerrcode.UniqueViolation {
 			return ErrDuplicateUser
@@ -52,7 +87,7 @@ func (d *Database) CreateUser(ctx context.Context, user UserDB) error {
 func (d *Database) FindUser(ctx context.Context, login string) (*UserDB, error) {
 	user := &UserDB{}
 
-	if err := d.db.QueryRow(ctx, SelectUserQuery, login).Scan(&user.ID, &user.Login, &user.Hash); err != nil {
+	if err := d.db.QueryRow(ctx, SelectUserQuery, login).Scan(&user.ID, &user.Login, &user.Hash, &user.Role); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
 		}
@@ -62,3 +97,57 @@ func (d *Database) FindUser(ctx context.Context, login string) (*UserDB, error)
 
 	return user, nil
 }
+
+func (d *Database) UpdateUserRole(ctx context.Context, login string, role models.Role) error {
+	tag, err := d.db.Exec(ctx, UpdateUserRoleQuery, login, role)
+
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrUserIsNotExist
+	}
+
+	return nil
+}
+
+// FindUsers returns users whose login contains the search string, sorted by login and
+// starting after the given login. Empty search and after select everything. Hashes aren't read.
+func (d *Database) FindUsers(ctx context.Context, search, after string, limit int) (*[]UserDB, error) {
+	var result []UserDB
+	var searchArg, afterArg *string
+
+	if search != "" {
+		escaped := likePatternReplacer.Replace(search)
+		searchArg = &escaped
+	}
+
+	if after != "" {
+		afterArg = &after
+	}
+
+	rows, err := d.db.Query(ctx, SelectUsersQuery, searchArg, afterArg, limit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var item UserDB
+
+		if err := rows.Scan(&item.ID, &item.Login, &item.Role); err != nil {
+			return nil, err
+		}
+
+		result = append(result, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
@@ -0,0 +1,41 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindUsersSearchesByLogin(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+	prefix := fmt.Sprintf("search-%d", time.Now().UnixNano())
+
+	for _, login := range []string{prefix + "_a", prefix + "_b", prefix + "xc"} {
+		require.NoError(t, db.CreateUser(ctx, UserDB{User: models.User{Login: login, Hash: "hash"}}))
+	}
+
+	require.NoError(t, db.UpdateUserRole(ctx, prefix+"_b", models.RoleSupport))
+
+	users, err := db.FindUsers(ctx, prefix+"_", "", 10)
+	require.NoError(t, err)
+	require.Len(t, *users, 2)
+
+	assert.Equal(t, prefix+"_a", (*users)[0].Login)
+	assert.Equal(t, models.RoleUser, (*users)[0].Role)
+	assert.Equal(t, prefix+"_b", (*users)[1].Login)
+	assert.Equal(t, models.RoleSupport, (*users)[1].Role)
+	assert.Empty(t, (*users)[0].Hash)
+
+	users, err = db.FindUsers(ctx, prefix, prefix+"_a", 1)
+	require.NoError(t, err)
+	require.Len(t, *users, 1)
+	assert.Equal(t, prefix+"_b", (*users)[0].Login)
+
+	assert.ErrorIs(t, db.UpdateUserRole(ctx, prefix+"-missing", models.RoleAdmin), ErrUserIsNotExist)
+}
@@ -0,0 +1,351 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	InsertWebhookQuery = `
+		INSERT INTO
+			webhooks (user_id, url, secret, events)
+		VALUES ($1, $2, $3, $4)
+		RETURNING
+			id,
+			created_at
+	`
+	SelectWebhooksQuery = `
+		SELECT
+			id,
+			user_id,
+			url,
+			secret,
+			events,
+			created_at
+		FROM
+			webhooks
+		WHERE
+			user_id = $1
+		ORDER BY
+			created_at
+	`
+	SelectWebhookQuery = `
+		SELECT
+			id,
+			user_id,
+			url,
+			secret,
+			events,
+			created_at
+		FROM
+			webhooks
+		WHERE
+			id = $1
+	`
+	DeleteWebhookQuery = `
+		DELETE FROM
+			webhooks
+		WHERE
+			id = $1
+			AND user_id = $2
+	`
+	InsertWebhookDeliveriesQuery = `
+		INSERT INTO
+			webhook_deliveries (webhook_id, event, payload)
+		SELECT
+			id,
+			$2,
+			$3
+		FROM
+			webhooks
+		WHERE
+			user_id = $1
+			AND $2 = ANY(events)
+	`
+	SelectWebhookDeliveriesQuery = `
+		SELECT
+			id,
+			event,
+			status,
+			attempts,
+			response_code,
+			last_error,
+			created_at,
+			delivered_at
+		FROM
+			webhook_deliveries
+		WHERE
+			webhook_id = $1
+		ORDER BY
+			created_at DESC
+		LIMIT $2
+	`
+	ClaimWebhookDeliveryQuery = `
+		UPDATE
+			webhook_deliveries d
+		SET
+			locked_until = current_timestamp + $2::bigint * interval '1 millisecond',
+			attempts = d.attempts + 1
+		FROM
+			webhooks w
+		WHERE
+			w.id = d.webhook_id
+			AND d.id = (
+				SELECT
+					id
+				FROM
+					webhook_deliveries
+				WHERE
+					status = $1
+					AND next_attempt_at <= current_timestamp
+					AND (locked_until IS NULL OR locked_until < current_timestamp)
+				ORDER BY
+					next_attempt_at
+				LIMIT 1
+				FOR UPDATE SKIP LOCKED
+			)
+		RETURNING
+			d.id,
+			d.event,
+			d.payload,
+			d.attempts,
+			d.created_at,
+			w.url,
+			w.secret
+	`
+	UpdateWebhookDeliveryQuery = `
+		UPDATE
+			webhook_deliveries
+		SET
+			status = $2,
+			response_code = $3,
+			last_error = $4,
+			next_attempt_at = current_timestamp + $5::bigint * interval '1 millisecond',
+			delivered_at = CASE WHEN $2 = $6 THEN current_timestamp END,
+			locked_until = NULL
+		WHERE
+			id = $1
+	`
+)
+
+type WebhookDB struct {
+	ID        string
+	UserID    string
+	URL       string
+	Secret    string
+	Events    []string
+	CreatedAt time.Time
+}
+
+type WebhookDeliveryDB struct {
+	ID           string
+	Event        string
+	Status       string
+	Attempts     int
+	ResponseCode *int
+	LastError    *string
+	CreatedAt    time.Time
+	DeliveredAt  *time.Time
+}
+
+// ClaimedWebhookDeliveryDB is a pending delivery together with the target of its webhook.
+type ClaimedWebhookDeliveryDB struct {
+	ID        string
+	Event     string
+	Payload   []byte
+	Attempts  int
+	CreatedAt time.Time
+	URL       string
+	Secret    string
+}
+
+// createWebhookDeliveries puts the event into the outbox of every webhook of the user
+// that is subscribed to it. The deliveries appear only when the transaction commits.
+func createWebhookDeliveries(ctx context.Context, tx pgx.Tx, userID string, event models.WebhookEvent, payload interface{}) error {
+	data, err := json.Marshal(payload)
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, InsertWebhookDeliveriesQuery, userID, string(event), data); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// isInvalidID reports whether the error is caused by an identifier that isn't a valid uuid.
+func isInvalidID(err error) bool {
+	var e *pgconn.PgError
+	return errors.As(err, &e) && e.Code == pgerrcode.InvalidTextRepresentation
+}
+
+func (d *Database) CreateWebhook(ctx context.Context, userID, url, secret string, events []string) (*WebhookDB, error) {
+	webhook := &WebhookDB{UserID: userID, URL: url, Secret: secret, Events: events}
+
+	if err := d.db.QueryRow(ctx, InsertWebhookQuery, userID, url, secret, events).Scan(&webhook.ID, &webhook.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+func (d *Database) FindWebhooks(ctx context.Context, userID string) (*[]WebhookDB, error) {
+	var result []WebhookDB
+
+	rows, err := d.db.Query(ctx, SelectWebhooksQuery, userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var item WebhookDB
+
+		if err := rows.Scan(&item.ID, &item.UserID, &item.URL, &item.Secret, &item.Events, &item.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		result = append(result, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (d *Database) FindWebhook(ctx context.Context, webhookID string) (*WebhookDB, error) {
+	webhook := &WebhookDB{}
+
+	if err := d.db.QueryRow(ctx, SelectWebhookQuery, webhookID).Scan(
+		&webhook.ID,
+		&webhook.UserID,
+		&webhook.URL,
+		&webhook.Secret,
+		&webhook.Events,
+		&webhook.CreatedAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) || isInvalidID(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+// DeleteWebhook deletes the webhook of the user together with its deliveries.
+// It returns false when the user has no such webhook.
+func (d *Database) DeleteWebhook(ctx context.Context, webhookID, userID string) (bool, error) {
+	tag, err := d.db.Exec(ctx, DeleteWebhookQuery, webhookID, userID)
+
+	if err != nil {
+		if isInvalidID(err) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+func (d *Database) FindWebhookDeliveries(ctx context.Context, webhookID string, limit int) (*[]WebhookDeliveryDB, error) {
+	var result []WebhookDeliveryDB
+
+	rows, err := d.db.Query(ctx, SelectWebhookDeliveriesQuery, webhookID, limit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var item WebhookDeliveryDB
+
+		if err := rows.Scan(
+			&item.ID,
+			&item.Event,
+			&item.Status,
+			&item.Attempts,
+			&item.ResponseCode,
+			&item.LastError,
+			&item.CreatedAt,
+			&item.DeliveredAt,
+		); err != nil {
+			return nil, err
+		}
+
+		result = append(result, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// ClaimWebhookDelivery locks the next due delivery for the lease and counts the attempt.
+// It returns nil when there is nothing to deliver.
+func (d *Database) ClaimWebhookDelivery(ctx context.Context, lease time.Duration) (*ClaimedWebhookDeliveryDB, error) {
+	delivery := &ClaimedWebhookDeliveryDB{}
+
+	if err := d.db.QueryRow(ctx, ClaimWebhookDeliveryQuery, string(models.WebhookDeliveryPending), lease.Milliseconds()).Scan(
+		&delivery.ID,
+		&delivery.Event,
+		&delivery.Payload,
+		&delivery.Attempts,
+		&delivery.CreatedAt,
+		&delivery.URL,
+		&delivery.Secret,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+// UpdateWebhookDelivery records the result of an attempt and releases the delivery.
+// A pending delivery is retried after retryDelay.
+func (d *Database) UpdateWebhookDelivery(
+	ctx context.Context,
+	deliveryID string,
+	status models.WebhookDeliveryStatus,
+	responseCode *int,
+	lastError *string,
+	retryDelay time.Duration,
+) error {
+	if _, err := d.db.Exec(
+		ctx,
+		UpdateWebhookDeliveryQuery,
+		deliveryID,
+		string(status),
+		responseCode,
+		lastError,
+		retryDelay.Milliseconds(),
+		string(models.WebhookDeliveryDelivered),
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
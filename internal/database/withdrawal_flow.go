@@ -2,7 +2,19 @@ package database
 
 import (
 	"context"
+	"errors"
 	"time"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/utils"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	ErrInsufficientFunds   = errors.New("there are insufficient funds")
+	ErrDuplicateWithdrawal = errors.New("withdrawal is duplicated")
 )
 
 const (
@@ -20,27 +32,104 @@ const (
 		    withdrawal_flow
 		WHERE
 		    user_id = $1
+			AND ($2::timestamp IS NULL OR processed_at >= $2::timestamp)
+			AND ($3::timestamp IS NULL OR processed_at < $3::timestamp)
+			AND ($4::timestamp IS NULL OR (processed_at, order_id) %[1]s ($4::timestamp, $5::text))
+		ORDER BY
+			processed_at %[2]s,
+			order_id %[2]s
+		LIMIT $6
+	`
+	SelectWithdrawalQuery = `
+		SELECT
+			order_id,
+			user_id,
+			amount,
+			processed_at
+		FROM
+			withdrawal_flow
+		WHERE
+			order_id = $1
 	`
 )
 
 type WithdrawalFlowItemDB struct {
 	OrderID     string
-	Amount      float64
+	Amount      utils.Money
 	ProcessedAt time.Time
 }
 
-func (d *Database) CreateWithdrawal(ctx context.Context, orderID, userID string, amount float64) error {
-	if _, err := d.db.Exec(ctx, InsertWithdrawalQuery, orderID, userID, amount); err != nil {
+type WithdrawalDB struct {
+	WithdrawalFlowItemDB
+	UserID string
+}
+
+// CreateWithdrawal locks the user's balance row so that concurrent withdrawals of the same user
+// are serialized, and inserts the withdrawal only when the balance covers the amount.
+// The order number of a withdrawal is unique across all users.
+func (d *Database) CreateWithdrawal(ctx context.Context, orderID, userID string, amount utils.Money) error {
+	tx, err := d.db.Begin(ctx)
+
+	if err != nil {
 		return err
 	}
 
-	return nil
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, EnsureBalanceQuery, userID); err != nil {
+		return err
+	}
+
+	var balance utils.Money
+
+	if err := tx.QueryRow(ctx, LockBalanceQuery, userID).Scan(&balance); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, InsertWithdrawalQuery, orderID, userID, amount); err != nil {
+		var e *pgconn.PgError
+		if errors.As(err, &e) && e.Code == pgerrcode.UniqueViolation {
+			return ErrDuplicateWithdrawal
+		}
+
+		return err
+	}
+
+	if balance < amount {
+		return ErrInsufficientFunds
+	}
+
+	var updated models.Balance
+
+	if err := tx.QueryRow(ctx, AddWithdrawalToBalanceQuery, userID, amount).Scan(&updated.Current, &updated.Withdrawn); err != nil {
+		return err
+	}
+
+	if err := createUserEvent(ctx, tx, userID, UserEventBalanceChanged, updated); err != nil {
+		return err
+	}
+
+	if err := createWebhookDeliveries(ctx, tx, userID, models.WebhookEventBalanceWithdrawn, models.BalanceWithdrawnEvent{OrderID: orderID, Sum: amount}); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
 }
 
-func (d *Database) FindWithdrawalFlow(ctx context.Context, userID string) (*[]WithdrawalFlowItemDB, error) {
+func (d *Database) FindWithdrawalFlow(ctx context.Context, userID string, filter models.ListFilter) (*[]WithdrawalFlowItemDB, error) {
 	var result []WithdrawalFlowItemDB
 
-	rows, err := d.db.Query(ctx, SelectWithdrawalFlowQuery, userID)
+	args := newPageArgs(filter)
+	rows, err := d.db.Query(
+		ctx,
+		pageQuery(SelectWithdrawalFlowQuery, filter),
+		userID,
+		args.from,
+		args.to,
+		args.cursorTime,
+		args.cursorID,
+		args.limit,
+	)
 
 	if err != nil {
 		return nil, err
@@ -64,3 +153,22 @@ func (d *Database) FindWithdrawalFlow(ctx context.Context, userID string) (*[]Wi
 
 	return &result, nil
 }
+
+func (d *Database) FindWithdrawal(ctx context.Context, orderID string) (*WithdrawalDB, error) {
+	withdrawal := &WithdrawalDB{}
+
+	if err := d.db.QueryRow(ctx, SelectWithdrawalQuery, orderID).Scan(
+		&withdrawal.OrderID,
+		&withdrawal.UserID,
+		&withdrawal.Amount,
+		&withdrawal.ProcessedAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return withdrawal, nil
+}
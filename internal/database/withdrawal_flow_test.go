@@ -0,0 +1,98 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDatabase(t *testing.T) *Database {
+	dsn := os.Getenv("TEST_DATABASE_URI")
+
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URI is not set")
+	}
+
+	db, err := New(context.Background(), dsn)
+	require.NoError(t, err)
+	require.NoError(t, db.RunMigrations())
+
+	t.Cleanup(db.db.Close)
+
+	return db
+}
+
+func createTestUserWithAccrual(t *testing.T, db *Database, amount utils.Money) string {
+	ctx := context.Background()
+	login := fmt.Sprintf("user-%d", time.Now().UnixNano())
+
+	require.NoError(t, db.CreateUser(ctx, UserDB{User: models.User{Login: login, Hash: "hash"}}))
+
+	user, err := db.FindUser(ctx, login)
+	require.NoError(t, err)
+
+	orderID := fmt.Sprintf("accrual-%d", time.Now().UnixNano())
+
+	require.NoError(t, db.CreateOrder(ctx, orderID, user.ID))
+	require.NoError(t, db.CreateAccrual(ctx, orderID, amount))
+
+	return user.ID
+}
+
+func TestCreateWithdrawalConcurrently(t *testing.T) {
+	db := newTestDatabase(t)
+	userID := createTestUserWithAccrual(t, db, utils.Money(10000))
+
+	const requests = 20
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var succeeded, rejected int
+
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			err := db.CreateWithdrawal(context.Background(), fmt.Sprintf("withdrawal-%s-%d", userID, i), userID, utils.Money(3000))
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			switch {
+			case err == nil:
+				succeeded++
+			case errors.Is(err, ErrInsufficientFunds):
+				rejected++
+			default:
+				t.Errorf("unexpected error: %s", err)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, 3, succeeded)
+	assert.Equal(t, requests-3, rejected)
+
+	withdrawalFlow, err := db.FindWithdrawalFlow(context.Background(), userID, models.ListFilter{})
+	require.NoError(t, err)
+
+	var withdrawn utils.Money
+
+	for _, item := range *withdrawalFlow {
+		withdrawn += item.Amount
+	}
+
+	assert.LessOrEqual(t, int64(withdrawn), int64(10000))
+}
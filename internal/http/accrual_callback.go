@@ -0,0 +1,40 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/middlewares"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/services"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/utils"
+)
+
+func AccrualCallback(w http.ResponseWriter, r *http.Request) {
+	data := middlewares.GetParsedJSONData[models.AccrualUpdate](w, r)
+
+	if data.ID == nil || data.Status == nil {
+		http.Error(w, "Request doesn't contain order or status", http.StatusBadRequest)
+		return
+	}
+
+	accrualService := middlewares.GetServiceFromContext[models.AccrualService](w, r, middlewares.AccrualServiceKey)
+
+	if err := (*accrualService).ApplyAccrualUpdate(r.Context(), *data.ID, *data.Status, (*utils.Money)(data.Accrual)); err != nil {
+		if errors.Is(err, services.ErrUnknownAccrualStatus) {
+			http.Error(w, "Status is unknown", http.StatusBadRequest)
+			return
+		}
+
+		if errors.Is(err, services.ErrOrderIsNotExist) {
+			http.Error(w, "Order is not found", http.StatusNotFound)
+			return
+		}
+
+		http.Error(w, fmt.Sprintf("Error occurred during applying accrual update: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,58 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/middlewares"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/services"
+	"github.com/go-chi/chi/v5"
+)
+
+// GetUsers lists users sorted by login. The login query parameter searches by a part of the login.
+func GetUsers(w http.ResponseWriter, r *http.Request) {
+	authService := middlewares.GetServiceFromContext[models.AuthService](w, r, middlewares.AuthServiceKey)
+
+	filter, err := parseListFilter(r, false)
+
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Query parameters are invalid: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	users, next, err := (*authService).GetUsers(r.Context(), r.URL.Query().Get("login"), filter)
+
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error occurred during getting users: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	if len(users) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	setNextLink(w, r, next)
+
+	middlewares.EncodeJSONResponse(w, users)
+}
+
+func GetOrderDetails(w http.ResponseWriter, r *http.Request) {
+	orderService := middlewares.GetServiceFromContext[models.OrderService](w, r, middlewares.OrderServiceKey)
+
+	order, err := (*orderService).GetOrderDetails(r.Context(), chi.URLParam(r, "number"))
+
+	if err != nil {
+		if errors.Is(err, services.ErrOrderIsNotExist) {
+			http.Error(w, "Order is not found", http.StatusNotFound)
+			return
+		}
+
+		http.Error(w, fmt.Sprintf("Error occurred during getting order: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	middlewares.EncodeJSONResponse(w, order)
+}
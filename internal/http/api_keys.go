@@ -0,0 +1,86 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/middlewares"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/services"
+	"github.com/go-chi/chi/v5"
+)
+
+func CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	data := middlewares.GetParsedJSONData[models.APIKeyRequest](w, r)
+
+	if data.Name == nil || data.Scopes == nil {
+		http.Error(w, "Request doesn't contain name or scopes", http.StatusBadRequest)
+		return
+	}
+
+	apiKeyService := middlewares.GetServiceFromContext[models.APIKeyService](w, r, middlewares.APIKeyServiceKey)
+	user := middlewares.GetUserFromContext(w, r)
+
+	var expiresAt *time.Time
+
+	if data.ExpiresAt != nil {
+		expiresAt = &data.ExpiresAt.Time
+	}
+
+	apiKey, err := (*apiKeyService).CreateAPIKey(r.Context(), user.ID, *data.Name, data.Scopes, expiresAt)
+
+	if err != nil {
+		if errors.Is(err, services.ErrAPIKeyNameIsInvalid) ||
+			errors.Is(err, services.ErrAPIKeyScopesAreInvalid) ||
+			errors.Is(err, services.ErrAPIKeyExpiryIsInvalid) {
+			http.Error(w, fmt.Sprintf("API key is invalid: %s", err.Error()), http.StatusUnprocessableEntity)
+			return
+		}
+
+		http.Error(w, fmt.Sprintf("Error occurred during creating api key: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	middlewares.EncodeJSONResponse(w, apiKey)
+}
+
+func GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	apiKeyService := middlewares.GetServiceFromContext[models.APIKeyService](w, r, middlewares.APIKeyServiceKey)
+	user := middlewares.GetUserFromContext(w, r)
+
+	apiKeys, err := (*apiKeyService).GetAPIKeys(r.Context(), user.ID)
+
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error occurred during getting api keys: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	if len(apiKeys) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	middlewares.EncodeJSONResponse(w, apiKeys)
+}
+
+func RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	apiKeyService := middlewares.GetServiceFromContext[models.APIKeyService](w, r, middlewares.APIKeyServiceKey)
+	user := middlewares.GetUserFromContext(w, r)
+
+	if err := (*apiKeyService).RevokeAPIKey(r.Context(), chi.URLParam(r, "id"), user.ID); err != nil {
+		if errors.Is(err, services.ErrAPIKeyIsNotExist) {
+			http.Error(w, "API key is not found", http.StatusNotFound)
+			return
+		}
+
+		http.Error(w, fmt.Sprintf("Error occurred during revoking api key: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
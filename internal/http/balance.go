@@ -1,11 +1,14 @@
 package router
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/middlewares"
 	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/services"
+	"github.com/go-chi/chi/v5"
 )
 
 func GetBalance(w http.ResponseWriter, r *http.Request) {
@@ -44,19 +47,23 @@ func CreateWithdrawal(w http.ResponseWriter, r *http.Request) {
 	}
 
 	user := middlewares.GetUserFromContext(w, r)
-	balance, err := (*balanceService).GetUserBalance(r.Context(), user.ID)
 
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Error occurred during getting balance: %s", err.Error()), http.StatusInternalServerError)
-		return
-	}
+	if err := (*balanceService).CreateWithdrawal(r.Context(), *data.ID, user.ID, *data.Sum); err != nil {
+		if errors.Is(err, services.ErrInsufficientFunds) {
+			http.Error(w, "There is not enough money", http.StatusPaymentRequired)
+			return
+		}
 
-	if balance.Current < *data.Sum {
-		http.Error(w, "There is not enough money", http.StatusPaymentRequired)
-		return
-	}
+		if errors.Is(err, services.ErrDuplicateWithdrawalByOriginalUser) {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		if errors.Is(err, services.ErrDuplicateWithdrawal) {
+			http.Error(w, "Withdrawal was created by another user", http.StatusConflict)
+			return
+		}
 
-	if err := (*balanceService).CreateWithdrawal(r.Context(), *data.ID, user.ID, *data.Sum); err != nil {
 		http.Error(w, fmt.Sprintf("Error occurred during creating withdrawal: %s", err.Error()), http.StatusInternalServerError)
 		return
 	}
@@ -68,7 +75,14 @@ func GetWithdrawals(w http.ResponseWriter, r *http.Request) {
 	balanceService := middlewares.GetServiceFromContext[models.BalanceService](w, r, middlewares.BalanceServiceKey)
 	user := middlewares.GetUserFromContext(w, r)
 
-	withdrawalFlow, err := (*balanceService).GetWithdrawalFlow(r.Context(), user.ID)
+	filter, err := parseListFilter(r, false)
+
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Query parameters are invalid: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	withdrawalFlow, next, err := (*balanceService).GetWithdrawalFlow(r.Context(), user.ID, filter)
 
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Error occurred during getting withdrawals: %s", err.Error()), http.StatusInternalServerError)
@@ -80,5 +94,26 @@ func GetWithdrawals(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	setNextLink(w, r, next)
+
 	middlewares.EncodeJSONResponse(w, withdrawalFlow)
 }
+
+func GetWithdrawal(w http.ResponseWriter, r *http.Request) {
+	balanceService := middlewares.GetServiceFromContext[models.BalanceService](w, r, middlewares.BalanceServiceKey)
+	user := middlewares.GetUserFromContext(w, r)
+
+	withdrawal, err := (*balanceService).GetWithdrawal(r.Context(), chi.URLParam(r, "order"), user.ID)
+
+	if err != nil {
+		if errors.Is(err, services.ErrWithdrawalIsNotExist) {
+			http.Error(w, "Withdrawal is not found", http.StatusNotFound)
+			return
+		}
+
+		http.Error(w, fmt.Sprintf("Error occurred during getting withdrawal: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	middlewares.EncodeJSONResponse(w, withdrawal)
+}
@@ -0,0 +1,75 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/middlewares"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/services"
+	"github.com/go-chi/chi/v5"
+)
+
+func GetDeadLetters(w http.ResponseWriter, r *http.Request) {
+	accrualService := middlewares.GetServiceFromContext[models.AccrualService](w, r, middlewares.AccrualServiceKey)
+
+	deadLetters, err := (*accrualService).GetDeadLetters(r.Context())
+
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error occurred during getting dead letters: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	if len(deadLetters) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	middlewares.EncodeJSONResponse(w, deadLetters)
+}
+
+func RetryDeadLetter(w http.ResponseWriter, r *http.Request) {
+	accrualService := middlewares.GetServiceFromContext[models.AccrualService](w, r, middlewares.AccrualServiceKey)
+
+	if err := (*accrualService).RetryDeadLetter(r.Context(), chi.URLParam(r, "order")); err != nil {
+		if errors.Is(err, services.ErrDeadLetterIsNotExist) {
+			http.Error(w, "Dead letter is not found", http.StatusNotFound)
+			return
+		}
+
+		http.Error(w, fmt.Sprintf("Error occurred during retrying dead letter: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func RetryDeadLetters(w http.ResponseWriter, r *http.Request) {
+	accrualService := middlewares.GetServiceFromContext[models.AccrualService](w, r, middlewares.AccrualServiceKey)
+
+	retried, err := (*accrualService).RetryDeadLetters(r.Context())
+
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error occurred during retrying dead letters: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	middlewares.EncodeJSONResponse(w, models.DeadLettersRetryResult{Retried: retried})
+}
+
+func DiscardDeadLetter(w http.ResponseWriter, r *http.Request) {
+	accrualService := middlewares.GetServiceFromContext[models.AccrualService](w, r, middlewares.AccrualServiceKey)
+
+	if err := (*accrualService).DiscardDeadLetter(r.Context(), chi.URLParam(r, "order")); err != nil {
+		if errors.Is(err, services.ErrDeadLetterIsNotExist) {
+			http.Error(w, "Dead letter is not found", http.StatusNotFound)
+			return
+		}
+
+		http.Error(w, fmt.Sprintf("Error occurred during discarding dead letter: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
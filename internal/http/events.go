@@ -0,0 +1,112 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/logger"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/middlewares"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
+	"go.uber.org/zap"
+)
+
+const defaultEventsHeartbeat = 15 * time.Second
+
+// StreamEvents streams user events as Server-Sent Events. A client resumes the stream
+// with the Last-Event-ID header, otherwise only events that happen after connecting are sent.
+func StreamEvents(heartbeat time.Duration) http.HandlerFunc {
+	if heartbeat <= 0 {
+		heartbeat = defaultEventsHeartbeat
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		eventService := middlewares.GetServiceFromContext[models.EventService](w, r, middlewares.EventServiceKey)
+		user := middlewares.GetUserFromContext(w, r)
+
+		// Subscribing before reading the storage guarantees that no event slips in between.
+		wakeup, unsubscribe := (*eventService).Subscribe(user.ID)
+		defer unsubscribe()
+
+		var lastEventID int64
+
+		if header := r.Header.Get("Last-Event-ID"); header != "" {
+			id, err := strconv.ParseInt(header, 10, 64)
+
+			if err != nil || id < 0 {
+				http.Error(w, "Last-Event-ID is invalid", http.StatusBadRequest)
+				return
+			}
+
+			lastEventID = id
+		} else {
+			id, err := (*eventService).LastEventID(r.Context(), user.ID)
+
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Error occurred during getting last event: %s", err.Error()), http.StatusInternalServerError)
+				return
+			}
+
+			lastEventID = id
+		}
+
+		controller := http.NewResponseController(w)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		if err := controller.Flush(); err != nil {
+			logger.Log.Error("events stream can't be flushed", zap.Error(err))
+			return
+		}
+
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+
+		for {
+			events, err := (*eventService).GetEvents(r.Context(), user.ID, lastEventID)
+
+			if err != nil {
+				if r.Context().Err() == nil {
+					logger.Log.Error("failed to get user events", zap.String("userID", user.ID), zap.Error(err))
+				}
+
+				return
+			}
+
+			for _, event := range events {
+				if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data); err != nil {
+					return
+				}
+
+				lastEventID = event.ID
+			}
+
+			if len(events) > 0 {
+				if err := controller.Flush(); err != nil {
+					return
+				}
+
+				continue
+			}
+
+			select {
+			case <-r.Context().Done():
+				return
+			case <-wakeup:
+			case <-ticker.C:
+				if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+					return
+				}
+
+				if err := controller.Flush(); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/middlewares"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/services"
+)
+
+func GetHealth(w http.ResponseWriter, r *http.Request) {
+	accrualService := middlewares.GetServiceFromContext[models.AccrualService](w, r, middlewares.AccrualServiceKey)
+
+	breakerState := (*accrualService).CircuitBreakerState()
+	status := models.HealthStatusOK
+
+	if breakerState != string(services.CircuitBreakerClosed) {
+		status = models.HealthStatusDegraded
+	}
+
+	middlewares.EncodeJSONResponse(w, models.Health{
+		Status:  status,
+		Accrual: models.AccrualHealth{CircuitBreaker: breakerState},
+	})
+}
@@ -10,11 +10,9 @@ import (
 	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/services"
 )
 
-// todo add invalidation prev token
 func Login(w http.ResponseWriter, r *http.Request) {
 	data := middlewares.GetParsedJSONData[models.UnknownUser](w, r)
 	authService := middlewares.GetServiceFromContext[models.AuthService](w, r, middlewares.AuthServiceKey)
-	jwtService := middlewares.GetServiceFromContext[models.JWTService](w, r, middlewares.JwtServiceKey)
 
 	if ok := IsUnknownUserDataValid(data); !ok {
 		http.Error(w, "Request doesn't contain login or password", http.StatusBadRequest)
@@ -36,12 +34,13 @@ func Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := (*jwtService).GenerateJWT(*data.Login)
+	refreshTokenService := middlewares.GetServiceFromContext[models.RefreshTokenService](w, r, middlewares.RefreshTokenServiceKey)
+	refreshToken, err := (*refreshTokenService).IssueRefreshToken(r.Context(), *data.Login)
 
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Error occurred during generating jwt token: %s", err.Error()), http.StatusInternalServerError)
+		http.Error(w, fmt.Sprintf("Error occurred during issuing refresh token: %s", err.Error()), http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	respondWithTokens(w, r, *data.Login, refreshToken)
 }
@@ -0,0 +1,69 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/middlewares"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
+)
+
+// Logout revokes the access token of the request. The refresh token of the session
+// may be passed in the body or the session cookie to revoke it as well.
+func Logout(w http.ResponseWriter, r *http.Request) {
+	var data models.RefreshTokenRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, fmt.Sprintf("Error occurred during unmarshaling data %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	jwtService := middlewares.GetServiceFromContext[models.JWTService](w, r, middlewares.JwtServiceKey)
+	refreshTokenService := middlewares.GetServiceFromContext[models.RefreshTokenService](w, r, middlewares.RefreshTokenServiceKey)
+	user := middlewares.GetUserFromContext(w, r)
+	token := middlewares.GetTokenFromContext(w, r)
+
+	if data.RefreshToken == nil {
+		if refreshToken := middlewares.GetSessionCookie(r, middlewares.RefreshTokenCookie); refreshToken != "" {
+			data.RefreshToken = &refreshToken
+		}
+	}
+
+	if data.RefreshToken != nil {
+		if err := (*refreshTokenService).RevokeRefreshToken(r.Context(), user.Login, *data.RefreshToken); err != nil {
+			http.Error(w, fmt.Sprintf("Error occurred during revoking refresh token: %s", err.Error()), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := (*jwtService).RevokeToken(r.Context(), token); err != nil {
+		http.Error(w, fmt.Sprintf("Error occurred during revoking token: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	middlewares.ClearSessionCookies(w, r)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// LogoutEverywhere revokes all access and refresh tokens of the user.
+func LogoutEverywhere(w http.ResponseWriter, r *http.Request) {
+	jwtService := middlewares.GetServiceFromContext[models.JWTService](w, r, middlewares.JwtServiceKey)
+	refreshTokenService := middlewares.GetServiceFromContext[models.RefreshTokenService](w, r, middlewares.RefreshTokenServiceKey)
+	user := middlewares.GetUserFromContext(w, r)
+
+	if err := (*refreshTokenService).RevokeAllRefreshTokens(r.Context(), user.Login); err != nil {
+		http.Error(w, fmt.Sprintf("Error occurred during revoking refresh tokens: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	if err := (*jwtService).RevokeAllTokens(r.Context(), user.Login); err != nil {
+		http.Error(w, fmt.Sprintf("Error occurred during revoking tokens: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	middlewares.ClearSessionCookies(w, r)
+	w.WriteHeader(http.StatusNoContent)
+}
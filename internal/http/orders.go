@@ -9,6 +9,7 @@ import (
 	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/services"
 
 	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/middlewares"
+	"github.com/go-chi/chi/v5"
 )
 
 func CreateOrder(w http.ResponseWriter, r *http.Request) {
@@ -53,7 +54,14 @@ func GetOrders(w http.ResponseWriter, r *http.Request) {
 	orderService := middlewares.GetServiceFromContext[models.OrderService](w, r, middlewares.OrderServiceKey)
 	user := middlewares.GetUserFromContext(w, r)
 
-	orders, err := (*orderService).GetOrders(r.Context(), user.ID)
+	filter, err := parseListFilter(r, true)
+
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Query parameters are invalid: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	orders, next, err := (*orderService).GetOrders(r.Context(), user.ID, filter)
 
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Error occurred during getting orders: %s", err.Error()), http.StatusInternalServerError)
@@ -65,5 +73,26 @@ func GetOrders(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	setNextLink(w, r, next)
+
 	middlewares.EncodeJSONResponse(w, orders)
 }
+
+func GetOrderHistory(w http.ResponseWriter, r *http.Request) {
+	orderService := middlewares.GetServiceFromContext[models.OrderService](w, r, middlewares.OrderServiceKey)
+	user := middlewares.GetUserFromContext(w, r)
+
+	history, err := (*orderService).GetOrderHistory(r.Context(), chi.URLParam(r, "number"), user.ID)
+
+	if err != nil {
+		if errors.Is(err, services.ErrOrderIsNotExist) {
+			http.Error(w, "Order is not found", http.StatusNotFound)
+			return
+		}
+
+		http.Error(w, fmt.Sprintf("Error occurred during getting order history: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	middlewares.EncodeJSONResponse(w, history)
+}
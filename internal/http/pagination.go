@@ -0,0 +1,111 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
+)
+
+const maxListLimit = 1000
+
+var errStatusFilterIsNotSupported = errors.New("status filter is not supported")
+
+// parseListFilter reads limit, cursor, status, from, to and sort query parameters.
+// Without parameters the filter selects all items in ascending order.
+func parseListFilter(r *http.Request, withStatuses bool) (models.ListFilter, error) {
+	query := r.URL.Query()
+	filter := models.ListFilter{Sort: models.SortAsc}
+
+	if limit := query.Get("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+
+		if err != nil || value < 1 || value > maxListLimit {
+			return filter, fmt.Errorf("limit must be a number from 1 to %d", maxListLimit)
+		}
+
+		filter.Limit = value
+	}
+
+	if cursor := query.Get("cursor"); cursor != "" {
+		after, err := models.DecodeCursor(cursor)
+
+		if err != nil {
+			return filter, err
+		}
+
+		filter.After = after
+	}
+
+	if statuses := query.Get("status"); statuses != "" {
+		if !withStatuses {
+			return filter, errStatusFilterIsNotSupported
+		}
+
+		for _, status := range strings.Split(statuses, ",") {
+			switch orderStatus := models.OrderStatus(strings.ToUpper(strings.TrimSpace(status))); orderStatus {
+			case models.StatusNew, models.StatusProcessing, models.StatusInvalid, models.StatusProcessed:
+				filter.Statuses = append(filter.Statuses, orderStatus)
+			default:
+				return filter, fmt.Errorf("status %q is unknown", status)
+			}
+		}
+	}
+
+	from, err := parseTimeParameter(r, "from")
+
+	if err != nil {
+		return filter, err
+	}
+
+	to, err := parseTimeParameter(r, "to")
+
+	if err != nil {
+		return filter, err
+	}
+
+	filter.From = from
+	filter.To = to
+
+	switch sort := models.SortDirection(strings.ToLower(query.Get("sort"))); sort {
+	case "":
+	case models.SortAsc, models.SortDesc:
+		filter.Sort = sort
+	default:
+		return filter, fmt.Errorf("sort must be %s or %s", models.SortAsc, models.SortDesc)
+	}
+
+	return filter, nil
+}
+
+func parseTimeParameter(r *http.Request, name string) (*time.Time, error) {
+	value := r.URL.Query().Get(name)
+
+	if value == "" {
+		return nil, nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, value)
+
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a RFC3339 date", name)
+	}
+
+	return &parsed, nil
+}
+
+// setNextLink points the Link header to the next page, keeping the rest of the query.
+func setNextLink(w http.ResponseWriter, r *http.Request, next *models.Cursor) {
+	if next == nil {
+		return
+	}
+
+	query := r.URL.Query()
+	query.Set("cursor", next.Encode())
+
+	w.Header().Set("Link", fmt.Sprintf("<%s?%s>; rel=\"next\"", r.URL.Path, query.Encode()))
+}
@@ -21,20 +21,12 @@ func IsUnknownUserDataValid(data models.UnknownUser) bool {
 func Register(w http.ResponseWriter, r *http.Request) {
 	data := middlewares.GetParsedJSONData[models.UnknownUser](w, r)
 	authService := middlewares.GetServiceFromContext[models.AuthService](w, r, middlewares.AuthServiceKey)
-	jwtService := middlewares.GetServiceFromContext[models.JWTService](w, r, middlewares.JwtServiceKey)
 
 	if ok := IsUnknownUserDataValid(data); !ok {
 		http.Error(w, "Request doesn't contain login or password", http.StatusBadRequest)
 		return
 	}
 
-	token, err := (*jwtService).GenerateJWT(*data.Login)
-
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Error occurred during generating jwt token: %s", err.Error()), http.StatusInternalServerError)
-		return
-	}
-
 	if err := (*authService).Register(r.Context(), data); err != nil {
 		if errors.Is(err, services.ErrUserIsAlreadyRegistered) {
 			http.Error(w, "User is already registered", http.StatusConflict)
@@ -45,5 +37,13 @@ func Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	refreshTokenService := middlewares.GetServiceFromContext[models.RefreshTokenService](w, r, middlewares.RefreshTokenServiceKey)
+	refreshToken, err := (*refreshTokenService).IssueRefreshToken(r.Context(), *data.Login)
+
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error occurred during issuing refresh token: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	respondWithTokens(w, r, *data.Login, refreshToken)
 }
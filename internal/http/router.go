@@ -3,6 +3,7 @@ package router
 import (
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/logger"
 	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/middlewares"
@@ -11,16 +12,25 @@ import (
 )
 
 type Config struct {
-	Endpoint string
+	Endpoint        string
+	CallbackSecret  string
+	CallbackMaxSkew time.Duration
+	EventsHeartbeat time.Duration
+	SessionCookies  bool
 }
 
 type Router struct {
-	config         Config
-	authService    models.AuthService
-	jwtService     models.JWTService
-	orderService   models.OrderService
-	accrualService models.AccrualService
-	balanceService models.BalanceService
+	config              Config
+	authService         models.AuthService
+	jwtService          models.JWTService
+	orderService        models.OrderService
+	accrualService      models.AccrualService
+	balanceService      models.BalanceService
+	idempotencyService  models.IdempotencyService
+	eventService        models.EventService
+	webhookService      models.WebhookService
+	refreshTokenService models.RefreshTokenService
+	apiKeyService       models.APIKeyService
 }
 
 func New(
@@ -30,6 +40,11 @@ func New(
 	orderService models.OrderService,
 	accrualService models.AccrualService,
 	balanceService models.BalanceService,
+	idempotencyService models.IdempotencyService,
+	eventService models.EventService,
+	webhookService models.WebhookService,
+	refreshTokenService models.RefreshTokenService,
+	apiKeyService models.APIKeyService,
 ) *Router {
 	return &Router{
 		config,
@@ -38,6 +53,11 @@ func New(
 		orderService,
 		accrualService,
 		balanceService,
+		idempotencyService,
+		eventService,
+		webhookService,
+		refreshTokenService,
+		apiKeyService,
 	}
 }
 
@@ -51,25 +71,94 @@ func (router *Router) get() chi.Router {
 			router.orderService,
 			router.accrualService,
 			router.balanceService,
+			router.idempotencyService,
+			router.eventService,
+			router.webhookService,
+			router.refreshTokenService,
+			router.apiKeyService,
 		),
 		logger.RequestLogger,
 		middlewares.AuthMiddleware().WithExcludedPaths(
 			"/api/user/register",
 			"/api/user/login",
-		).Middleware,
+			"/api/user/token/refresh",
+			"/api/health",
+			"/api/accrual/callback",
+			"/.well-known/jwks.json",
+		).WithSessionCookies(router.config.SessionCookies).Middleware,
 	)
 
+	r.Get("/api/health", GetHealth)
+	r.Get("/.well-known/jwks.json", GetJWKS)
+
+	if router.config.CallbackSecret != "" {
+		r.With(
+			middlewares.SignatureMiddleware(router.config.CallbackSecret, router.config.CallbackMaxSkew),
+			middlewares.JSONMiddleware[models.AccrualUpdate],
+		).Post("/api/accrual/callback", AccrualCallback)
+	}
+
 	r.Route("/api/user", func(r chi.Router) {
 		r.With(middlewares.JSONMiddleware[models.UnknownUser]).Post("/register", Register)
 		r.With(middlewares.JSONMiddleware[models.UnknownUser]).Post("/login", Login)
+		r.With(middlewares.JSONMiddleware[models.RefreshTokenRequest]).Post("/token/refresh", RefreshToken)
+
+		// Routes available to API keys with the scope.
+		r.With(
+			middlewares.RequireScope(models.APIKeyScopeOrdersWrite),
+			middlewares.IdempotencyMiddleware,
+			middlewares.TextMiddleware,
+		).Post("/orders", CreateOrder)
+		r.With(middlewares.RequireScope(models.APIKeyScopeOrdersRead)).Get("/orders", GetOrders)
+		r.With(middlewares.RequireScope(models.APIKeyScopeOrdersRead)).Get("/orders/{number}/history", GetOrderHistory)
+
+		r.With(middlewares.RequireScope(models.APIKeyScopeBalanceRead)).Get("/balance", GetBalance)
+		r.With(
+			middlewares.RequireScope(models.APIKeyScopeWithdraw),
+			middlewares.IdempotencyMiddleware,
+			middlewares.JSONMiddleware[models.Withdrawal],
+		).Post("/balance/withdraw", CreateWithdrawal)
+
+		r.With(middlewares.RequireScope(models.APIKeyScopeWithdrawalsRead)).Get("/withdrawals", GetWithdrawals)
+		r.With(middlewares.RequireScope(models.APIKeyScopeWithdrawalsRead)).Get("/withdrawals/{order}", GetWithdrawal)
+
+		// Routes available to user sessions only.
+		r.Group(func(r chi.Router) {
+			r.Use(middlewares.DenyAPIKeys)
+
+			r.Post("/logout", Logout)
+			r.Post("/logout/all", LogoutEverywhere)
+
+			r.Get("/events", StreamEvents(router.config.EventsHeartbeat))
+
+			r.With(middlewares.JSONMiddleware[models.WebhookRegistration]).Post("/webhooks", CreateWebhook)
+			r.Get("/webhooks", GetWebhooks)
+			r.Delete("/webhooks/{id}", DeleteWebhook)
+			r.Get("/webhooks/{id}/deliveries", GetWebhookDeliveries)
+
+			r.With(middlewares.JSONMiddleware[models.APIKeyRequest]).Post("/api-keys", CreateAPIKey)
+			r.Get("/api-keys", GetAPIKeys)
+			r.Delete("/api-keys/{id}", RevokeAPIKey)
+		})
+	})
+
+	r.Route("/api/admin", func(r chi.Router) {
+		r.Use(middlewares.DenyAPIKeys, middlewares.RequireRole(models.RoleAdmin))
 
-		r.With(middlewares.TextMiddleware).Post("/orders", CreateOrder)
-		r.Get("/orders", GetOrders)
+		r.Get("/users", GetUsers)
+		r.Route("/users/{login}", func(r chi.Router) {
+			r.Use(middlewares.TargetUserMiddleware("login"))
 
-		r.Get("/balance", GetBalance)
-		r.With(middlewares.JSONMiddleware[models.Withdrawal]).Post("/balance/withdraw", CreateWithdrawal)
+			r.Get("/orders", GetOrders)
+			r.Get("/balance", GetBalance)
+			r.Get("/withdrawals", GetWithdrawals)
+		})
+		r.Get("/orders/{number}", GetOrderDetails)
 
-		r.Get("/withdrawals", GetWithdrawals)
+		r.Get("/accrual/dead-letters", GetDeadLetters)
+		r.Post("/accrual/dead-letters/retry", RetryDeadLetters)
+		r.Post("/accrual/dead-letters/{order}/retry", RetryDeadLetter)
+		r.Delete("/accrual/dead-letters/{order}", DiscardDeadLetter)
 	})
 
 	return r
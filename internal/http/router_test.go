@@ -3,9 +3,11 @@ package router
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 	"time"
 
@@ -16,6 +18,7 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRegisterRoute(t *testing.T) {
@@ -24,9 +27,10 @@ func TestRegisterRoute(t *testing.T) {
 
 	authServiceMock := mock_models.NewMockAuthService(ctrl)
 	jwtServiceMock := mock_models.NewMockJWTService(ctrl)
+	refreshTokenServiceMock := mock_models.NewMockRefreshTokenService(ctrl)
 
 	testServer := httptest.NewServer(
-		New(Config{}, authServiceMock, jwtServiceMock, nil, nil, nil).get(),
+		New(Config{}, authServiceMock, jwtServiceMock, nil, nil, nil, nil, nil, nil, refreshTokenServiceMock, nil).get(),
 	)
 	defer testServer.Close()
 
@@ -78,7 +82,6 @@ func TestRegisterRoute(t *testing.T) {
 				Login := "user"
 				Password := "123"
 
-				jwtServiceMock.EXPECT().GenerateJWT("user").Return("token", nil)
 				authServiceMock.EXPECT().Register(gomock.Any(), models.UnknownUser{Login: &Login, Password: &Password}).Return(services.ErrUserIsAlreadyRegistered)
 			},
 			body: func() io.Reader {
@@ -98,8 +101,10 @@ func TestRegisterRoute(t *testing.T) {
 				Login := "user"
 				Password := "123"
 
-				jwtServiceMock.EXPECT().GenerateJWT("user").Return("token", nil)
+				authServiceMock.EXPECT().GetUser(gomock.Any(), "user").Return(&models.User{ID: "user-id", Login: "user", Role: models.RoleUser}, nil)
+				jwtServiceMock.EXPECT().GenerateJWT("user", models.RoleUser).Return("token", nil)
 				authServiceMock.EXPECT().Register(gomock.Any(), models.UnknownUser{Login: &Login, Password: &Password}).Return(nil)
+				refreshTokenServiceMock.EXPECT().IssueRefreshToken(gomock.Any(), "user").Return("refresh-token", nil)
 			},
 			body: func() io.Reader {
 				Login := "user"
@@ -108,7 +113,7 @@ func TestRegisterRoute(t *testing.T) {
 				return bytes.NewBuffer(data)
 			},
 			expectedCode:    http.StatusOK,
-			expectedMessage: "",
+			expectedMessage: "{\"access_token\":\"token\",\"refresh_token\":\"refresh-token\"}",
 		},
 	}
 
@@ -146,9 +151,10 @@ func TestLoginRoute(t *testing.T) {
 
 	authServiceMock := mock_models.NewMockAuthService(ctrl)
 	jwtServiceMock := mock_models.NewMockJWTService(ctrl)
+	refreshTokenServiceMock := mock_models.NewMockRefreshTokenService(ctrl)
 
 	testServer := httptest.NewServer(
-		New(Config{}, authServiceMock, jwtServiceMock, nil, nil, nil).get(),
+		New(Config{}, authServiceMock, jwtServiceMock, nil, nil, nil, nil, nil, nil, refreshTokenServiceMock, nil).get(),
 	)
 	defer testServer.Close()
 
@@ -239,8 +245,10 @@ func TestLoginRoute(t *testing.T) {
 				Login := "user"
 				Password := "123"
 
-				jwtServiceMock.EXPECT().GenerateJWT("user").Return("token", nil)
+				authServiceMock.EXPECT().GetUser(gomock.Any(), "user").Return(&models.User{ID: "user-id", Login: "user", Role: models.RoleUser}, nil)
+				jwtServiceMock.EXPECT().GenerateJWT("user", models.RoleUser).Return("token", nil)
 				authServiceMock.EXPECT().Login(gomock.Any(), models.UnknownUser{Login: &Login, Password: &Password}).Return(nil)
+				refreshTokenServiceMock.EXPECT().IssueRefreshToken(gomock.Any(), "user").Return("refresh-token", nil)
 			},
 			body: func() io.Reader {
 				Login := "user"
@@ -249,7 +257,7 @@ func TestLoginRoute(t *testing.T) {
 				return bytes.NewBuffer(data)
 			},
 			expectedCode:    http.StatusOK,
-			expectedMessage: "",
+			expectedMessage: "{\"access_token\":\"token\",\"refresh_token\":\"refresh-token\"}",
 			testHeader: func(t *testing.T, header http.Header) {
 				assert.Equal(t, "Bearer token", header.Get("Authorization"))
 			},
@@ -288,64 +296,76 @@ func TestLoginRoute(t *testing.T) {
 	}
 }
 
-func TestCreateOrderRoute(t *testing.T) {
+func TestRefreshTokenRoute(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	authServiceMock := mock_models.NewMockAuthService(ctrl)
 	jwtServiceMock := mock_models.NewMockJWTService(ctrl)
-	orderServiceMock := mock_models.NewMockOrderService(ctrl)
-	accrualServiceMock := mock_models.NewMockAccrualService(ctrl)
+	refreshTokenServiceMock := mock_models.NewMockRefreshTokenService(ctrl)
 
 	testServer := httptest.NewServer(
-		New(Config{}, authServiceMock, jwtServiceMock, orderServiceMock, accrualServiceMock, nil).get(),
+		New(Config{}, authServiceMock, jwtServiceMock, nil, nil, nil, nil, nil, nil, refreshTokenServiceMock, nil).get(),
 	)
 	defer testServer.Close()
 
 	testCases := []struct {
-		testName        string
-		methodName      string
-		targetURL       string
-		body            func() io.Reader
-		test            func(t *testing.T)
-		expectedCode    int
-		expectedMessage string
+		testName              string
+		body                  string
+		test                  func(t *testing.T)
+		expectedCode          int
+		expectedMessage       string
+		expectedAuthorization string
 	}{
 		{
-			testName:   "Should create order",
-			methodName: "POST",
-			targetURL:  "/api/user/orders",
+			testName: "Should rotate refresh token",
+			body:     `{"refresh_token":"refresh-token"}`,
 			test: func(t *testing.T) {
-				jwtToken := jwt.NewWithClaims(
-					jwt.SigningMethodHS256,
-					jwt.MapClaims{
-						"sub": "login",
-					})
-
-				user := models.User{ID: "user-id", Login: "user", Hash: "hash"}
-
-				jwtServiceMock.EXPECT().ValidateToken("token").Return(jwtToken, nil)
-				orderServiceMock.EXPECT().VerifyOrderID("order-id").Return(true)
-				orderServiceMock.EXPECT().CreateOrder(gomock.Any(), "order-id", "user-id").Return(nil)
-				authServiceMock.EXPECT().GetUser(gomock.Any(), "login").Return(&user, nil)
-				accrualServiceMock.EXPECT().CalculateAccrual("order-id")
+				refreshTokenServiceMock.EXPECT().RotateRefreshToken(gomock.Any(), "refresh-token").Return("user", "new-refresh-token", nil)
+				authServiceMock.EXPECT().GetUser(gomock.Any(), "user").Return(&models.User{ID: "user-id", Login: "user", Role: models.RoleUser}, nil)
+				jwtServiceMock.EXPECT().GenerateJWT("user", models.RoleUser).Return("token", nil)
 			},
-			body: func() io.Reader {
-				return bytes.NewBuffer([]byte("order-id"))
+			expectedCode:          http.StatusOK,
+			expectedMessage:       "{\"access_token\":\"token\",\"refresh_token\":\"new-refresh-token\"}",
+			expectedAuthorization: "Bearer token",
+		},
+		{
+			testName:        "Should return 400 when refresh token is absent",
+			body:            `{}`,
+			expectedCode:    http.StatusBadRequest,
+			expectedMessage: "Request doesn't contain refresh token\n",
+		},
+		{
+			testName: "Should return 401 when refresh token is invalid",
+			body:     `{"refresh_token":"refresh-token"}`,
+			test: func(t *testing.T) {
+				refreshTokenServiceMock.EXPECT().RotateRefreshToken(gomock.Any(), "refresh-token").Return("", "", services.ErrRefreshTokenIsInvalid)
 			},
-			expectedCode:    http.StatusAccepted,
-			expectedMessage: "",
+			expectedCode:    http.StatusUnauthorized,
+			expectedMessage: "Refresh token is invalid\n",
+		},
+		{
+			testName: "Should return 401 when refresh token is expired",
+			body:     `{"refresh_token":"refresh-token"}`,
+			test: func(t *testing.T) {
+				refreshTokenServiceMock.EXPECT().RotateRefreshToken(gomock.Any(), "refresh-token").Return("", "", services.ErrRefreshTokenIsExpired)
+			},
+			expectedCode:    http.StatusUnauthorized,
+			expectedMessage: "Refresh token is expired\n",
+		},
+		{
+			testName: "Should return 401 when refresh token is reused",
+			body:     `{"refresh_token":"refresh-token"}`,
+			test: func(t *testing.T) {
+				refreshTokenServiceMock.EXPECT().RotateRefreshToken(gomock.Any(), "refresh-token").Return("", "", services.ErrRefreshTokenIsReused)
+			},
+			expectedCode:    http.StatusUnauthorized,
+			expectedMessage: "Refresh token is already used, the session is revoked\n",
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.testName, func(t *testing.T) {
-			var body io.Reader
-
-			if tc.body != nil {
-				body = tc.body()
-			}
-
 			if tc.test != nil {
 				tc.test(t)
 			}
@@ -353,66 +373,104 @@ func TestCreateOrderRoute(t *testing.T) {
 			res, mes := utils.TestRequest(
 				t,
 				testServer,
-				tc.methodName,
-				tc.targetURL,
-				map[string]string{"Content-Type": "text/plain", "Authorization": "Bearer token"},
-				body,
+				"POST",
+				"/api/user/token/refresh",
+				map[string]string{"Content-Type": "application/json"},
+				bytes.NewBufferString(tc.body),
 			)
 			res.Body.Close()
 
 			assert.Equal(t, tc.expectedCode, res.StatusCode)
 			assert.Equal(t, tc.expectedMessage, mes)
+			assert.Equal(t, tc.expectedAuthorization, res.Header.Get("Authorization"))
 		})
 	}
 }
 
-func TestGerOrdersRoute(t *testing.T) {
+func TestLogoutRoutes(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	authServiceMock := mock_models.NewMockAuthService(ctrl)
 	jwtServiceMock := mock_models.NewMockJWTService(ctrl)
-	orderServiceMock := mock_models.NewMockOrderService(ctrl)
+	refreshTokenServiceMock := mock_models.NewMockRefreshTokenService(ctrl)
 
 	testServer := httptest.NewServer(
-		New(Config{}, authServiceMock, jwtServiceMock, orderServiceMock, nil, nil).get(),
+		New(Config{}, authServiceMock, jwtServiceMock, nil, nil, nil, nil, nil, nil, refreshTokenServiceMock, nil).get(),
 	)
 	defer testServer.Close()
 
+	jwtToken := jwt.NewWithClaims(
+		jwt.SigningMethodHS256,
+		jwt.MapClaims{
+			"sub": "login",
+		})
+	authorize := func() {
+		user := models.User{ID: "user-id", Login: "login", Hash: "hash"}
+
+		authServiceMock.EXPECT().GetUser(gomock.Any(), "login").Return(&user, nil)
+		jwtServiceMock.EXPECT().ValidateToken(gomock.Any(), "token").Return(jwtToken, nil)
+	}
+
 	testCases := []struct {
 		testName        string
-		methodName      string
 		targetURL       string
+		body            string
 		test            func(t *testing.T)
 		expectedCode    int
 		expectedMessage string
 	}{
 		{
-			testName:   "Should return orders",
-			methodName: "GET",
-			targetURL:  "/api/user/orders",
+			testName:  "Should revoke access token",
+			targetURL: "/api/user/logout",
 			test: func(t *testing.T) {
-				jwtToken := jwt.NewWithClaims(
-					jwt.SigningMethodHS256,
-					jwt.MapClaims{
-						"sub": "login",
-					})
-
-				user := models.User{ID: "user-id", Login: "user", Hash: "hash"}
-
-				authServiceMock.EXPECT().GetUser(gomock.Any(), "login").Return(&user, nil)
-				jwtServiceMock.EXPECT().ValidateToken("token").Return(jwtToken, nil)
-				orderServiceMock.EXPECT().GetOrders(gomock.Any(), "user-id").Return([]models.Order{
-					{
-						ID:         "order-id",
-						Status:     "StatusNew",
-						Accrual:    nil,
-						UploadedAt: utils.RFC3339Date{Time: time.Date(2009, 11, 17, 0, 0, 0, 0, time.UTC)},
-					},
-				}, nil)
+				authorize()
+				jwtServiceMock.EXPECT().RevokeToken(gomock.Any(), jwtToken).Return(nil)
 			},
-			expectedCode:    http.StatusOK,
-			expectedMessage: "[{\"number\":\"order-id\",\"status\":\"StatusNew\",\"uploaded_at\":\"2009-11-17T00:00:00Z\"}]",
+			expectedCode:    http.StatusNoContent,
+			expectedMessage: "",
+		},
+		{
+			testName:  "Should revoke access and refresh tokens",
+			targetURL: "/api/user/logout",
+			body:      `{"refresh_token":"refresh-token"}`,
+			test: func(t *testing.T) {
+				authorize()
+				refreshTokenServiceMock.EXPECT().RevokeRefreshToken(gomock.Any(), "login", "refresh-token").Return(nil)
+				jwtServiceMock.EXPECT().RevokeToken(gomock.Any(), jwtToken).Return(nil)
+			},
+			expectedCode:    http.StatusNoContent,
+			expectedMessage: "",
+		},
+		{
+			testName:  "Should return 400 when body is malformed",
+			targetURL: "/api/user/logout",
+			body:      `{"refresh_token":`,
+			test: func(t *testing.T) {
+				authorize()
+			},
+			expectedCode:    http.StatusBadRequest,
+			expectedMessage: "Error occurred during unmarshaling data unexpected EOF\n",
+		},
+		{
+			testName:  "Should revoke all tokens of the user",
+			targetURL: "/api/user/logout/all",
+			test: func(t *testing.T) {
+				authorize()
+				refreshTokenServiceMock.EXPECT().RevokeAllRefreshTokens(gomock.Any(), "login").Return(nil)
+				jwtServiceMock.EXPECT().RevokeAllTokens(gomock.Any(), "login").Return(nil)
+			},
+			expectedCode:    http.StatusNoContent,
+			expectedMessage: "",
+		},
+		{
+			testName:  "Should return 401 when token is revoked",
+			targetURL: "/api/user/logout",
+			test: func(t *testing.T) {
+				jwtServiceMock.EXPECT().ValidateToken(gomock.Any(), "token").Return(nil, services.ErrTokenIsRevoked)
+			},
+			expectedCode:    http.StatusUnauthorized,
+			expectedMessage: "Token is revoked\n",
 		},
 	}
 
@@ -425,10 +483,10 @@ func TestGerOrdersRoute(t *testing.T) {
 			res, mes := utils.TestRequest(
 				t,
 				testServer,
-				tc.methodName,
+				"POST",
 				tc.targetURL,
-				map[string]string{"Authorization": "Bearer token"},
-				nil,
+				map[string]string{"Content-Type": "application/json", "Authorization": "Bearer token"},
+				bytes.NewBufferString(tc.body),
 			)
 			res.Body.Close()
 
@@ -438,46 +496,146 @@ func TestGerOrdersRoute(t *testing.T) {
 	}
 }
 
-func TestGerBalanceRoute(t *testing.T) {
+func TestSessionCookies(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	authServiceMock := mock_models.NewMockAuthService(ctrl)
 	jwtServiceMock := mock_models.NewMockJWTService(ctrl)
-	balanceServiceMock := mock_models.NewMockBalanceService(ctrl)
+	refreshTokenServiceMock := mock_models.NewMockRefreshTokenService(ctrl)
 
 	testServer := httptest.NewServer(
-		New(Config{}, authServiceMock, jwtServiceMock, nil, nil, balanceServiceMock).get(),
+		New(Config{SessionCookies: true}, authServiceMock, jwtServiceMock, nil, nil, nil, nil, nil, nil, refreshTokenServiceMock, nil).get(),
 	)
 	defer testServer.Close()
 
+	jwtToken := jwt.NewWithClaims(
+		jwt.SigningMethodHS256,
+		jwt.MapClaims{
+			"sub": "login",
+		})
+	authorize := func() {
+		user := models.User{ID: "user-id", Login: "login", Hash: "hash"}
+
+		authServiceMock.EXPECT().GetUser(gomock.Any(), "login").Return(&user, nil)
+		jwtServiceMock.EXPECT().ValidateToken(gomock.Any(), "token").Return(jwtToken, nil)
+	}
+	sessionCookies := "access_token=token; refresh_token=refresh-token; csrf_token=csrf"
+
 	testCases := []struct {
 		testName        string
-		methodName      string
 		targetURL       string
+		headers         map[string]string
+		body            string
 		test            func(t *testing.T)
 		expectedCode    int
 		expectedMessage string
+		testCookies     func(t *testing.T, cookies []*http.Cookie)
 	}{
 		{
-			testName:   "Should return balance",
-			methodName: "GET",
-			targetURL:  "/api/user/balance",
+			testName:  "Should set session cookies on login",
+			targetURL: "/api/user/login",
+			headers:   map[string]string{"Content-Type": "application/json"},
+			body:      `{"login":"login","password":"password"}`,
 			test: func(t *testing.T) {
-				jwtToken := jwt.NewWithClaims(
-					jwt.SigningMethodHS256,
-					jwt.MapClaims{
-						"sub": "login",
-					})
-
-				user := models.User{ID: "user-id", Login: "user", Hash: "hash"}
+				authServiceMock.EXPECT().Login(gomock.Any(), gomock.Any()).Return(nil)
+				refreshTokenServiceMock.EXPECT().IssueRefreshToken(gomock.Any(), "login").Return("refresh-token", nil)
+				authServiceMock.EXPECT().GetUser(gomock.Any(), "login").Return(&models.User{ID: "user-id", Login: "login", Role: models.RoleUser}, nil)
+				jwtServiceMock.EXPECT().GenerateJWT("login", models.RoleUser).Return("token", nil)
+			},
+			expectedCode:    http.StatusOK,
+			expectedMessage: "{\"access_token\":\"token\",\"refresh_token\":\"refresh-token\"}",
+			testCookies: func(t *testing.T, cookies []*http.Cookie) {
+				require.Len(t, cookies, 3)
+
+				assert.Equal(t, "access_token", cookies[0].Name)
+				assert.Equal(t, "token", cookies[0].Value)
+				assert.True(t, cookies[0].HttpOnly)
+				assert.True(t, cookies[0].Secure)
+				assert.Equal(t, http.SameSiteStrictMode, cookies[0].SameSite)
+
+				assert.Equal(t, "refresh_token", cookies[1].Name)
+				assert.Equal(t, "refresh-token", cookies[1].Value)
+				assert.Equal(t, "/api/user", cookies[1].Path)
+				assert.True(t, cookies[1].HttpOnly)
+
+				assert.Equal(t, "csrf_token", cookies[2].Name)
+				assert.NotEmpty(t, cookies[2].Value)
+				assert.False(t, cookies[2].HttpOnly)
+			},
+		},
+		{
+			testName:        "Should reject cookie authenticated request without csrf token",
+			targetURL:       "/api/user/logout",
+			headers:         map[string]string{"Cookie": sessionCookies},
+			expectedCode:    http.StatusForbidden,
+			expectedMessage: "CSRF token is invalid\n",
+		},
+		{
+			testName:        "Should reject cookie authenticated request with wrong csrf token",
+			targetURL:       "/api/user/logout",
+			headers:         map[string]string{"Cookie": sessionCookies, "X-CSRF-Token": "wrong"},
+			expectedCode:    http.StatusForbidden,
+			expectedMessage: "CSRF token is invalid\n",
+		},
+		{
+			testName:  "Should not require csrf token with bearer token",
+			targetURL: "/api/user/logout",
+			headers:   map[string]string{"Cookie": sessionCookies, "Authorization": "Bearer token"},
+			test: func(t *testing.T) {
+				authorize()
+				refreshTokenServiceMock.EXPECT().RevokeRefreshToken(gomock.Any(), "login", "refresh-token").Return(nil)
+				jwtServiceMock.EXPECT().RevokeToken(gomock.Any(), jwtToken).Return(nil)
+			},
+			expectedCode:    http.StatusNoContent,
+			expectedMessage: "",
+		},
+		{
+			testName:  "Should logout with session cookies and clear them",
+			targetURL: "/api/user/logout",
+			headers:   map[string]string{"Cookie": sessionCookies, "X-CSRF-Token": "csrf"},
+			test: func(t *testing.T) {
+				authorize()
+				refreshTokenServiceMock.EXPECT().RevokeRefreshToken(gomock.Any(), "login", "refresh-token").Return(nil)
+				jwtServiceMock.EXPECT().RevokeToken(gomock.Any(), jwtToken).Return(nil)
+			},
+			expectedCode:    http.StatusNoContent,
+			expectedMessage: "",
+			testCookies: func(t *testing.T, cookies []*http.Cookie) {
+				require.Len(t, cookies, 3)
 
-				authServiceMock.EXPECT().GetUser(gomock.Any(), "login").Return(&user, nil)
-				jwtServiceMock.EXPECT().ValidateToken("token").Return(jwtToken, nil)
-				balanceServiceMock.EXPECT().GetUserBalance(gomock.Any(), "user-id").Return(models.Balance{Current: 100.2, Withdrawn: 100.3}, nil)
+				for _, cookie := range cookies {
+					assert.Empty(t, cookie.Value)
+					assert.Equal(t, -1, cookie.MaxAge)
+				}
+			},
+		},
+		{
+			testName:        "Should reject refresh from cookie without csrf token",
+			targetURL:       "/api/user/token/refresh",
+			headers:         map[string]string{"Content-Type": "application/json", "Cookie": sessionCookies},
+			body:            `{}`,
+			expectedCode:    http.StatusForbidden,
+			expectedMessage: "CSRF token is invalid\n",
+		},
+		{
+			testName:  "Should refresh from cookie",
+			targetURL: "/api/user/token/refresh",
+			headers:   map[string]string{"Content-Type": "application/json", "Cookie": sessionCookies, "X-CSRF-Token": "csrf"},
+			body:      `{}`,
+			test: func(t *testing.T) {
+				refreshTokenServiceMock.EXPECT().RotateRefreshToken(gomock.Any(), "refresh-token").Return("login", "new-refresh-token", nil)
+				authServiceMock.EXPECT().GetUser(gomock.Any(), "login").Return(&models.User{ID: "user-id", Login: "login", Role: models.RoleUser}, nil)
+				jwtServiceMock.EXPECT().GenerateJWT("login", models.RoleUser).Return("new-token", nil)
 			},
 			expectedCode:    http.StatusOK,
-			expectedMessage: "{\"current\":100.2,\"withdrawn\":100.3}",
+			expectedMessage: "{\"access_token\":\"new-token\",\"refresh_token\":\"new-refresh-token\"}",
+			testCookies: func(t *testing.T, cookies []*http.Cookie) {
+				require.Len(t, cookies, 3)
+				assert.Equal(t, "new-token", cookies[0].Value)
+				assert.Equal(t, "new-refresh-token", cookies[1].Value)
+				assert.NotEqual(t, "csrf", cookies[2].Value)
+			},
 		},
 	}
 
@@ -490,73 +648,209 @@ func TestGerBalanceRoute(t *testing.T) {
 			res, mes := utils.TestRequest(
 				t,
 				testServer,
-				tc.methodName,
+				"POST",
 				tc.targetURL,
-				map[string]string{"Authorization": "Bearer token"},
-				nil,
+				tc.headers,
+				bytes.NewBufferString(tc.body),
 			)
 			res.Body.Close()
 
 			assert.Equal(t, tc.expectedCode, res.StatusCode)
 			assert.Equal(t, tc.expectedMessage, mes)
+
+			if tc.testCookies != nil {
+				tc.testCookies(t, res.Cookies())
+			}
 		})
 	}
 }
 
-func TestCreateWithdrawalRoute(t *testing.T) {
+func TestAPIKeyRoutes(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	authServiceMock := mock_models.NewMockAuthService(ctrl)
 	jwtServiceMock := mock_models.NewMockJWTService(ctrl)
 	orderServiceMock := mock_models.NewMockOrderService(ctrl)
-	balanceServiceMock := mock_models.NewMockBalanceService(ctrl)
+	apiKeyServiceMock := mock_models.NewMockAPIKeyService(ctrl)
 
 	testServer := httptest.NewServer(
-		New(Config{}, authServiceMock, jwtServiceMock, orderServiceMock, nil, balanceServiceMock).get(),
+		New(Config{}, authServiceMock, jwtServiceMock, orderServiceMock, nil, nil, nil, nil, nil, nil, apiKeyServiceMock).get(),
 	)
 	defer testServer.Close()
 
+	user := models.User{ID: "user-id", Login: "login", Hash: "hash"}
+	authorize := func() {
+		jwtToken := jwt.NewWithClaims(
+			jwt.SigningMethodHS256,
+			jwt.MapClaims{
+				"sub": "login",
+			})
+
+		authServiceMock.EXPECT().GetUser(gomock.Any(), "login").Return(&user, nil)
+		jwtServiceMock.EXPECT().ValidateToken(gomock.Any(), "token").Return(jwtToken, nil)
+	}
+	authorizeAPIKey := func(scopes ...models.APIKeyScope) {
+		authServiceMock.EXPECT().GetUser(gomock.Any(), "login").Return(&user, nil)
+		apiKeyServiceMock.EXPECT().AuthenticateAPIKey(gomock.Any(), "gmk_key").Return(&models.APIKeyPrincipal{
+			KeyID:  "key-id",
+			Login:  "login",
+			Scopes: scopes,
+		}, nil)
+	}
+	createdAt := utils.RFC3339Date{Time: time.Date(2009, 11, 17, 0, 0, 0, 0, time.UTC)}
+	expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
 	testCases := []struct {
 		testName        string
 		methodName      string
 		targetURL       string
+		headers         map[string]string
+		body            string
 		test            func(t *testing.T)
-		body            func() io.Reader
 		expectedCode    int
 		expectedMessage string
 	}{
 		{
-			testName:   "Should create withdraw",
+			testName:   "Should create api key",
 			methodName: "POST",
-			targetURL:  "/api/user/balance/withdraw",
+			targetURL:  "/api/user/api-keys",
+			headers:    map[string]string{"Authorization": "Bearer token", "Content-Type": "application/json"},
+			body:       `{"name":"pos","scopes":["orders:write"],"expires_at":"2030-01-01T00:00:00Z"}`,
 			test: func(t *testing.T) {
-				jwtToken := jwt.NewWithClaims(
-					jwt.SigningMethodHS256,
-					jwt.MapClaims{
-						"sub": "login",
-					})
-
-				user := models.User{ID: "user-id", Login: "user", Hash: "hash"}
-				orderID := "withdraw-id"
-				sum := 50.2
-
-				authServiceMock.EXPECT().GetUser(gomock.Any(), "login").Return(&user, nil)
-				jwtServiceMock.EXPECT().ValidateToken("token").Return(jwtToken, nil)
-				orderServiceMock.EXPECT().VerifyOrderID(orderID).Return(true)
-				balanceServiceMock.EXPECT().GetUserBalance(gomock.Any(), "user-id").Return(models.Balance{Current: 100.2, Withdrawn: 100.3}, nil)
-				balanceServiceMock.EXPECT().CreateWithdrawal(gomock.Any(), orderID, "user-id", sum).Return(nil)
+				authorize()
+				apiKeyServiceMock.EXPECT().
+					CreateAPIKey(gomock.Any(), "user-id", "pos", []models.APIKeyScope{models.APIKeyScopeOrdersWrite}, &expiresAt).
+					Return(models.APIKey{
+						ID:        "key-id",
+						Name:      "pos",
+						Prefix:    "gmk_abcdefgh",
+						Key:       "gmk_key",
+						Scopes:    []models.APIKeyScope{models.APIKeyScopeOrdersWrite},
+						ExpiresAt: &utils.RFC3339Date{Time: expiresAt},
+						CreatedAt: createdAt,
+					}, nil)
 			},
-			body: func() io.Reader {
-				ID := "withdraw-id"
-				Sum := 50.2
-
-				data, _ := json.Marshal(models.Withdrawal{ID: &ID, Sum: &Sum})
-				return bytes.NewBuffer(data)
+			expectedCode:    http.StatusCreated,
+			expectedMessage: "{\"id\":\"key-id\",\"name\":\"pos\",\"prefix\":\"gmk_abcdefgh\",\"key\":\"gmk_key\",\"scopes\":[\"orders:write\"],\"expires_at\":\"2030-01-01T00:00:00Z\",\"created_at\":\"2009-11-17T00:00:00Z\"}",
+		},
+		{
+			testName:   "Should return 422 when scopes are invalid",
+			methodName: "POST",
+			targetURL:  "/api/user/api-keys",
+			headers:    map[string]string{"Authorization": "Bearer token", "Content-Type": "application/json"},
+			body:       `{"name":"pos","scopes":["everything"]}`,
+			test: func(t *testing.T) {
+				authorize()
+				apiKeyServiceMock.EXPECT().
+					CreateAPIKey(gomock.Any(), "user-id", "pos", []models.APIKeyScope{"everything"}, nil).
+					Return(models.APIKey{}, services.ErrAPIKeyScopesAreInvalid)
+			},
+			expectedCode:    http.StatusUnprocessableEntity,
+			expectedMessage: "API key is invalid: api key scopes are invalid\n",
+		},
+		{
+			testName:   "Should list api keys",
+			methodName: "GET",
+			targetURL:  "/api/user/api-keys",
+			headers:    map[string]string{"Authorization": "Bearer token"},
+			test: func(t *testing.T) {
+				authorize()
+				apiKeyServiceMock.EXPECT().GetAPIKeys(gomock.Any(), "user-id").Return([]models.APIKey{
+					{
+						ID:         "key-id",
+						Name:       "pos",
+						Prefix:     "gmk_abcdefgh",
+						Scopes:     []models.APIKeyScope{models.APIKeyScopeOrdersWrite},
+						LastUsedAt: &createdAt,
+						CreatedAt:  createdAt,
+					},
+				}, nil)
 			},
 			expectedCode:    http.StatusOK,
+			expectedMessage: "[{\"id\":\"key-id\",\"name\":\"pos\",\"prefix\":\"gmk_abcdefgh\",\"scopes\":[\"orders:write\"],\"last_used_at\":\"2009-11-17T00:00:00Z\",\"created_at\":\"2009-11-17T00:00:00Z\"}]",
+		},
+		{
+			testName:   "Should return 404 when revoked api key is not found",
+			methodName: "DELETE",
+			targetURL:  "/api/user/api-keys/key-id",
+			headers:    map[string]string{"Authorization": "Bearer token"},
+			test: func(t *testing.T) {
+				authorize()
+				apiKeyServiceMock.EXPECT().RevokeAPIKey(gomock.Any(), "key-id", "user-id").Return(services.ErrAPIKeyIsNotExist)
+			},
+			expectedCode:    http.StatusNotFound,
+			expectedMessage: "API key is not found\n",
+		},
+		{
+			testName:   "Should revoke api key",
+			methodName: "DELETE",
+			targetURL:  "/api/user/api-keys/key-id",
+			headers:    map[string]string{"Authorization": "Bearer token"},
+			test: func(t *testing.T) {
+				authorize()
+				apiKeyServiceMock.EXPECT().RevokeAPIKey(gomock.Any(), "key-id", "user-id").Return(nil)
+			},
+			expectedCode:    http.StatusNoContent,
+			expectedMessage: "",
+		},
+		{
+			testName:   "Should authenticate api key with the scope",
+			methodName: "GET",
+			targetURL:  "/api/user/orders",
+			headers:    map[string]string{"X-API-Key": "gmk_key"},
+			test: func(t *testing.T) {
+				authorizeAPIKey(models.APIKeyScopeOrdersRead)
+				orderServiceMock.EXPECT().GetOrders(gomock.Any(), "user-id", models.ListFilter{Sort: models.SortAsc}).Return([]models.Order{}, nil, nil)
+			},
+			expectedCode:    http.StatusNoContent,
 			expectedMessage: "",
 		},
+		{
+			testName:   "Should return 403 when api key doesn't have the scope",
+			methodName: "POST",
+			targetURL:  "/api/user/orders",
+			headers:    map[string]string{"X-API-Key": "gmk_key", "Content-Type": "text/plain"},
+			body:       "12345678903",
+			test: func(t *testing.T) {
+				authorizeAPIKey(models.APIKeyScopeOrdersRead)
+			},
+			expectedCode:    http.StatusForbidden,
+			expectedMessage: "API key doesn't have the orders:write scope\n",
+		},
+		{
+			testName:   "Should return 403 when api key manages api keys",
+			methodName: "GET",
+			targetURL:  "/api/user/api-keys",
+			headers:    map[string]string{"X-API-Key": "gmk_key"},
+			test: func(t *testing.T) {
+				authorizeAPIKey(models.APIKeyScopeOrdersRead, models.APIKeyScopeOrdersWrite)
+			},
+			expectedCode:    http.StatusForbidden,
+			expectedMessage: "Access is forbidden for API keys\n",
+		},
+		{
+			testName:   "Should return 401 when api key is invalid",
+			methodName: "GET",
+			targetURL:  "/api/user/orders",
+			headers:    map[string]string{"X-API-Key": "gmk_key"},
+			test: func(t *testing.T) {
+				apiKeyServiceMock.EXPECT().AuthenticateAPIKey(gomock.Any(), "gmk_key").Return(nil, services.ErrAPIKeyIsInvalid)
+			},
+			expectedCode:    http.StatusUnauthorized,
+			expectedMessage: "API key is invalid\n",
+		},
+		{
+			testName:   "Should return 401 when api key is expired",
+			methodName: "GET",
+			targetURL:  "/api/user/orders",
+			headers:    map[string]string{"X-API-Key": "gmk_key"},
+			test: func(t *testing.T) {
+				apiKeyServiceMock.EXPECT().AuthenticateAPIKey(gomock.Any(), "gmk_key").Return(nil, services.ErrAPIKeyIsExpired)
+			},
+			expectedCode:    http.StatusUnauthorized,
+			expectedMessage: "API key is expired\n",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -570,8 +864,8 @@ func TestCreateWithdrawalRoute(t *testing.T) {
 				testServer,
 				tc.methodName,
 				tc.targetURL,
-				map[string]string{"Content-Type": "application/json", "Authorization": "Bearer token"},
-				tc.body(),
+				tc.headers,
+				bytes.NewBufferString(tc.body),
 			)
 			res.Body.Close()
 
@@ -581,17 +875,17 @@ func TestCreateWithdrawalRoute(t *testing.T) {
 	}
 }
 
-func TestGetWithdrawalsRoute(t *testing.T) {
+func TestCreateOrderRoute(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	authServiceMock := mock_models.NewMockAuthService(ctrl)
 	jwtServiceMock := mock_models.NewMockJWTService(ctrl)
 	orderServiceMock := mock_models.NewMockOrderService(ctrl)
-	balanceServiceMock := mock_models.NewMockBalanceService(ctrl)
+	accrualServiceMock := mock_models.NewMockAccrualService(ctrl)
 
 	testServer := httptest.NewServer(
-		New(Config{}, authServiceMock, jwtServiceMock, orderServiceMock, nil, balanceServiceMock).get(),
+		New(Config{}, authServiceMock, jwtServiceMock, orderServiceMock, accrualServiceMock, nil, nil, nil, nil, nil, nil).get(),
 	)
 	defer testServer.Close()
 
@@ -599,14 +893,15 @@ func TestGetWithdrawalsRoute(t *testing.T) {
 		testName        string
 		methodName      string
 		targetURL       string
+		body            func() io.Reader
 		test            func(t *testing.T)
 		expectedCode    int
 		expectedMessage string
 	}{
 		{
-			testName:   "Should returns withdrawals",
-			methodName: "GET",
-			targetURL:  "/api/user/withdrawals",
+			testName:   "Should create order",
+			methodName: "POST",
+			targetURL:  "/api/user/orders",
 			test: func(t *testing.T) {
 				jwtToken := jwt.NewWithClaims(
 					jwt.SigningMethodHS256,
@@ -616,18 +911,1456 @@ func TestGetWithdrawalsRoute(t *testing.T) {
 
 				user := models.User{ID: "user-id", Login: "user", Hash: "hash"}
 
+				jwtServiceMock.EXPECT().ValidateToken(gomock.Any(), "token").Return(jwtToken, nil)
+				orderServiceMock.EXPECT().VerifyOrderID("order-id").Return(true)
+				orderServiceMock.EXPECT().CreateOrder(gomock.Any(), "order-id", "user-id").Return(nil)
 				authServiceMock.EXPECT().GetUser(gomock.Any(), "login").Return(&user, nil)
-				jwtServiceMock.EXPECT().ValidateToken("token").Return(jwtToken, nil)
-				balanceServiceMock.EXPECT().GetWithdrawalFlow(gomock.Any(), "user-id").Return([]models.WithdrawalFlowItem{
-					{
-						OrderID:     "order-id",
-						Sum:         123.123,
-						ProcessedAt: utils.RFC3339Date{Time: time.Date(2009, 11, 17, 0, 0, 0, 0, time.UTC)},
+				accrualServiceMock.EXPECT().CalculateAccrual("order-id")
+			},
+			body: func() io.Reader {
+				return bytes.NewBuffer([]byte("order-id"))
+			},
+			expectedCode:    http.StatusAccepted,
+			expectedMessage: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			var body io.Reader
+
+			if tc.body != nil {
+				body = tc.body()
+			}
+
+			if tc.test != nil {
+				tc.test(t)
+			}
+
+			res, mes := utils.TestRequest(
+				t,
+				testServer,
+				tc.methodName,
+				tc.targetURL,
+				map[string]string{"Content-Type": "text/plain", "Authorization": "Bearer token"},
+				body,
+			)
+			res.Body.Close()
+
+			assert.Equal(t, tc.expectedCode, res.StatusCode)
+			assert.Equal(t, tc.expectedMessage, mes)
+		})
+	}
+}
+
+func TestGerOrdersRoute(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	authServiceMock := mock_models.NewMockAuthService(ctrl)
+	jwtServiceMock := mock_models.NewMockJWTService(ctrl)
+	orderServiceMock := mock_models.NewMockOrderService(ctrl)
+
+	testServer := httptest.NewServer(
+		New(Config{}, authServiceMock, jwtServiceMock, orderServiceMock, nil, nil, nil, nil, nil, nil, nil).get(),
+	)
+	defer testServer.Close()
+
+	testCases := []struct {
+		testName        string
+		methodName      string
+		targetURL       string
+		test            func(t *testing.T)
+		expectedCode    int
+		expectedMessage string
+		expectedLink    string
+	}{
+		{
+			testName:   "Should return orders",
+			methodName: "GET",
+			targetURL:  "/api/user/orders",
+			test: func(t *testing.T) {
+				jwtToken := jwt.NewWithClaims(
+					jwt.SigningMethodHS256,
+					jwt.MapClaims{
+						"sub": "login",
+					})
+
+				user := models.User{ID: "user-id", Login: "user", Hash: "hash"}
+
+				authServiceMock.EXPECT().GetUser(gomock.Any(), "login").Return(&user, nil)
+				jwtServiceMock.EXPECT().ValidateToken(gomock.Any(), "token").Return(jwtToken, nil)
+				orderServiceMock.EXPECT().GetOrders(gomock.Any(), "user-id", models.ListFilter{Sort: models.SortAsc}).Return([]models.Order{
+					{
+						ID:         "order-id",
+						Status:     "StatusNew",
+						Accrual:    nil,
+						UploadedAt: utils.RFC3339Date{Time: time.Date(2009, 11, 17, 0, 0, 0, 0, time.UTC)},
+					},
+				}, nil, nil)
+			},
+			expectedCode:    http.StatusOK,
+			expectedMessage: "[{\"number\":\"order-id\",\"status\":\"StatusNew\",\"uploaded_at\":\"2009-11-17T00:00:00Z\"}]",
+		},
+		{
+			testName:   "Should return page of orders with next link",
+			methodName: "GET",
+			targetURL:  "/api/user/orders?limit=1&status=new,processed&from=2009-11-01T00:00:00Z&sort=desc",
+			test: func(t *testing.T) {
+				jwtToken := jwt.NewWithClaims(
+					jwt.SigningMethodHS256,
+					jwt.MapClaims{
+						"sub": "login",
+					})
+
+				user := models.User{ID: "user-id", Login: "user", Hash: "hash"}
+				from := time.Date(2009, 11, 1, 0, 0, 0, 0, time.UTC)
+				uploadedAt := time.Date(2009, 11, 17, 0, 0, 0, 0, time.UTC)
+
+				authServiceMock.EXPECT().GetUser(gomock.Any(), "login").Return(&user, nil)
+				jwtServiceMock.EXPECT().ValidateToken(gomock.Any(), "token").Return(jwtToken, nil)
+				orderServiceMock.EXPECT().GetOrders(gomock.Any(), "user-id", models.ListFilter{
+					Limit:    1,
+					Statuses: []models.OrderStatus{models.StatusNew, models.StatusProcessed},
+					From:     &from,
+					Sort:     models.SortDesc,
+				}).Return([]models.Order{
+					{
+						ID:         "order-id",
+						Status:     models.StatusNew,
+						UploadedAt: utils.RFC3339Date{Time: uploadedAt},
+					},
+				}, &models.Cursor{Time: uploadedAt, ID: "order-id"}, nil)
+			},
+			expectedCode:    http.StatusOK,
+			expectedMessage: "[{\"number\":\"order-id\",\"status\":\"NEW\",\"uploaded_at\":\"2009-11-17T00:00:00Z\"}]",
+			expectedLink: "</api/user/orders?cursor=" +
+				models.Cursor{Time: time.Date(2009, 11, 17, 0, 0, 0, 0, time.UTC), ID: "order-id"}.Encode() +
+				"&from=2009-11-01T00%3A00%3A00Z&limit=1&sort=desc&status=new%2Cprocessed>; rel=\"next\"",
+		},
+		{
+			testName:   "Should reject unknown status filter",
+			methodName: "GET",
+			targetURL:  "/api/user/orders?status=lost",
+			test: func(t *testing.T) {
+				jwtToken := jwt.NewWithClaims(
+					jwt.SigningMethodHS256,
+					jwt.MapClaims{
+						"sub": "login",
+					})
+
+				user := models.User{ID: "user-id", Login: "user", Hash: "hash"}
+
+				authServiceMock.EXPECT().GetUser(gomock.Any(), "login").Return(&user, nil)
+				jwtServiceMock.EXPECT().ValidateToken(gomock.Any(), "token").Return(jwtToken, nil)
+			},
+			expectedCode:    http.StatusBadRequest,
+			expectedMessage: "Query parameters are invalid: status \"lost\" is unknown\n",
+		},
+		{
+			testName:   "Should reject invalid cursor",
+			methodName: "GET",
+			targetURL:  "/api/user/orders?cursor=broken",
+			test: func(t *testing.T) {
+				jwtToken := jwt.NewWithClaims(
+					jwt.SigningMethodHS256,
+					jwt.MapClaims{
+						"sub": "login",
+					})
+
+				user := models.User{ID: "user-id", Login: "user", Hash: "hash"}
+
+				authServiceMock.EXPECT().GetUser(gomock.Any(), "login").Return(&user, nil)
+				jwtServiceMock.EXPECT().ValidateToken(gomock.Any(), "token").Return(jwtToken, nil)
+			},
+			expectedCode:    http.StatusBadRequest,
+			expectedMessage: "Query parameters are invalid: cursor is invalid\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			if tc.test != nil {
+				tc.test(t)
+			}
+
+			res, mes := utils.TestRequest(
+				t,
+				testServer,
+				tc.methodName,
+				tc.targetURL,
+				map[string]string{"Authorization": "Bearer token"},
+				nil,
+			)
+			res.Body.Close()
+
+			assert.Equal(t, tc.expectedCode, res.StatusCode)
+			assert.Equal(t, tc.expectedMessage, mes)
+			assert.Equal(t, tc.expectedLink, res.Header.Get("Link"))
+		})
+	}
+}
+
+func TestGetOrderHistoryRoute(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	authServiceMock := mock_models.NewMockAuthService(ctrl)
+	jwtServiceMock := mock_models.NewMockJWTService(ctrl)
+	orderServiceMock := mock_models.NewMockOrderService(ctrl)
+
+	testServer := httptest.NewServer(
+		New(Config{}, authServiceMock, jwtServiceMock, orderServiceMock, nil, nil, nil, nil, nil, nil, nil).get(),
+	)
+	defer testServer.Close()
+
+	testCases := []struct {
+		testName        string
+		targetURL       string
+		test            func(t *testing.T)
+		expectedCode    int
+		expectedMessage string
+	}{
+		{
+			testName:  "Should return order history",
+			targetURL: "/api/user/orders/12345678903/history",
+			test: func(t *testing.T) {
+				jwtToken := jwt.NewWithClaims(
+					jwt.SigningMethodHS256,
+					jwt.MapClaims{
+						"sub": "login",
+					})
+
+				user := models.User{ID: "user-id", Login: "user", Hash: "hash"}
+				from := models.StatusNew
+
+				authServiceMock.EXPECT().GetUser(gomock.Any(), "login").Return(&user, nil)
+				jwtServiceMock.EXPECT().ValidateToken(gomock.Any(), "token").Return(jwtToken, nil)
+				orderServiceMock.EXPECT().GetOrderHistory(gomock.Any(), "12345678903", "user-id").Return([]models.OrderStatusChange{
+					{
+						To:        models.StatusNew,
+						Source:    models.StatusSourceUpload,
+						ChangedAt: utils.RFC3339Date{Time: time.Date(2009, 11, 17, 0, 0, 0, 0, time.UTC)},
+					},
+					{
+						From:      &from,
+						To:        models.StatusProcessing,
+						Source:    models.StatusSourcePoll,
+						ChangedAt: utils.RFC3339Date{Time: time.Date(2009, 11, 17, 0, 1, 0, 0, time.UTC)},
+					},
+				}, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedMessage: "[{\"to\":\"NEW\",\"source\":\"upload\",\"changed_at\":\"2009-11-17T00:00:00Z\"}," +
+				"{\"from\":\"NEW\",\"to\":\"PROCESSING\",\"source\":\"poll\",\"changed_at\":\"2009-11-17T00:01:00Z\"}]",
+		},
+		{
+			testName:  "Should return 404 when order is not found",
+			targetURL: "/api/user/orders/12345678903/history",
+			test: func(t *testing.T) {
+				jwtToken := jwt.NewWithClaims(
+					jwt.SigningMethodHS256,
+					jwt.MapClaims{
+						"sub": "login",
+					})
+
+				user := models.User{ID: "user-id", Login: "user", Hash: "hash"}
+
+				authServiceMock.EXPECT().GetUser(gomock.Any(), "login").Return(&user, nil)
+				jwtServiceMock.EXPECT().ValidateToken(gomock.Any(), "token").Return(jwtToken, nil)
+				orderServiceMock.EXPECT().GetOrderHistory(gomock.Any(), "12345678903", "user-id").Return([]models.OrderStatusChange{}, services.ErrOrderIsNotExist)
+			},
+			expectedCode:    http.StatusNotFound,
+			expectedMessage: "Order is not found\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			if tc.test != nil {
+				tc.test(t)
+			}
+
+			res, mes := utils.TestRequest(
+				t,
+				testServer,
+				"GET",
+				tc.targetURL,
+				map[string]string{"Authorization": "Bearer token"},
+				nil,
+			)
+			res.Body.Close()
+
+			assert.Equal(t, tc.expectedCode, res.StatusCode)
+			assert.Equal(t, tc.expectedMessage, mes)
+		})
+	}
+}
+
+func TestGerBalanceRoute(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	authServiceMock := mock_models.NewMockAuthService(ctrl)
+	jwtServiceMock := mock_models.NewMockJWTService(ctrl)
+	balanceServiceMock := mock_models.NewMockBalanceService(ctrl)
+
+	testServer := httptest.NewServer(
+		New(Config{}, authServiceMock, jwtServiceMock, nil, nil, balanceServiceMock, nil, nil, nil, nil, nil).get(),
+	)
+	defer testServer.Close()
+
+	testCases := []struct {
+		testName        string
+		methodName      string
+		targetURL       string
+		test            func(t *testing.T)
+		expectedCode    int
+		expectedMessage string
+	}{
+		{
+			testName:   "Should return balance",
+			methodName: "GET",
+			targetURL:  "/api/user/balance",
+			test: func(t *testing.T) {
+				jwtToken := jwt.NewWithClaims(
+					jwt.SigningMethodHS256,
+					jwt.MapClaims{
+						"sub": "login",
+					})
+
+				user := models.User{ID: "user-id", Login: "user", Hash: "hash"}
+
+				authServiceMock.EXPECT().GetUser(gomock.Any(), "login").Return(&user, nil)
+				jwtServiceMock.EXPECT().ValidateToken(gomock.Any(), "token").Return(jwtToken, nil)
+				balanceServiceMock.EXPECT().GetUserBalance(gomock.Any(), "user-id").Return(models.Balance{Current: utils.Money(10020), Withdrawn: utils.Money(10030)}, nil)
+			},
+			expectedCode:    http.StatusOK,
+			expectedMessage: "{\"current\":100.2,\"withdrawn\":100.3}",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			if tc.test != nil {
+				tc.test(t)
+			}
+
+			res, mes := utils.TestRequest(
+				t,
+				testServer,
+				tc.methodName,
+				tc.targetURL,
+				map[string]string{"Authorization": "Bearer token"},
+				nil,
+			)
+			res.Body.Close()
+
+			assert.Equal(t, tc.expectedCode, res.StatusCode)
+			assert.Equal(t, tc.expectedMessage, mes)
+		})
+	}
+}
+
+func TestCreateWithdrawalRoute(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	authServiceMock := mock_models.NewMockAuthService(ctrl)
+	jwtServiceMock := mock_models.NewMockJWTService(ctrl)
+	orderServiceMock := mock_models.NewMockOrderService(ctrl)
+	balanceServiceMock := mock_models.NewMockBalanceService(ctrl)
+
+	testServer := httptest.NewServer(
+		New(Config{}, authServiceMock, jwtServiceMock, orderServiceMock, nil, balanceServiceMock, nil, nil, nil, nil, nil).get(),
+	)
+	defer testServer.Close()
+
+	testCases := []struct {
+		testName        string
+		methodName      string
+		targetURL       string
+		test            func(t *testing.T)
+		body            func() io.Reader
+		expectedCode    int
+		expectedMessage string
+	}{
+		{
+			testName:   "Should create withdraw",
+			methodName: "POST",
+			targetURL:  "/api/user/balance/withdraw",
+			test: func(t *testing.T) {
+				jwtToken := jwt.NewWithClaims(
+					jwt.SigningMethodHS256,
+					jwt.MapClaims{
+						"sub": "login",
+					})
+
+				user := models.User{ID: "user-id", Login: "user", Hash: "hash"}
+				orderID := "withdraw-id"
+				sum := utils.Money(5020)
+
+				authServiceMock.EXPECT().GetUser(gomock.Any(), "login").Return(&user, nil)
+				jwtServiceMock.EXPECT().ValidateToken(gomock.Any(), "token").Return(jwtToken, nil)
+				orderServiceMock.EXPECT().VerifyOrderID(orderID).Return(true)
+				balanceServiceMock.EXPECT().CreateWithdrawal(gomock.Any(), orderID, "user-id", sum).Return(nil)
+			},
+			body: func() io.Reader {
+				ID := "withdraw-id"
+				Sum := utils.Money(5020)
+
+				data, _ := json.Marshal(models.Withdrawal{ID: &ID, Sum: &Sum})
+				return bytes.NewBuffer(data)
+			},
+			expectedCode:    http.StatusOK,
+			expectedMessage: "",
+		},
+		{
+			testName:   "Should return 402 when there is not enough money",
+			methodName: "POST",
+			targetURL:  "/api/user/balance/withdraw",
+			test: func(t *testing.T) {
+				jwtToken := jwt.NewWithClaims(
+					jwt.SigningMethodHS256,
+					jwt.MapClaims{
+						"sub": "login",
+					})
+
+				user := models.User{ID: "user-id", Login: "user", Hash: "hash"}
+				orderID := "withdraw-id"
+				sum := utils.Money(50000)
+
+				authServiceMock.EXPECT().GetUser(gomock.Any(), "login").Return(&user, nil)
+				jwtServiceMock.EXPECT().ValidateToken(gomock.Any(), "token").Return(jwtToken, nil)
+				orderServiceMock.EXPECT().VerifyOrderID(orderID).Return(true)
+				balanceServiceMock.EXPECT().CreateWithdrawal(gomock.Any(), orderID, "user-id", sum).Return(services.ErrInsufficientFunds)
+			},
+			body: func() io.Reader {
+				ID := "withdraw-id"
+				Sum := utils.Money(50000)
+
+				data, _ := json.Marshal(models.Withdrawal{ID: &ID, Sum: &Sum})
+				return bytes.NewBuffer(data)
+			},
+			expectedCode:    http.StatusPaymentRequired,
+			expectedMessage: "There is not enough money\n",
+		},
+		{
+			testName:   "Should return 200 when withdrawal was already created by the same user",
+			methodName: "POST",
+			targetURL:  "/api/user/balance/withdraw",
+			test: func(t *testing.T) {
+				jwtToken := jwt.NewWithClaims(
+					jwt.SigningMethodHS256,
+					jwt.MapClaims{
+						"sub": "login",
+					})
+
+				user := models.User{ID: "user-id", Login: "user", Hash: "hash"}
+				orderID := "withdraw-id"
+				sum := utils.Money(5020)
+
+				authServiceMock.EXPECT().GetUser(gomock.Any(), "login").Return(&user, nil)
+				jwtServiceMock.EXPECT().ValidateToken(gomock.Any(), "token").Return(jwtToken, nil)
+				orderServiceMock.EXPECT().VerifyOrderID(orderID).Return(true)
+				balanceServiceMock.EXPECT().CreateWithdrawal(gomock.Any(), orderID, "user-id", sum).Return(services.ErrDuplicateWithdrawalByOriginalUser)
+			},
+			body: func() io.Reader {
+				ID := "withdraw-id"
+				Sum := utils.Money(5020)
+
+				data, _ := json.Marshal(models.Withdrawal{ID: &ID, Sum: &Sum})
+				return bytes.NewBuffer(data)
+			},
+			expectedCode:    http.StatusOK,
+			expectedMessage: "",
+		},
+		{
+			testName:   "Should return 409 when withdrawal was created by another user",
+			methodName: "POST",
+			targetURL:  "/api/user/balance/withdraw",
+			test: func(t *testing.T) {
+				jwtToken := jwt.NewWithClaims(
+					jwt.SigningMethodHS256,
+					jwt.MapClaims{
+						"sub": "login",
+					})
+
+				user := models.User{ID: "user-id", Login: "user", Hash: "hash"}
+				orderID := "withdraw-id"
+				sum := utils.Money(5020)
+
+				authServiceMock.EXPECT().GetUser(gomock.Any(), "login").Return(&user, nil)
+				jwtServiceMock.EXPECT().ValidateToken(gomock.Any(), "token").Return(jwtToken, nil)
+				orderServiceMock.EXPECT().VerifyOrderID(orderID).Return(true)
+				balanceServiceMock.EXPECT().CreateWithdrawal(gomock.Any(), orderID, "user-id", sum).Return(services.ErrDuplicateWithdrawal)
+			},
+			body: func() io.Reader {
+				ID := "withdraw-id"
+				Sum := utils.Money(5020)
+
+				data, _ := json.Marshal(models.Withdrawal{ID: &ID, Sum: &Sum})
+				return bytes.NewBuffer(data)
+			},
+			expectedCode:    http.StatusConflict,
+			expectedMessage: "Withdrawal was created by another user\n",
+		},
+		{
+			testName:   "Should reject sum with more than two decimals",
+			methodName: "POST",
+			targetURL:  "/api/user/balance/withdraw",
+			test: func(t *testing.T) {
+				jwtToken := jwt.NewWithClaims(
+					jwt.SigningMethodHS256,
+					jwt.MapClaims{
+						"sub": "login",
+					})
+
+				user := models.User{ID: "user-id", Login: "user", Hash: "hash"}
+
+				authServiceMock.EXPECT().GetUser(gomock.Any(), "login").Return(&user, nil)
+				jwtServiceMock.EXPECT().ValidateToken(gomock.Any(), "token").Return(jwtToken, nil)
+			},
+			body: func() io.Reader {
+				return bytes.NewBufferString(`{"order":"withdraw-id","sum":50.123}`)
+			},
+			expectedCode:    http.StatusBadRequest,
+			expectedMessage: "Error occurred during unmarshaling data money must be a number with at most two decimal places\n",
+		},
+		{
+			testName:   "Should reject negative sum",
+			methodName: "POST",
+			targetURL:  "/api/user/balance/withdraw",
+			test: func(t *testing.T) {
+				jwtToken := jwt.NewWithClaims(
+					jwt.SigningMethodHS256,
+					jwt.MapClaims{
+						"sub": "login",
+					})
+
+				user := models.User{ID: "user-id", Login: "user", Hash: "hash"}
+
+				authServiceMock.EXPECT().GetUser(gomock.Any(), "login").Return(&user, nil)
+				jwtServiceMock.EXPECT().ValidateToken(gomock.Any(), "token").Return(jwtToken, nil)
+			},
+			body: func() io.Reader {
+				return bytes.NewBufferString(`{"order":"withdraw-id","sum":-50}`)
+			},
+			expectedCode:    http.StatusBadRequest,
+			expectedMessage: "Error occurred during unmarshaling data money must not be negative\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			if tc.test != nil {
+				tc.test(t)
+			}
+
+			res, mes := utils.TestRequest(
+				t,
+				testServer,
+				tc.methodName,
+				tc.targetURL,
+				map[string]string{"Content-Type": "application/json", "Authorization": "Bearer token"},
+				tc.body(),
+			)
+			res.Body.Close()
+
+			assert.Equal(t, tc.expectedCode, res.StatusCode)
+			assert.Equal(t, tc.expectedMessage, mes)
+		})
+	}
+}
+
+func TestGetWithdrawalsRoute(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	authServiceMock := mock_models.NewMockAuthService(ctrl)
+	jwtServiceMock := mock_models.NewMockJWTService(ctrl)
+	orderServiceMock := mock_models.NewMockOrderService(ctrl)
+	balanceServiceMock := mock_models.NewMockBalanceService(ctrl)
+
+	testServer := httptest.NewServer(
+		New(Config{}, authServiceMock, jwtServiceMock, orderServiceMock, nil, balanceServiceMock, nil, nil, nil, nil, nil).get(),
+	)
+	defer testServer.Close()
+
+	testCases := []struct {
+		testName        string
+		methodName      string
+		targetURL       string
+		test            func(t *testing.T)
+		expectedCode    int
+		expectedMessage string
+	}{
+		{
+			testName:   "Should returns withdrawals",
+			methodName: "GET",
+			targetURL:  "/api/user/withdrawals",
+			test: func(t *testing.T) {
+				jwtToken := jwt.NewWithClaims(
+					jwt.SigningMethodHS256,
+					jwt.MapClaims{
+						"sub": "login",
+					})
+
+				user := models.User{ID: "user-id", Login: "user", Hash: "hash"}
+
+				authServiceMock.EXPECT().GetUser(gomock.Any(), "login").Return(&user, nil)
+				jwtServiceMock.EXPECT().ValidateToken(gomock.Any(), "token").Return(jwtToken, nil)
+				balanceServiceMock.EXPECT().GetWithdrawalFlow(gomock.Any(), "user-id", models.ListFilter{Sort: models.SortAsc}).Return([]models.WithdrawalFlowItem{
+					{
+						OrderID:     "order-id",
+						Sum:         utils.Money(12312),
+						ProcessedAt: utils.RFC3339Date{Time: time.Date(2009, 11, 17, 0, 0, 0, 0, time.UTC)},
+					},
+				}, nil, nil)
+			},
+			expectedCode:    http.StatusOK,
+			expectedMessage: "[{\"order\":\"order-id\",\"sum\":123.12,\"processed_at\":\"2009-11-17T00:00:00Z\"}]",
+		},
+		{
+			testName:   "Should return withdrawal",
+			methodName: "GET",
+			targetURL:  "/api/user/withdrawals/order-id",
+			test: func(t *testing.T) {
+				jwtToken := jwt.NewWithClaims(
+					jwt.SigningMethodHS256,
+					jwt.MapClaims{
+						"sub": "login",
+					})
+
+				user := models.User{ID: "user-id", Login: "user", Hash: "hash"}
+
+				authServiceMock.EXPECT().GetUser(gomock.Any(), "login").Return(&user, nil)
+				jwtServiceMock.EXPECT().ValidateToken(gomock.Any(), "token").Return(jwtToken, nil)
+				balanceServiceMock.EXPECT().GetWithdrawal(gomock.Any(), "order-id", "user-id").Return(models.WithdrawalFlowItem{
+					OrderID:     "order-id",
+					Sum:         utils.Money(12312),
+					ProcessedAt: utils.RFC3339Date{Time: time.Date(2009, 11, 17, 0, 0, 0, 0, time.UTC)},
+				}, nil)
+			},
+			expectedCode:    http.StatusOK,
+			expectedMessage: "{\"order\":\"order-id\",\"sum\":123.12,\"processed_at\":\"2009-11-17T00:00:00Z\"}",
+		},
+		{
+			testName:   "Should return 404 when withdrawal is not found",
+			methodName: "GET",
+			targetURL:  "/api/user/withdrawals/unknown-id",
+			test: func(t *testing.T) {
+				jwtToken := jwt.NewWithClaims(
+					jwt.SigningMethodHS256,
+					jwt.MapClaims{
+						"sub": "login",
+					})
+
+				user := models.User{ID: "user-id", Login: "user", Hash: "hash"}
+
+				authServiceMock.EXPECT().GetUser(gomock.Any(), "login").Return(&user, nil)
+				jwtServiceMock.EXPECT().ValidateToken(gomock.Any(), "token").Return(jwtToken, nil)
+				balanceServiceMock.EXPECT().GetWithdrawal(gomock.Any(), "unknown-id", "user-id").Return(models.WithdrawalFlowItem{}, services.ErrWithdrawalIsNotExist)
+			},
+			expectedCode:    http.StatusNotFound,
+			expectedMessage: "Withdrawal is not found\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			if tc.test != nil {
+				tc.test(t)
+			}
+
+			res, mes := utils.TestRequest(
+				t,
+				testServer,
+				tc.methodName,
+				tc.targetURL,
+				map[string]string{"Authorization": "Bearer token"},
+				nil,
+			)
+			res.Body.Close()
+
+			assert.Equal(t, tc.expectedCode, res.StatusCode)
+			assert.Equal(t, tc.expectedMessage, mes)
+		})
+	}
+}
+
+func TestIdempotentWithdrawalRoute(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	authServiceMock := mock_models.NewMockAuthService(ctrl)
+	jwtServiceMock := mock_models.NewMockJWTService(ctrl)
+	orderServiceMock := mock_models.NewMockOrderService(ctrl)
+	balanceServiceMock := mock_models.NewMockBalanceService(ctrl)
+	idempotencyServiceMock := mock_models.NewMockIdempotencyService(ctrl)
+
+	testServer := httptest.NewServer(
+		New(Config{}, authServiceMock, jwtServiceMock, orderServiceMock, nil, balanceServiceMock, idempotencyServiceMock, nil, nil, nil, nil).get(),
+	)
+	defer testServer.Close()
+
+	body := `{"order":"12345678903","sum":50.2}`
+
+	authorize := func() {
+		jwtToken := jwt.NewWithClaims(
+			jwt.SigningMethodHS256,
+			jwt.MapClaims{
+				"sub": "login",
+			})
+
+		user := models.User{ID: "user-id", Login: "user", Hash: "hash"}
+
+		authServiceMock.EXPECT().GetUser(gomock.Any(), "login").Return(&user, nil)
+		jwtServiceMock.EXPECT().ValidateToken(gomock.Any(), "token").Return(jwtToken, nil)
+	}
+
+	testCases := []struct {
+		testName         string
+		test             func(t *testing.T)
+		expectedCode     int
+		expectedMessage  string
+		expectedReplayed string
+	}{
+		{
+			testName: "Should execute first request and store response",
+			test: func(t *testing.T) {
+				authorize()
+				idempotencyServiceMock.EXPECT().Begin(gomock.Any(), "user-id", "key", gomock.Any()).Return(nil, nil)
+				orderServiceMock.EXPECT().VerifyOrderID("12345678903").Return(true)
+				balanceServiceMock.EXPECT().CreateWithdrawal(gomock.Any(), "12345678903", "user-id", utils.Money(5020)).Return(nil)
+				idempotencyServiceMock.EXPECT().Complete(gomock.Any(), "user-id", "key", models.IdempotentResponse{
+					StatusCode: http.StatusOK,
+				}).Return(nil)
+			},
+			expectedCode:    http.StatusOK,
+			expectedMessage: "",
+		},
+		{
+			testName: "Should replay stored response",
+			test: func(t *testing.T) {
+				authorize()
+				idempotencyServiceMock.EXPECT().Begin(gomock.Any(), "user-id", "key", gomock.Any()).Return(&models.IdempotentResponse{
+					StatusCode:  http.StatusPaymentRequired,
+					ContentType: "text/plain; charset=utf-8",
+					Body:        []byte("There is not enough money\n"),
+				}, nil)
+			},
+			expectedCode:     http.StatusPaymentRequired,
+			expectedMessage:  "There is not enough money\n",
+			expectedReplayed: "true",
+		},
+		{
+			testName: "Should return 422 when key is reused with a different body",
+			test: func(t *testing.T) {
+				authorize()
+				idempotencyServiceMock.EXPECT().Begin(gomock.Any(), "user-id", "key", gomock.Any()).Return(nil, services.ErrIdempotencyKeyIsReused)
+			},
+			expectedCode:    http.StatusUnprocessableEntity,
+			expectedMessage: "Idempotency key is already used for a different request\n",
+		},
+		{
+			testName: "Should return 409 when request with the same key is in progress",
+			test: func(t *testing.T) {
+				authorize()
+				idempotencyServiceMock.EXPECT().Begin(gomock.Any(), "user-id", "key", gomock.Any()).Return(nil, services.ErrIdempotencyKeyIsInProgress)
+			},
+			expectedCode:    http.StatusConflict,
+			expectedMessage: "Request with the same idempotency key is in progress\n",
+		},
+		{
+			testName: "Should release key on server error",
+			test: func(t *testing.T) {
+				authorize()
+				idempotencyServiceMock.EXPECT().Begin(gomock.Any(), "user-id", "key", gomock.Any()).Return(nil, nil)
+				orderServiceMock.EXPECT().VerifyOrderID("12345678903").Return(true)
+				balanceServiceMock.EXPECT().CreateWithdrawal(gomock.Any(), "12345678903", "user-id", utils.Money(5020)).Return(errors.New("db is down"))
+				idempotencyServiceMock.EXPECT().Release(gomock.Any(), "user-id", "key").Return(nil)
+			},
+			expectedCode:    http.StatusInternalServerError,
+			expectedMessage: "Error occurred during creating withdrawal: db is down\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			if tc.test != nil {
+				tc.test(t)
+			}
+
+			res, mes := utils.TestRequest(
+				t,
+				testServer,
+				"POST",
+				"/api/user/balance/withdraw",
+				map[string]string{"Content-Type": "application/json", "Authorization": "Bearer token", "Idempotency-Key": "key"},
+				bytes.NewBufferString(body),
+			)
+			res.Body.Close()
+
+			assert.Equal(t, tc.expectedCode, res.StatusCode)
+			assert.Equal(t, tc.expectedMessage, mes)
+			assert.Equal(t, tc.expectedReplayed, res.Header.Get("Idempotent-Replayed"))
+		})
+	}
+}
+
+func TestStreamEventsRoute(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	authServiceMock := mock_models.NewMockAuthService(ctrl)
+	jwtServiceMock := mock_models.NewMockJWTService(ctrl)
+	eventServiceMock := mock_models.NewMockEventService(ctrl)
+
+	testServer := httptest.NewServer(
+		New(Config{}, authServiceMock, jwtServiceMock, nil, nil, nil, nil, eventServiceMock, nil, nil, nil).get(),
+	)
+	defer testServer.Close()
+
+	authorize := func() {
+		jwtToken := jwt.NewWithClaims(
+			jwt.SigningMethodHS256,
+			jwt.MapClaims{
+				"sub": "login",
+			})
+
+		user := models.User{ID: "user-id", Login: "user", Hash: "hash"}
+
+		authServiceMock.EXPECT().GetUser(gomock.Any(), "login").Return(&user, nil)
+		jwtServiceMock.EXPECT().ValidateToken(gomock.Any(), "token").Return(jwtToken, nil)
+		eventServiceMock.EXPECT().Subscribe("user-id").Return(make(chan struct{}), func() {})
+	}
+
+	// The mocked storage fails once the expected events are sent, which closes the stream.
+	closeStream := errors.New("stream is closed")
+
+	testCases := []struct {
+		testName            string
+		lastEventID         string
+		test                func(t *testing.T)
+		expectedCode        int
+		expectedContentType string
+		expectedMessage     string
+	}{
+		{
+			testName:    "Should resume stream from Last-Event-ID",
+			lastEventID: "5",
+			test: func(t *testing.T) {
+				authorize()
+
+				gomock.InOrder(
+					eventServiceMock.EXPECT().GetEvents(gomock.Any(), "user-id", int64(5)).Return([]models.UserEvent{
+						{ID: 6, Type: "order_status_changed", Data: json.RawMessage(`{"number":"12345678903","status":"PROCESSED"}`)},
+						{ID: 7, Type: "balance_changed", Data: json.RawMessage(`{"current":500.5,"withdrawn":0}`)},
+					}, nil),
+					eventServiceMock.EXPECT().GetEvents(gomock.Any(), "user-id", int64(7)).Return([]models.UserEvent{}, closeStream),
+				)
+			},
+			expectedCode:        http.StatusOK,
+			expectedContentType: "text/event-stream",
+			expectedMessage: "id: 6\nevent: order_status_changed\ndata: {\"number\":\"12345678903\",\"status\":\"PROCESSED\"}\n\n" +
+				"id: 7\nevent: balance_changed\ndata: {\"current\":500.5,\"withdrawn\":0}\n\n",
+		},
+		{
+			testName: "Should start stream after last event when Last-Event-ID is absent",
+			test: func(t *testing.T) {
+				authorize()
+
+				eventServiceMock.EXPECT().LastEventID(gomock.Any(), "user-id").Return(int64(9), nil)
+				eventServiceMock.EXPECT().GetEvents(gomock.Any(), "user-id", int64(9)).Return([]models.UserEvent{}, closeStream)
+			},
+			expectedCode:        http.StatusOK,
+			expectedContentType: "text/event-stream",
+			expectedMessage:     "",
+		},
+		{
+			testName:    "Should return 400 when Last-Event-ID is invalid",
+			lastEventID: "abc",
+			test: func(t *testing.T) {
+				authorize()
+			},
+			expectedCode:        http.StatusBadRequest,
+			expectedContentType: "text/plain; charset=utf-8",
+			expectedMessage:     "Last-Event-ID is invalid\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			if tc.test != nil {
+				tc.test(t)
+			}
+
+			headers := map[string]string{"Authorization": "Bearer token"}
+
+			if tc.lastEventID != "" {
+				headers["Last-Event-ID"] = tc.lastEventID
+			}
+
+			res, mes := utils.TestRequest(t, testServer, "GET", "/api/user/events", headers, nil)
+			res.Body.Close()
+
+			assert.Equal(t, tc.expectedCode, res.StatusCode)
+			assert.Equal(t, tc.expectedContentType, res.Header.Get("Content-Type"))
+			assert.Equal(t, tc.expectedMessage, mes)
+		})
+	}
+}
+
+func TestWebhookRoutes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	authServiceMock := mock_models.NewMockAuthService(ctrl)
+	jwtServiceMock := mock_models.NewMockJWTService(ctrl)
+	webhookServiceMock := mock_models.NewMockWebhookService(ctrl)
+
+	testServer := httptest.NewServer(
+		New(Config{}, authServiceMock, jwtServiceMock, nil, nil, nil, nil, nil, webhookServiceMock, nil, nil).get(),
+	)
+	defer testServer.Close()
+
+	authorize := func() {
+		jwtToken := jwt.NewWithClaims(
+			jwt.SigningMethodHS256,
+			jwt.MapClaims{
+				"sub": "login",
+			})
+
+		user := models.User{ID: "user-id", Login: "user", Hash: "hash"}
+
+		authServiceMock.EXPECT().GetUser(gomock.Any(), "login").Return(&user, nil)
+		jwtServiceMock.EXPECT().ValidateToken(gomock.Any(), "token").Return(jwtToken, nil)
+	}
+	createdAt := utils.RFC3339Date{Time: time.Date(2009, 11, 17, 0, 0, 0, 0, time.UTC)}
+	responseCode := http.StatusServiceUnavailable
+	lastError := "webhook responded with status 503"
+
+	testCases := []struct {
+		testName        string
+		method          string
+		targetURL       string
+		body            string
+		test            func(t *testing.T)
+		expectedCode    int
+		expectedMessage string
+	}{
+		{
+			testName:  "Should register webhook and return its secret",
+			method:    "POST",
+			targetURL: "/api/user/webhooks",
+			body:      `{"url":"https://partner.example/hook","events":["order_accrued"]}`,
+			test: func(t *testing.T) {
+				authorize()
+				webhookServiceMock.EXPECT().
+					CreateWebhook(gomock.Any(), "user-id", "https://partner.example/hook", []models.WebhookEvent{models.WebhookEventOrderAccrued}).
+					Return(models.Webhook{
+						ID:        "webhook-id",
+						URL:       "https://partner.example/hook",
+						Events:    []models.WebhookEvent{models.WebhookEventOrderAccrued},
+						Secret:    "secret",
+						CreatedAt: createdAt,
+					}, nil)
+			},
+			expectedCode: http.StatusCreated,
+			expectedMessage: "{\"id\":\"webhook-id\",\"url\":\"https://partner.example/hook\",\"events\":[\"order_accrued\"]," +
+				"\"secret\":\"secret\",\"created_at\":\"2009-11-17T00:00:00Z\"}",
+		},
+		{
+			testName:  "Should return 400 when url is absent",
+			method:    "POST",
+			targetURL: "/api/user/webhooks",
+			body:      `{"events":["order_accrued"]}`,
+			test: func(t *testing.T) {
+				authorize()
+			},
+			expectedCode:    http.StatusBadRequest,
+			expectedMessage: "Request doesn't contain url or events\n",
+		},
+		{
+			testName:  "Should return 422 when events are unknown",
+			method:    "POST",
+			targetURL: "/api/user/webhooks",
+			body:      `{"url":"https://partner.example/hook","events":["unknown"]}`,
+			test: func(t *testing.T) {
+				authorize()
+				webhookServiceMock.EXPECT().
+					CreateWebhook(gomock.Any(), "user-id", "https://partner.example/hook", []models.WebhookEvent{"unknown"}).
+					Return(models.Webhook{}, services.ErrWebhookEventsAreInvalid)
+			},
+			expectedCode:    http.StatusUnprocessableEntity,
+			expectedMessage: "Webhook is invalid: webhook events are invalid\n",
+		},
+		{
+			testName:  "Should return webhooks without secrets",
+			method:    "GET",
+			targetURL: "/api/user/webhooks",
+			test: func(t *testing.T) {
+				authorize()
+				webhookServiceMock.EXPECT().GetWebhooks(gomock.Any(), "user-id").Return([]models.Webhook{
+					{
+						ID:        "webhook-id",
+						URL:       "https://partner.example/hook",
+						Events:    []models.WebhookEvent{models.WebhookEventOrderAccrued, models.WebhookEventBalanceWithdrawn},
+						CreatedAt: createdAt,
+					},
+				}, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedMessage: "[{\"id\":\"webhook-id\",\"url\":\"https://partner.example/hook\"," +
+				"\"events\":[\"order_accrued\",\"balance_withdrawn\"],\"created_at\":\"2009-11-17T00:00:00Z\"}]",
+		},
+		{
+			testName:  "Should return 204 when there are no webhooks",
+			method:    "GET",
+			targetURL: "/api/user/webhooks",
+			test: func(t *testing.T) {
+				authorize()
+				webhookServiceMock.EXPECT().GetWebhooks(gomock.Any(), "user-id").Return([]models.Webhook{}, nil)
+			},
+			expectedCode:    http.StatusNoContent,
+			expectedMessage: "",
+		},
+		{
+			testName:  "Should delete webhook",
+			method:    "DELETE",
+			targetURL: "/api/user/webhooks/webhook-id",
+			test: func(t *testing.T) {
+				authorize()
+				webhookServiceMock.EXPECT().DeleteWebhook(gomock.Any(), "webhook-id", "user-id").Return(nil)
+			},
+			expectedCode:    http.StatusNoContent,
+			expectedMessage: "",
+		},
+		{
+			testName:  "Should return 404 when deleted webhook is not found",
+			method:    "DELETE",
+			targetURL: "/api/user/webhooks/webhook-id",
+			test: func(t *testing.T) {
+				authorize()
+				webhookServiceMock.EXPECT().DeleteWebhook(gomock.Any(), "webhook-id", "user-id").Return(services.ErrWebhookIsNotExist)
+			},
+			expectedCode:    http.StatusNotFound,
+			expectedMessage: "Webhook is not found\n",
+		},
+		{
+			testName:  "Should return delivery log",
+			method:    "GET",
+			targetURL: "/api/user/webhooks/webhook-id/deliveries",
+			test: func(t *testing.T) {
+				authorize()
+				webhookServiceMock.EXPECT().GetWebhookDeliveries(gomock.Any(), "webhook-id", "user-id").Return([]models.WebhookDelivery{
+					{
+						ID:           "delivery-id",
+						Event:        models.WebhookEventBalanceWithdrawn,
+						Status:       models.WebhookDeliveryPending,
+						Attempts:     2,
+						ResponseCode: &responseCode,
+						LastError:    &lastError,
+						CreatedAt:    createdAt,
+					},
+				}, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedMessage: "[{\"id\":\"delivery-id\",\"event\":\"balance_withdrawn\",\"status\":\"pending\",\"attempts\":2," +
+				"\"response_code\":503,\"last_error\":\"webhook responded with status 503\",\"created_at\":\"2009-11-17T00:00:00Z\"}]",
+		},
+		{
+			testName:  "Should return 404 when webhook of delivery log is not found",
+			method:    "GET",
+			targetURL: "/api/user/webhooks/webhook-id/deliveries",
+			test: func(t *testing.T) {
+				authorize()
+				webhookServiceMock.EXPECT().GetWebhookDeliveries(gomock.Any(), "webhook-id", "user-id").Return([]models.WebhookDelivery{}, services.ErrWebhookIsNotExist)
+			},
+			expectedCode:    http.StatusNotFound,
+			expectedMessage: "Webhook is not found\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			if tc.test != nil {
+				tc.test(t)
+			}
+
+			res, mes := utils.TestRequest(
+				t,
+				testServer,
+				tc.method,
+				tc.targetURL,
+				map[string]string{"Content-Type": "application/json", "Authorization": "Bearer token"},
+				bytes.NewBufferString(tc.body),
+			)
+			res.Body.Close()
+
+			assert.Equal(t, tc.expectedCode, res.StatusCode)
+			assert.Equal(t, tc.expectedMessage, mes)
+		})
+	}
+}
+
+func TestGetHealthRoute(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	accrualServiceMock := mock_models.NewMockAccrualService(ctrl)
+
+	testServer := httptest.NewServer(
+		New(Config{}, nil, nil, nil, accrualServiceMock, nil, nil, nil, nil, nil, nil).get(),
+	)
+	defer testServer.Close()
+
+	testCases := []struct {
+		testName        string
+		methodName      string
+		targetURL       string
+		test            func(t *testing.T)
+		expectedCode    int
+		expectedMessage string
+	}{
+		{
+			testName:   "Should return ok status when circuit breaker is closed",
+			methodName: "GET",
+			targetURL:  "/api/health",
+			test: func(t *testing.T) {
+				accrualServiceMock.EXPECT().CircuitBreakerState().Return("closed")
+			},
+			expectedCode:    http.StatusOK,
+			expectedMessage: "{\"status\":\"ok\",\"accrual\":{\"circuit_breaker\":\"closed\"}}",
+		},
+		{
+			testName:   "Should return degraded status when circuit breaker is open",
+			methodName: "GET",
+			targetURL:  "/api/health",
+			test: func(t *testing.T) {
+				accrualServiceMock.EXPECT().CircuitBreakerState().Return("open")
+			},
+			expectedCode:    http.StatusOK,
+			expectedMessage: "{\"status\":\"degraded\",\"accrual\":{\"circuit_breaker\":\"open\"}}",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			if tc.test != nil {
+				tc.test(t)
+			}
+
+			res, mes := utils.TestRequest(
+				t,
+				testServer,
+				tc.methodName,
+				tc.targetURL,
+				nil,
+				nil,
+			)
+			res.Body.Close()
+
+			assert.Equal(t, tc.expectedCode, res.StatusCode)
+			assert.Equal(t, tc.expectedMessage, mes)
+		})
+	}
+}
+
+func TestGetJWKSRoute(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	jwtServiceMock := mock_models.NewMockJWTService(ctrl)
+
+	testServer := httptest.NewServer(
+		New(Config{}, nil, jwtServiceMock, nil, nil, nil, nil, nil, nil, nil, nil).get(),
+	)
+	defer testServer.Close()
+
+	jwtServiceMock.EXPECT().GetJWKS().Return(models.JWKS{Keys: []models.JWK{
+		{KeyType: "OKP", KeyID: "2026-01", Use: "sig", Algorithm: "EdDSA", Curve: "Ed25519", X: "x"},
+	}})
+
+	res, mes := utils.TestRequest(t, testServer, "GET", "/.well-known/jwks.json", nil, nil)
+	res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "{\"keys\":[{\"kty\":\"OKP\",\"kid\":\"2026-01\",\"use\":\"sig\",\"alg\":\"EdDSA\",\"crv\":\"Ed25519\",\"x\":\"x\"}]}", mes)
+}
+
+func TestAdminRoutes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	authServiceMock := mock_models.NewMockAuthService(ctrl)
+	jwtServiceMock := mock_models.NewMockJWTService(ctrl)
+	orderServiceMock := mock_models.NewMockOrderService(ctrl)
+	balanceServiceMock := mock_models.NewMockBalanceService(ctrl)
+
+	testServer := httptest.NewServer(
+		New(Config{}, authServiceMock, jwtServiceMock, orderServiceMock, nil, balanceServiceMock, nil, nil, nil, nil, nil).get(),
+	)
+	defer testServer.Close()
+
+	authorize := func(role models.Role) {
+		jwtToken := jwt.NewWithClaims(
+			jwt.SigningMethodHS256,
+			jwt.MapClaims{
+				"sub": "operator",
+			})
+
+		user := models.User{ID: "operator-id", Login: "operator", Hash: "hash", Role: role}
+
+		authServiceMock.EXPECT().GetUser(gomock.Any(), "operator").Return(&user, nil)
+		jwtServiceMock.EXPECT().ValidateToken(gomock.Any(), "token").Return(jwtToken, nil)
+	}
+	alice := models.User{ID: "alice-id", Login: "alice", Hash: "hash", Role: models.RoleUser}
+	uploadedAt := utils.RFC3339Date{Time: time.Date(2009, 11, 17, 0, 0, 0, 0, time.UTC)}
+	accrual := utils.Money(50050)
+
+	testCases := []struct {
+		testName        string
+		targetURL       string
+		test            func(t *testing.T)
+		expectedCode    int
+		expectedMessage string
+		expectedLink    string
+	}{
+		{
+			testName:  "Should forbid access for user",
+			targetURL: "/api/admin/users",
+			test: func(t *testing.T) {
+				authorize(models.RoleUser)
+			},
+			expectedCode:    http.StatusForbidden,
+			expectedMessage: "Access is forbidden\n",
+		},
+		{
+			testName:  "Should forbid access for support user",
+			targetURL: "/api/admin/users/alice/balance",
+			test: func(t *testing.T) {
+				authorize(models.RoleSupport)
+			},
+			expectedCode:    http.StatusForbidden,
+			expectedMessage: "Access is forbidden\n",
+		},
+		{
+			testName:  "Should search users",
+			targetURL: "/api/admin/users?login=ali&limit=1",
+			test: func(t *testing.T) {
+				authorize(models.RoleAdmin)
+				authServiceMock.EXPECT().GetUsers(gomock.Any(), "ali", models.ListFilter{Limit: 1, Sort: models.SortAsc}).Return(
+					[]models.UserSummary{{ID: "alice-id", Login: "alice", Role: models.RoleUser}},
+					&models.Cursor{ID: "alice"},
+					nil,
+				)
+			},
+			expectedCode:    http.StatusOK,
+			expectedMessage: "[{\"id\":\"alice-id\",\"login\":\"alice\",\"role\":\"user\"}]",
+			expectedLink:    "</api/admin/users?cursor=" + models.Cursor{ID: "alice"}.Encode() + "&limit=1&login=ali>; rel=\"next\"",
+		},
+		{
+			testName:  "Should return orders of the user",
+			targetURL: "/api/admin/users/alice/orders",
+			test: func(t *testing.T) {
+				authorize(models.RoleAdmin)
+				authServiceMock.EXPECT().GetUser(gomock.Any(), "alice").Return(&alice, nil)
+				orderServiceMock.EXPECT().GetOrders(gomock.Any(), "alice-id", models.ListFilter{Sort: models.SortAsc}).Return([]models.Order{
+					{ID: "12345678903", Status: models.StatusProcessed, Accrual: &accrual, UploadedAt: uploadedAt},
+				}, nil, nil)
+			},
+			expectedCode:    http.StatusOK,
+			expectedMessage: "[{\"number\":\"12345678903\",\"status\":\"PROCESSED\",\"accrual\":500.5,\"uploaded_at\":\"2009-11-17T00:00:00Z\"}]",
+		},
+		{
+			testName:  "Should return balance of the user",
+			targetURL: "/api/admin/users/alice/balance",
+			test: func(t *testing.T) {
+				authorize(models.RoleAdmin)
+				authServiceMock.EXPECT().GetUser(gomock.Any(), "alice").Return(&alice, nil)
+				balanceServiceMock.EXPECT().GetUserBalance(gomock.Any(), "alice-id").Return(models.Balance{Current: utils.Money(10020), Withdrawn: utils.Money(10030)}, nil)
+			},
+			expectedCode:    http.StatusOK,
+			expectedMessage: "{\"current\":100.2,\"withdrawn\":100.3}",
+		},
+		{
+			testName:  "Should return withdrawals of the user",
+			targetURL: "/api/admin/users/alice/withdrawals",
+			test: func(t *testing.T) {
+				authorize(models.RoleAdmin)
+				authServiceMock.EXPECT().GetUser(gomock.Any(), "alice").Return(&alice, nil)
+				balanceServiceMock.EXPECT().GetWithdrawalFlow(gomock.Any(), "alice-id", models.ListFilter{Sort: models.SortAsc}).Return([]models.WithdrawalFlowItem{}, nil, nil)
+			},
+			expectedCode:    http.StatusNoContent,
+			expectedMessage: "",
+		},
+		{
+			testName:  "Should return 404 when user is not found",
+			targetURL: "/api/admin/users/bob/balance",
+			test: func(t *testing.T) {
+				authorize(models.RoleAdmin)
+				authServiceMock.EXPECT().GetUser(gomock.Any(), "bob").Return(nil, services.ErrUserIsNotExist)
+			},
+			expectedCode:    http.StatusNotFound,
+			expectedMessage: "User is not found\n",
+		},
+		{
+			testName:  "Should find order by number",
+			targetURL: "/api/admin/orders/12345678903",
+			test: func(t *testing.T) {
+				authorize(models.RoleAdmin)
+				orderServiceMock.EXPECT().GetOrderDetails(gomock.Any(), "12345678903").Return(models.OrderDetails{
+					Order:   models.Order{ID: "12345678903", Status: models.StatusProcessed, Accrual: &accrual, UploadedAt: uploadedAt},
+					UserID:  "alice-id",
+					Login:   "alice",
+					History: []models.OrderStatusChange{},
+				}, nil)
+			},
+			expectedCode:    http.StatusOK,
+			expectedMessage: "{\"number\":\"12345678903\",\"status\":\"PROCESSED\",\"accrual\":500.5,\"uploaded_at\":\"2009-11-17T00:00:00Z\",\"user_id\":\"alice-id\",\"login\":\"alice\",\"history\":[]}",
+		},
+		{
+			testName:  "Should return 404 when order is not found",
+			targetURL: "/api/admin/orders/12345678903",
+			test: func(t *testing.T) {
+				authorize(models.RoleAdmin)
+				orderServiceMock.EXPECT().GetOrderDetails(gomock.Any(), "12345678903").Return(models.OrderDetails{}, services.ErrOrderIsNotExist)
+			},
+			expectedCode:    http.StatusNotFound,
+			expectedMessage: "Order is not found\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			if tc.test != nil {
+				tc.test(t)
+			}
+
+			res, mes := utils.TestRequest(
+				t,
+				testServer,
+				"GET",
+				tc.targetURL,
+				map[string]string{"Authorization": "Bearer token"},
+				nil,
+			)
+			res.Body.Close()
+
+			assert.Equal(t, tc.expectedCode, res.StatusCode)
+			assert.Equal(t, tc.expectedMessage, mes)
+			assert.Equal(t, tc.expectedLink, res.Header.Get("Link"))
+		})
+	}
+}
+
+func TestDeadLettersRoutes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	authServiceMock := mock_models.NewMockAuthService(ctrl)
+	jwtServiceMock := mock_models.NewMockJWTService(ctrl)
+	accrualServiceMock := mock_models.NewMockAccrualService(ctrl)
+
+	testServer := httptest.NewServer(
+		New(Config{}, authServiceMock, jwtServiceMock, nil, accrualServiceMock, nil, nil, nil, nil, nil, nil).get(),
+	)
+	defer testServer.Close()
+
+	authorize := func(login string, role models.Role) {
+		jwtToken := jwt.NewWithClaims(
+			jwt.SigningMethodHS256,
+			jwt.MapClaims{
+				"sub": login,
+			})
+
+		user := models.User{ID: "user-id", Login: login, Hash: "hash", Role: role}
+
+		authServiceMock.EXPECT().GetUser(gomock.Any(), login).Return(&user, nil)
+		jwtServiceMock.EXPECT().ValidateToken(gomock.Any(), "token").Return(jwtToken, nil)
+	}
+
+	testCases := []struct {
+		testName        string
+		methodName      string
+		targetURL       string
+		test            func(t *testing.T)
+		expectedCode    int
+		expectedMessage string
+	}{
+		{
+			testName:   "Should forbid access for non admin user",
+			methodName: "GET",
+			targetURL:  "/api/admin/accrual/dead-letters",
+			test: func(t *testing.T) {
+				authorize("user", models.RoleUser)
+			},
+			expectedCode:    http.StatusForbidden,
+			expectedMessage: "Access is forbidden\n",
+		},
+		{
+			testName:   "Should forbid access for support user",
+			methodName: "GET",
+			targetURL:  "/api/admin/accrual/dead-letters",
+			test: func(t *testing.T) {
+				authorize("support", models.RoleSupport)
+			},
+			expectedCode:    http.StatusForbidden,
+			expectedMessage: "Access is forbidden\n",
+		},
+		{
+			testName:   "Should return dead letters",
+			methodName: "GET",
+			targetURL:  "/api/admin/accrual/dead-letters",
+			test: func(t *testing.T) {
+				authorize("admin", models.RoleAdmin)
+				accrualServiceMock.EXPECT().GetDeadLetters(gomock.Any()).Return([]models.DeadLetter{
+					{
+						OrderID:   "12345678903",
+						LastError: "internal server error",
+						Attempts:  10,
+						CreatedAt: utils.RFC3339Date{Time: time.Date(2009, 11, 17, 0, 0, 0, 0, time.UTC)},
+						UpdatedAt: utils.RFC3339Date{Time: time.Date(2009, 11, 18, 0, 0, 0, 0, time.UTC)},
 					},
 				}, nil)
 			},
 			expectedCode:    http.StatusOK,
-			expectedMessage: "[{\"order\":\"order-id\",\"sum\":123.123,\"processed_at\":\"2009-11-17T00:00:00Z\"}]",
+			expectedMessage: "[{\"order\":\"12345678903\",\"last_error\":\"internal server error\",\"attempts\":10,\"created_at\":\"2009-11-17T00:00:00Z\",\"updated_at\":\"2009-11-18T00:00:00Z\"}]",
+		},
+		{
+			testName:   "Should return not found when retrying unknown dead letter",
+			methodName: "POST",
+			targetURL:  "/api/admin/accrual/dead-letters/12345678903/retry",
+			test: func(t *testing.T) {
+				authorize("admin", models.RoleAdmin)
+				accrualServiceMock.EXPECT().RetryDeadLetter(gomock.Any(), "12345678903").Return(services.ErrDeadLetterIsNotExist)
+			},
+			expectedCode:    http.StatusNotFound,
+			expectedMessage: "Dead letter is not found\n",
+		},
+		{
+			testName:   "Should retry all dead letters",
+			methodName: "POST",
+			targetURL:  "/api/admin/accrual/dead-letters/retry",
+			test: func(t *testing.T) {
+				authorize("admin", models.RoleAdmin)
+				accrualServiceMock.EXPECT().RetryDeadLetters(gomock.Any()).Return(2, nil)
+			},
+			expectedCode:    http.StatusOK,
+			expectedMessage: "{\"retried\":2}",
+		},
+		{
+			testName:   "Should discard dead letter",
+			methodName: "DELETE",
+			targetURL:  "/api/admin/accrual/dead-letters/12345678903",
+			test: func(t *testing.T) {
+				authorize("admin", models.RoleAdmin)
+				accrualServiceMock.EXPECT().DiscardDeadLetter(gomock.Any(), "12345678903").Return(nil)
+			},
+			expectedCode:    http.StatusNoContent,
+			expectedMessage: "",
 		},
 	}
 
@@ -652,3 +2385,83 @@ func TestGetWithdrawalsRoute(t *testing.T) {
 		})
 	}
 }
+
+func TestAccrualCallbackRoute(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	accrualServiceMock := mock_models.NewMockAccrualService(ctrl)
+
+	testServer := httptest.NewServer(
+		New(Config{CallbackSecret: "secret", CallbackMaxSkew: time.Minute}, nil, nil, nil, accrualServiceMock, nil, nil, nil, nil, nil, nil).get(),
+	)
+	defer testServer.Close()
+
+	body := `{"order":"12345678903","status":"PROCESSED","accrual":86.415}`
+
+	testCases := []struct {
+		testName        string
+		timestamp       int64
+		signature       func(timestamp int64) string
+		test            func(t *testing.T)
+		expectedCode    int
+		expectedMessage string
+	}{
+		{
+			testName:  "Should reject request with invalid signature",
+			timestamp: time.Now().Unix(),
+			signature: func(timestamp int64) string {
+				return utils.SignPayload("another-secret", timestamp, []byte(body))
+			},
+			expectedCode:    http.StatusUnauthorized,
+			expectedMessage: "Signature is invalid\n",
+		},
+		{
+			testName:  "Should reject request with stale timestamp",
+			timestamp: time.Now().Add(-time.Hour).Unix(),
+			signature: func(timestamp int64) string {
+				return utils.SignPayload("secret", timestamp, []byte(body))
+			},
+			expectedCode:    http.StatusUnauthorized,
+			expectedMessage: "Signature timestamp is stale\n",
+		},
+		{
+			testName:  "Should apply accrual update",
+			timestamp: time.Now().Unix(),
+			signature: func(timestamp int64) string {
+				return utils.SignPayload("secret", timestamp, []byte(body))
+			},
+			test: func(t *testing.T) {
+				accrual := utils.Money(8642)
+				accrualServiceMock.EXPECT().ApplyAccrualUpdate(gomock.Any(), "12345678903", "PROCESSED", &accrual).Return(nil)
+			},
+			expectedCode:    http.StatusOK,
+			expectedMessage: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			if tc.test != nil {
+				tc.test(t)
+			}
+
+			res, mes := utils.TestRequest(
+				t,
+				testServer,
+				"POST",
+				"/api/accrual/callback",
+				map[string]string{
+					"Content-Type":        "application/json",
+					"X-Accrual-Timestamp": strconv.FormatInt(tc.timestamp, 10),
+					"X-Accrual-Signature": tc.signature(tc.timestamp),
+				},
+				bytes.NewBufferString(body),
+			)
+			res.Body.Close()
+
+			assert.Equal(t, tc.expectedCode, res.StatusCode)
+			assert.Equal(t, tc.expectedMessage, mes)
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/middlewares"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/services"
+)
+
+// respondWithTokens generates a new access token for the user and returns it together with the refresh token.
+// The access token is also set to the Authorization header, and both tokens are set to cookies
+// when session cookies are enabled.
+func respondWithTokens(w http.ResponseWriter, r *http.Request, login, refreshToken string) {
+	authService := middlewares.GetServiceFromContext[models.AuthService](w, r, middlewares.AuthServiceKey)
+	jwtService := middlewares.GetServiceFromContext[models.JWTService](w, r, middlewares.JwtServiceKey)
+
+	user, err := (*authService).GetUser(r.Context(), login)
+
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error occurred during getting user: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	token, err := (*jwtService).GenerateJWT(user.Login, user.Role)
+
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error occurred during generating jwt token: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	if err := middlewares.SetSessionCookies(w, r, token, refreshToken); err != nil {
+		http.Error(w, fmt.Sprintf("Error occurred during setting session cookies: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Authorization", fmt.Sprintf("Bearer %s", token))
+
+	middlewares.EncodeJSONResponse(w, models.Tokens{AccessToken: token, RefreshToken: refreshToken})
+}
+
+func RefreshToken(w http.ResponseWriter, r *http.Request) {
+	data := middlewares.GetParsedJSONData[models.RefreshTokenRequest](w, r)
+	currentRefreshToken := ""
+
+	if data.RefreshToken != nil {
+		currentRefreshToken = *data.RefreshToken
+	}
+
+	// Browser clients send the refresh token in the cookie, which requires the CSRF token as well.
+	if currentRefreshToken == "" {
+		currentRefreshToken = middlewares.GetSessionCookie(r, middlewares.RefreshTokenCookie)
+
+		if currentRefreshToken != "" && !middlewares.IsCSRFTokenValid(r) {
+			http.Error(w, "CSRF token is invalid", http.StatusForbidden)
+			return
+		}
+	}
+
+	if currentRefreshToken == "" {
+		http.Error(w, "Request doesn't contain refresh token", http.StatusBadRequest)
+		return
+	}
+
+	refreshTokenService := middlewares.GetServiceFromContext[models.RefreshTokenService](w, r, middlewares.RefreshTokenServiceKey)
+
+	login, refreshToken, err := (*refreshTokenService).RotateRefreshToken(r.Context(), currentRefreshToken)
+
+	if err != nil {
+		if errors.Is(err, services.ErrRefreshTokenIsInvalid) {
+			http.Error(w, "Refresh token is invalid", http.StatusUnauthorized)
+			return
+		}
+
+		if errors.Is(err, services.ErrRefreshTokenIsExpired) {
+			http.Error(w, "Refresh token is expired", http.StatusUnauthorized)
+			return
+		}
+
+		if errors.Is(err, services.ErrRefreshTokenIsReused) {
+			http.Error(w, "Refresh token is already used, the session is revoked", http.StatusUnauthorized)
+			return
+		}
+
+		http.Error(w, fmt.Sprintf("Error occurred during refreshing token: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	respondWithTokens(w, r, login, refreshToken)
+}
+
+func GetJWKS(w http.ResponseWriter, r *http.Request) {
+	jwtService := middlewares.GetServiceFromContext[models.JWTService](w, r, middlewares.JwtServiceKey)
+
+	middlewares.EncodeJSONResponse(w, (*jwtService).GetJWKS())
+}
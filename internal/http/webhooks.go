@@ -0,0 +1,101 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/middlewares"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/services"
+	"github.com/go-chi/chi/v5"
+)
+
+func CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	data := middlewares.GetParsedJSONData[models.WebhookRegistration](w, r)
+
+	if data.URL == nil || data.Events == nil {
+		http.Error(w, "Request doesn't contain url or events", http.StatusBadRequest)
+		return
+	}
+
+	webhookService := middlewares.GetServiceFromContext[models.WebhookService](w, r, middlewares.WebhookServiceKey)
+	user := middlewares.GetUserFromContext(w, r)
+
+	webhook, err := (*webhookService).CreateWebhook(r.Context(), user.ID, *data.URL, data.Events)
+
+	if err != nil {
+		if errors.Is(err, services.ErrWebhookURLIsInvalid) || errors.Is(err, services.ErrWebhookEventsAreInvalid) {
+			http.Error(w, fmt.Sprintf("Webhook is invalid: %s", err.Error()), http.StatusUnprocessableEntity)
+			return
+		}
+
+		http.Error(w, fmt.Sprintf("Error occurred during creating webhook: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	middlewares.EncodeJSONResponse(w, webhook)
+}
+
+func GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	webhookService := middlewares.GetServiceFromContext[models.WebhookService](w, r, middlewares.WebhookServiceKey)
+	user := middlewares.GetUserFromContext(w, r)
+
+	webhooks, err := (*webhookService).GetWebhooks(r.Context(), user.ID)
+
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error occurred during getting webhooks: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	if len(webhooks) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	middlewares.EncodeJSONResponse(w, webhooks)
+}
+
+func DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	webhookService := middlewares.GetServiceFromContext[models.WebhookService](w, r, middlewares.WebhookServiceKey)
+	user := middlewares.GetUserFromContext(w, r)
+
+	if err := (*webhookService).DeleteWebhook(r.Context(), chi.URLParam(r, "id"), user.ID); err != nil {
+		if errors.Is(err, services.ErrWebhookIsNotExist) {
+			http.Error(w, "Webhook is not found", http.StatusNotFound)
+			return
+		}
+
+		http.Error(w, fmt.Sprintf("Error occurred during deleting webhook: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func GetWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	webhookService := middlewares.GetServiceFromContext[models.WebhookService](w, r, middlewares.WebhookServiceKey)
+	user := middlewares.GetUserFromContext(w, r)
+
+	deliveries, err := (*webhookService).GetWebhookDeliveries(r.Context(), chi.URLParam(r, "id"), user.ID)
+
+	if err != nil {
+		if errors.Is(err, services.ErrWebhookIsNotExist) {
+			http.Error(w, "Webhook is not found", http.StatusNotFound)
+			return
+		}
+
+		http.Error(w, fmt.Sprintf("Error occurred during getting webhook deliveries: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	if len(deliveries) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	middlewares.EncodeJSONResponse(w, deliveries)
+}
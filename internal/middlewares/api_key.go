@@ -0,0 +1,42 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+// GetAPIKeyFromContext returns the API key that authenticated the request or nil for other requests.
+func GetAPIKeyFromContext(r *http.Request) *models.APIKeyPrincipal {
+	principal, _ := r.Context().Value(apiKeyField).(*models.APIKeyPrincipal)
+	return principal
+}
+
+// RequireScope lets requests authenticated by an API key through only when the key has the scope.
+// Requests authenticated by a user session aren't restricted.
+func RequireScope(scope models.APIKeyScope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if principal := GetAPIKeyFromContext(r); principal != nil && !principal.HasScope(scope) {
+				http.Error(w, "API key doesn't have the "+string(scope)+" scope", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// DenyAPIKeys restricts the routes to user sessions, e.g. account management.
+func DenyAPIKeys(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if GetAPIKeyFromContext(r) != nil {
+			http.Error(w, "Access is forbidden for API keys", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,148 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/logger"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/services"
+	"go.uber.org/zap"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (ir *idempotencyRecorder) WriteHeader(statusCode int) {
+	if ir.statusCode == 0 {
+		ir.statusCode = statusCode
+	}
+
+	ir.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (ir *idempotencyRecorder) Write(data []byte) (int, error) {
+	if ir.statusCode == 0 {
+		ir.statusCode = http.StatusOK
+	}
+
+	ir.body.Write(data)
+
+	return ir.ResponseWriter.Write(data)
+}
+
+// IdempotencyMiddleware executes a request with an Idempotency-Key header only once per user
+// and replays the stored response for its repeats. Server errors aren't stored,
+// so such requests can be retried with the same key.
+func IdempotencyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			http.Error(w, "Idempotency key is too long", http.StatusBadRequest)
+			return
+		}
+
+		user := GetUserFromContext(w, r)
+
+		if user == nil {
+			return
+		}
+
+		idempotencyService := GetServiceFromContext[models.IdempotencyService](w, r, IdempotencyServiceKey)
+
+		if idempotencyService == nil {
+			return
+		}
+
+		var buf bytes.Buffer
+
+		if _, err := buf.ReadFrom(r.Body); err != nil {
+			http.Error(w, fmt.Sprintf("Error occurred during reading from the body: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+
+		hash := sha256.Sum256([]byte(r.Method + " " + r.URL.Path + "\n" + buf.String()))
+		requestHash := hex.EncodeToString(hash[:])
+
+		response, err := (*idempotencyService).Begin(r.Context(), user.ID, key, requestHash)
+
+		if err != nil {
+			if errors.Is(err, services.ErrIdempotencyKeyIsReused) {
+				http.Error(w, "Idempotency key is already used for a different request", http.StatusUnprocessableEntity)
+				return
+			}
+
+			if errors.Is(err, services.ErrIdempotencyKeyIsInProgress) {
+				http.Error(w, "Request with the same idempotency key is in progress", http.StatusConflict)
+				return
+			}
+
+			http.Error(w, fmt.Sprintf("Error occurred during checking idempotency key: %s", err.Error()), http.StatusInternalServerError)
+			return
+		}
+
+		if response != nil {
+			if response.ContentType != "" {
+				w.Header().Set("Content-Type", response.ContentType)
+			}
+
+			w.Header().Set(IdempotentReplayedHeader, "true")
+			w.WriteHeader(response.StatusCode)
+
+			if _, err := w.Write(response.Body); err != nil {
+				logger.Log.Error("failed to replay idempotent response", zap.Error(err))
+			}
+
+			return
+		}
+
+		r.Body = io.NopCloser(&buf)
+		recorder := &idempotencyRecorder{ResponseWriter: w}
+
+		next.ServeHTTP(recorder, r)
+
+		if recorder.statusCode == 0 {
+			recorder.statusCode = http.StatusOK
+		}
+
+		// The response is already sent, so the key is saved even if the client has gone away.
+		ctx := context.Background()
+
+		if recorder.statusCode >= http.StatusInternalServerError {
+			if err := (*idempotencyService).Release(ctx, user.ID, key); err != nil {
+				logger.Log.Error("failed to release idempotency key", zap.String("key", key), zap.Error(err))
+			}
+
+			return
+		}
+
+		if err := (*idempotencyService).Complete(ctx, user.ID, key, models.IdempotentResponse{
+			StatusCode:  recorder.statusCode,
+			ContentType: recorder.Header().Get("Content-Type"),
+			Body:        recorder.body.Bytes(),
+		}); err != nil {
+			logger.Log.Error("failed to save idempotent response", zap.String("key", key), zap.Error(err))
+		}
+	})
+}
@@ -9,14 +9,20 @@ import (
 
 	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
 	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/services"
+	"github.com/golang-jwt/jwt/v5"
 )
 
 type userFieldType string
 
-const userField userFieldType = "userField"
+const (
+	userField   userFieldType = "userField"
+	tokenField  userFieldType = "tokenField"
+	apiKeyField userFieldType = "apiKeyField"
+)
 
 type AuthMiddlewareConfig struct {
-	excludePaths []string
+	excludePaths   []string
+	sessionCookies bool
 }
 
 func AuthMiddleware() *AuthMiddlewareConfig {
@@ -28,8 +34,19 @@ func (a *AuthMiddlewareConfig) WithExcludedPaths(paths ...string) *AuthMiddlewar
 	return a
 }
 
+// WithSessionCookies accepts the access token from the session cookie when the Authorization header
+// is absent. Mutating requests authenticated by the cookie require the CSRF token.
+func (a *AuthMiddlewareConfig) WithSessionCookies(enabled bool) *AuthMiddlewareConfig {
+	a.sessionCookies = enabled
+	return a
+}
+
 func (a *AuthMiddlewareConfig) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.sessionCookies {
+			r = r.WithContext(context.WithValue(r.Context(), sessionCookiesField, true))
+		}
+
 		for _, path := range a.excludePaths {
 			if strings.HasPrefix(r.URL.Path, path) {
 				next.ServeHTTP(w, r)
@@ -37,61 +54,124 @@ func (a *AuthMiddlewareConfig) Middleware(next http.Handler) http.Handler {
 			}
 		}
 
-		authService := GetServiceFromContext[models.AuthService](w, r, AuthServiceKey)
-		jwtService := GetServiceFromContext[models.JWTService](w, r, JwtServiceKey)
-
-		authHeader := r.Header.Get("Authorization")
+		var (
+			login string
+			ctx   context.Context
+			ok    bool
+		)
 
-		if authHeader == "" {
-			http.Error(w, "Authorization header is required", http.StatusUnauthorized)
-			return
+		if apiKey := r.Header.Get(APIKeyHeader); apiKey != "" {
+			login, ctx, ok = authenticateAPIKey(w, r, apiKey)
+		} else {
+			login, ctx, ok = authenticateToken(w, r)
 		}
 
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-
-		if tokenString == "" {
-			http.Error(w, "Bearer token is empty", http.StatusUnauthorized)
+		if !ok {
 			return
 		}
 
-		token, err := (*jwtService).ValidateToken(tokenString)
+		authService := GetServiceFromContext[models.AuthService](w, r, AuthServiceKey)
 
-		if err != nil {
-			if errors.Is(err, services.ErrTokenIsInvalid) {
-				http.Error(w, "Invalid token", http.StatusUnauthorized)
-				return
-			}
+		user, err := (*authService).GetUser(ctx, login)
 
-			if errors.Is(err, services.ErrTokenIsExpired) {
-				http.Error(w, "Token is expired", http.StatusUnauthorized)
+		if err != nil {
+			if errors.Is(err, services.ErrUserIsNotExist) {
+				http.Error(w, fmt.Sprintf("UnknownUser login %s doesn't exist", login), http.StatusConflict)
 				return
 			}
 
-			http.Error(w, fmt.Sprintf("Error occurred during validating token: %s", err.Error()), http.StatusUnauthorized)
+			http.Error(w, fmt.Sprintf("Error occurred during validation user login: %s", err.Error()), http.StatusInternalServerError)
 			return
 		}
 
-		login, err := token.Claims.GetSubject()
+		next.ServeHTTP(w, r.WithContext(context.WithValue(ctx, userField, user)))
+	})
+}
+
+// authenticateToken validates the bearer token or, for browser clients, the session cookie
+// and returns the login of its subject.
+func authenticateToken(w http.ResponseWriter, r *http.Request) (string, context.Context, bool) {
+	jwtService := GetServiceFromContext[models.JWTService](w, r, JwtServiceKey)
 
-		if err != nil {
-			http.Error(w, fmt.Sprintf("Error occurred during reading sub field: %s", err.Error()), http.StatusUnauthorized)
-			return
+	authHeader := r.Header.Get("Authorization")
+	cookieToken := GetSessionCookie(r, AccessTokenCookie)
+
+	if authHeader == "" && cookieToken == "" {
+		http.Error(w, "Authorization header is required", http.StatusUnauthorized)
+		return "", nil, false
+	}
+
+	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+
+	if authHeader == "" {
+		if !IsCSRFTokenValid(r) {
+			http.Error(w, "CSRF token is invalid", http.StatusForbidden)
+			return "", nil, false
 		}
 
-		user, err := (*authService).GetUser(r.Context(), login)
+		tokenString = cookieToken
+	}
 
-		if err != nil {
-			if errors.Is(err, services.ErrUserIsNotExist) {
-				http.Error(w, fmt.Sprintf("UnknownUser login %s doesn't exist", login), http.StatusConflict)
-				return
-			}
+	if tokenString == "" {
+		http.Error(w, "Bearer token is empty", http.StatusUnauthorized)
+		return "", nil, false
+	}
 
-			http.Error(w, fmt.Sprintf("Error occurred during validation user login: %s", err.Error()), http.StatusInternalServerError)
-			return
+	token, err := (*jwtService).ValidateToken(r.Context(), tokenString)
+
+	if err != nil {
+		if errors.Is(err, services.ErrTokenIsInvalid) {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return "", nil, false
 		}
 
-		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userField, user)))
-	})
+		if errors.Is(err, services.ErrTokenIsExpired) {
+			http.Error(w, "Token is expired", http.StatusUnauthorized)
+			return "", nil, false
+		}
+
+		if errors.Is(err, services.ErrTokenIsRevoked) {
+			http.Error(w, "Token is revoked", http.StatusUnauthorized)
+			return "", nil, false
+		}
+
+		http.Error(w, fmt.Sprintf("Error occurred during validating token: %s", err.Error()), http.StatusUnauthorized)
+		return "", nil, false
+	}
+
+	login, err := token.Claims.GetSubject()
+
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error occurred during reading sub field: %s", err.Error()), http.StatusUnauthorized)
+		return "", nil, false
+	}
+
+	return login, context.WithValue(r.Context(), tokenField, token), true
+}
+
+// authenticateAPIKey checks the API key and returns the login of its owner.
+// The key is kept in the context, so RequireScope can check its scopes.
+func authenticateAPIKey(w http.ResponseWriter, r *http.Request, apiKey string) (string, context.Context, bool) {
+	apiKeyService := GetServiceFromContext[models.APIKeyService](w, r, APIKeyServiceKey)
+
+	principal, err := (*apiKeyService).AuthenticateAPIKey(r.Context(), apiKey)
+
+	if err != nil {
+		if errors.Is(err, services.ErrAPIKeyIsInvalid) {
+			http.Error(w, "API key is invalid", http.StatusUnauthorized)
+			return "", nil, false
+		}
+
+		if errors.Is(err, services.ErrAPIKeyIsExpired) {
+			http.Error(w, "API key is expired", http.StatusUnauthorized)
+			return "", nil, false
+		}
+
+		http.Error(w, fmt.Sprintf("Error occurred during validating api key: %s", err.Error()), http.StatusInternalServerError)
+		return "", nil, false
+	}
+
+	return principal.Login, context.WithValue(r.Context(), apiKeyField, principal), true
 }
 
 func GetUserFromContext(w http.ResponseWriter, r *http.Request) *models.User {
@@ -104,3 +184,14 @@ func GetUserFromContext(w http.ResponseWriter, r *http.Request) *models.User {
 
 	return user
 }
+
+func GetTokenFromContext(w http.ResponseWriter, r *http.Request) *jwt.Token {
+	token, ok := r.Context().Value(tokenField).(*jwt.Token)
+
+	if !ok {
+		http.Error(w, "Could not retrieve token from context", http.StatusInternalServerError)
+		return nil
+	}
+
+	return token
+}
@@ -0,0 +1,28 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
+)
+
+// RequireRole lets through users whose role includes the given one. It runs after AuthMiddleware
+// and checks the role stored on the user rather than the token claim.
+func RequireRole(role models.Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user := GetUserFromContext(w, r)
+
+			if user == nil {
+				return
+			}
+
+			if !user.Role.Includes(role) {
+				http.Error(w, "Access is forbidden", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -16,6 +16,11 @@ const (
 	OrderServiceKey
 	AccrualServiceKey
 	BalanceServiceKey
+	IdempotencyServiceKey
+	EventServiceKey
+	WebhookServiceKey
+	RefreshTokenServiceKey
+	APIKeyServiceKey
 )
 
 func ServiceInjectorMiddleware(
@@ -24,6 +29,11 @@ func ServiceInjectorMiddleware(
 	orderService models.OrderService,
 	accrualService models.AccrualService,
 	balanceService models.BalanceService,
+	idempotencyService models.IdempotencyService,
+	eventService models.EventService,
+	webhookService models.WebhookService,
+	refreshTokenService models.RefreshTokenService,
+	apiKeyService models.APIKeyService,
 ) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -32,6 +42,11 @@ func ServiceInjectorMiddleware(
 			ctx = context.WithValue(ctx, OrderServiceKey, orderService)
 			ctx = context.WithValue(ctx, AccrualServiceKey, accrualService)
 			ctx = context.WithValue(ctx, BalanceServiceKey, balanceService)
+			ctx = context.WithValue(ctx, IdempotencyServiceKey, idempotencyService)
+			ctx = context.WithValue(ctx, EventServiceKey, eventService)
+			ctx = context.WithValue(ctx, WebhookServiceKey, webhookService)
+			ctx = context.WithValue(ctx, RefreshTokenServiceKey, refreshTokenService)
+			ctx = context.WithValue(ctx, APIKeyServiceKey, apiKeyService)
 
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
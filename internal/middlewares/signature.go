@@ -0,0 +1,53 @@
+package middlewares
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/utils"
+)
+
+const (
+	SignatureHeader          = "X-Accrual-Signature"
+	SignatureTimestampHeader = "X-Accrual-Timestamp"
+)
+
+// SignatureMiddleware verifies that the request body is signed with the shared secret
+// and that the signature timestamp is not older than maxAge.
+func SignatureMiddleware(secret string, maxAge time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			timestamp, err := strconv.ParseInt(r.Header.Get(SignatureTimestampHeader), 10, 64)
+
+			if err != nil {
+				http.Error(w, "Signature timestamp is invalid", http.StatusUnauthorized)
+				return
+			}
+
+			if age := time.Since(time.Unix(timestamp, 0)); age > maxAge || age < -maxAge {
+				http.Error(w, "Signature timestamp is stale", http.StatusUnauthorized)
+				return
+			}
+
+			var buf bytes.Buffer
+
+			if _, err := buf.ReadFrom(r.Body); err != nil {
+				http.Error(w, fmt.Sprintf("Error occurred during reading from the body: %s", err.Error()), http.StatusBadRequest)
+				return
+			}
+
+			if !utils.IsSignatureValid(secret, timestamp, buf.Bytes(), r.Header.Get(SignatureHeader)) {
+				http.Error(w, "Signature is invalid", http.StatusUnauthorized)
+				return
+			}
+
+			r.Body = io.NopCloser(&buf)
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/services"
+	"github.com/go-chi/chi/v5"
+)
+
+// TargetUserMiddleware replaces the user of the request with the one whose login is in the URL parameter,
+// so admin routes can reuse the read-only handlers of user routes. It runs after the role check.
+func TargetUserMiddleware(param string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authService := GetServiceFromContext[models.AuthService](w, r, AuthServiceKey)
+			login := chi.URLParam(r, param)
+
+			user, err := (*authService).GetUser(r.Context(), login)
+
+			if err != nil {
+				if errors.Is(err, services.ErrUserIsNotExist) {
+					http.Error(w, "User is not found", http.StatusNotFound)
+					return
+				}
+
+				http.Error(w, fmt.Sprintf("Error occurred during getting user: %s", err.Error()), http.StatusInternalServerError)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userField, user)))
+		})
+	}
+}
@@ -0,0 +1,9 @@
+package models
+
+import "github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/utils"
+
+type AccrualUpdate struct {
+	ID      *string             `json:"order"`
+	Status  *string             `json:"status"`
+	Accrual *utils.RoundedMoney `json:"accrual,omitempty"`
+}
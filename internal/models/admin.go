@@ -0,0 +1,16 @@
+package models
+
+// UserSummary is a user as seen by support and admins.
+type UserSummary struct {
+	ID    string `json:"id"`
+	Login string `json:"login"`
+	Role  Role   `json:"role"`
+}
+
+// OrderDetails is an order of any user together with its owner and status history.
+type OrderDetails struct {
+	Order
+	UserID  string              `json:"user_id"`
+	Login   string              `json:"login"`
+	History []OrderStatusChange `json:"history"`
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/utils"
+)
+
+type APIKeyScope string
+
+const (
+	APIKeyScopeOrdersRead      APIKeyScope = "orders:read"
+	APIKeyScopeOrdersWrite     APIKeyScope = "orders:write"
+	APIKeyScopeBalanceRead     APIKeyScope = "balance:read"
+	APIKeyScopeWithdrawalsRead APIKeyScope = "withdrawals:read"
+	APIKeyScopeWithdraw        APIKeyScope = "withdraw"
+)
+
+func (s APIKeyScope) IsValid() bool {
+	switch s {
+	case APIKeyScopeOrdersRead, APIKeyScopeOrdersWrite, APIKeyScopeBalanceRead, APIKeyScopeWithdrawalsRead, APIKeyScopeWithdraw:
+		return true
+	}
+
+	return false
+}
+
+type APIKeyRequest struct {
+	Name      *string            `json:"name"`
+	Scopes    []APIKeyScope      `json:"scopes"`
+	ExpiresAt *utils.RFC3339Date `json:"expires_at"`
+}
+
+// APIKey contains the key itself only in the response to the creation.
+type APIKey struct {
+	ID         string             `json:"id"`
+	Name       string             `json:"name"`
+	Prefix     string             `json:"prefix"`
+	Key        string             `json:"key,omitempty"`
+	Scopes     []APIKeyScope      `json:"scopes"`
+	ExpiresAt  *utils.RFC3339Date `json:"expires_at,omitempty"`
+	LastUsedAt *utils.RFC3339Date `json:"last_used_at,omitempty"`
+	CreatedAt  utils.RFC3339Date  `json:"created_at"`
+}
+
+// APIKeyPrincipal is the owner and the scopes of the API key that authenticated the request.
+type APIKeyPrincipal struct {
+	KeyID  string
+	Login  string
+	Scopes []APIKeyScope
+}
+
+func (p APIKeyPrincipal) HasScope(scope APIKeyScope) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
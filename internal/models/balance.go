@@ -3,17 +3,17 @@ package models
 import "github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/utils"
 
 type Balance struct {
-	Current   float64 `json:"current"`
-	Withdrawn float64 `json:"withdrawn"`
+	Current   utils.Money `json:"current"`
+	Withdrawn utils.Money `json:"withdrawn"`
 }
 
 type Withdrawal struct {
-	ID  *string  `json:"order"`
-	Sum *float64 `json:"sum"`
+	ID  *string      `json:"order"`
+	Sum *utils.Money `json:"sum"`
 }
 
 type WithdrawalFlowItem struct {
 	OrderID     string            `json:"order"`
-	Sum         float64           `json:"sum"`
+	Sum         utils.Money       `json:"sum"`
 	ProcessedAt utils.RFC3339Date `json:"processed_at"`
 }
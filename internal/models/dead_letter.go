@@ -0,0 +1,15 @@
+package models
+
+import "github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/utils"
+
+type DeadLetter struct {
+	OrderID   string            `json:"order"`
+	LastError string            `json:"last_error"`
+	Attempts  int               `json:"attempts"`
+	CreatedAt utils.RFC3339Date `json:"created_at"`
+	UpdatedAt utils.RFC3339Date `json:"updated_at"`
+}
+
+type DeadLettersRetryResult struct {
+	Retried int `json:"retried"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "encoding/json"
+
+type UserEvent struct {
+	ID   int64
+	Type string
+	Data json.RawMessage
+}
+
+type OrderStatusChangedEvent struct {
+	OrderID string      `json:"number"`
+	Status  OrderStatus `json:"status"`
+}
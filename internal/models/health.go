@@ -0,0 +1,17 @@
+package models
+
+type HealthStatus string
+
+const (
+	HealthStatusOK       HealthStatus = "ok"
+	HealthStatusDegraded HealthStatus = "degraded"
+)
+
+type AccrualHealth struct {
+	CircuitBreaker string `json:"circuit_breaker"`
+}
+
+type Health struct {
+	Status  HealthStatus  `json:"status"`
+	Accrual AccrualHealth `json:"accrual"`
+}
@@ -8,6 +8,8 @@ import (
 	context "context"
 	reflect "reflect"
 
+	models "github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
+	utils "github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/utils"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -34,6 +36,20 @@ func (m *MockAccrualService) EXPECT() *MockAccrualServiceMockRecorder {
 	return m.recorder
 }
 
+// ApplyAccrualUpdate mocks base method.
+func (m *MockAccrualService) ApplyAccrualUpdate(arg0 context.Context, arg1, arg2 string, arg3 *utils.Money) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplyAccrualUpdate", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ApplyAccrualUpdate indicates an expected call of ApplyAccrualUpdate.
+func (mr *MockAccrualServiceMockRecorder) ApplyAccrualUpdate(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyAccrualUpdate", reflect.TypeOf((*MockAccrualService)(nil).ApplyAccrualUpdate), arg0, arg1, arg2, arg3)
+}
+
 // CalculateAccrual mocks base method.
 func (m *MockAccrualService) CalculateAccrual(arg0 string) {
 	m.ctrl.T.Helper()
@@ -46,6 +62,78 @@ func (mr *MockAccrualServiceMockRecorder) CalculateAccrual(arg0 interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CalculateAccrual", reflect.TypeOf((*MockAccrualService)(nil).CalculateAccrual), arg0)
 }
 
+// CircuitBreakerState mocks base method.
+func (m *MockAccrualService) CircuitBreakerState() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CircuitBreakerState")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// CircuitBreakerState indicates an expected call of CircuitBreakerState.
+func (mr *MockAccrualServiceMockRecorder) CircuitBreakerState() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CircuitBreakerState", reflect.TypeOf((*MockAccrualService)(nil).CircuitBreakerState))
+}
+
+// DiscardDeadLetter mocks base method.
+func (m *MockAccrualService) DiscardDeadLetter(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DiscardDeadLetter", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DiscardDeadLetter indicates an expected call of DiscardDeadLetter.
+func (mr *MockAccrualServiceMockRecorder) DiscardDeadLetter(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiscardDeadLetter", reflect.TypeOf((*MockAccrualService)(nil).DiscardDeadLetter), arg0, arg1)
+}
+
+// GetDeadLetters mocks base method.
+func (m *MockAccrualService) GetDeadLetters(arg0 context.Context) ([]models.DeadLetter, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeadLetters", arg0)
+	ret0, _ := ret[0].([]models.DeadLetter)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeadLetters indicates an expected call of GetDeadLetters.
+func (mr *MockAccrualServiceMockRecorder) GetDeadLetters(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeadLetters", reflect.TypeOf((*MockAccrualService)(nil).GetDeadLetters), arg0)
+}
+
+// RetryDeadLetter mocks base method.
+func (m *MockAccrualService) RetryDeadLetter(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetryDeadLetter", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RetryDeadLetter indicates an expected call of RetryDeadLetter.
+func (mr *MockAccrualServiceMockRecorder) RetryDeadLetter(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetryDeadLetter", reflect.TypeOf((*MockAccrualService)(nil).RetryDeadLetter), arg0, arg1)
+}
+
+// RetryDeadLetters mocks base method.
+func (m *MockAccrualService) RetryDeadLetters(arg0 context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetryDeadLetters", arg0)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RetryDeadLetters indicates an expected call of RetryDeadLetters.
+func (mr *MockAccrualServiceMockRecorder) RetryDeadLetters(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetryDeadLetters", reflect.TypeOf((*MockAccrualService)(nil).RetryDeadLetters), arg0)
+}
+
 // StartCalculationAccruals mocks base method.
 func (m *MockAccrualService) StartCalculationAccruals(arg0 context.Context) error {
 	m.ctrl.T.Helper()
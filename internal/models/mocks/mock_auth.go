@@ -50,6 +50,22 @@ func (mr *MockAuthServiceMockRecorder) GetUser(arg0, arg1 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockAuthService)(nil).GetUser), arg0, arg1)
 }
 
+// GetUsers mocks base method.
+func (m *MockAuthService) GetUsers(arg0 context.Context, arg1 string, arg2 models.ListFilter) ([]models.UserSummary, *models.Cursor, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsers", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]models.UserSummary)
+	ret1, _ := ret[1].(*models.Cursor)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetUsers indicates an expected call of GetUsers.
+func (mr *MockAuthServiceMockRecorder) GetUsers(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockAuthService)(nil).GetUsers), arg0, arg1, arg2)
+}
+
 // Login mocks base method.
 func (m *MockAuthService) Login(arg0 context.Context, arg1 models.UnknownUser) error {
 	m.ctrl.T.Helper()
@@ -9,6 +9,7 @@ import (
 	reflect "reflect"
 
 	models "github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
+	utils "github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/utils"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -36,7 +37,7 @@ func (m *MockBalanceService) EXPECT() *MockBalanceServiceMockRecorder {
 }
 
 // CreateWithdrawal mocks base method.
-func (m *MockBalanceService) CreateWithdrawal(arg0 context.Context, arg1, arg2 string, arg3 float64) error {
+func (m *MockBalanceService) CreateWithdrawal(arg0 context.Context, arg1, arg2 string, arg3 utils.Money) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CreateWithdrawal", arg0, arg1, arg2, arg3)
 	ret0, _ := ret[0].(error)
@@ -64,17 +65,33 @@ func (mr *MockBalanceServiceMockRecorder) GetUserBalance(arg0, arg1 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserBalance", reflect.TypeOf((*MockBalanceService)(nil).GetUserBalance), arg0, arg1)
 }
 
-// GetWithdrawalFlow mocks base method.
-func (m *MockBalanceService) GetWithdrawalFlow(arg0 context.Context, arg1 string) ([]models.WithdrawalFlowItem, error) {
+// GetWithdrawal mocks base method.
+func (m *MockBalanceService) GetWithdrawal(arg0 context.Context, arg1, arg2 string) (models.WithdrawalFlowItem, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetWithdrawalFlow", arg0, arg1)
-	ret0, _ := ret[0].([]models.WithdrawalFlowItem)
+	ret := m.ctrl.Call(m, "GetWithdrawal", arg0, arg1, arg2)
+	ret0, _ := ret[0].(models.WithdrawalFlowItem)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
+// GetWithdrawal indicates an expected call of GetWithdrawal.
+func (mr *MockBalanceServiceMockRecorder) GetWithdrawal(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWithdrawal", reflect.TypeOf((*MockBalanceService)(nil).GetWithdrawal), arg0, arg1, arg2)
+}
+
+// GetWithdrawalFlow mocks base method.
+func (m *MockBalanceService) GetWithdrawalFlow(arg0 context.Context, arg1 string, arg2 models.ListFilter) ([]models.WithdrawalFlowItem, *models.Cursor, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWithdrawalFlow", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]models.WithdrawalFlowItem)
+	ret1, _ := ret[1].(*models.Cursor)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
 // GetWithdrawalFlow indicates an expected call of GetWithdrawalFlow.
-func (mr *MockBalanceServiceMockRecorder) GetWithdrawalFlow(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockBalanceServiceMockRecorder) GetWithdrawalFlow(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWithdrawalFlow", reflect.TypeOf((*MockBalanceService)(nil).GetWithdrawalFlow), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWithdrawalFlow", reflect.TypeOf((*MockBalanceService)(nil).GetWithdrawalFlow), arg0, arg1, arg2)
 }
@@ -5,8 +5,10 @@
 package mock_models
 
 import (
+	context "context"
 	reflect "reflect"
 
+	models "github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
 	jwt "github.com/golang-jwt/jwt/v5"
 	gomock "github.com/golang/mock/gomock"
 )
@@ -35,31 +37,73 @@ func (m *MockJWTService) EXPECT() *MockJWTServiceMockRecorder {
 }
 
 // GenerateJWT mocks base method.
-func (m *MockJWTService) GenerateJWT(arg0 string) (string, error) {
+func (m *MockJWTService) GenerateJWT(arg0 string, arg1 models.Role) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GenerateJWT", arg0)
+	ret := m.ctrl.Call(m, "GenerateJWT", arg0, arg1)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GenerateJWT indicates an expected call of GenerateJWT.
-func (mr *MockJWTServiceMockRecorder) GenerateJWT(arg0 interface{}) *gomock.Call {
+func (mr *MockJWTServiceMockRecorder) GenerateJWT(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateJWT", reflect.TypeOf((*MockJWTService)(nil).GenerateJWT), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateJWT", reflect.TypeOf((*MockJWTService)(nil).GenerateJWT), arg0, arg1)
+}
+
+// GetJWKS mocks base method.
+func (m *MockJWTService) GetJWKS() models.JWKS {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetJWKS")
+	ret0, _ := ret[0].(models.JWKS)
+	return ret0
+}
+
+// GetJWKS indicates an expected call of GetJWKS.
+func (mr *MockJWTServiceMockRecorder) GetJWKS() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJWKS", reflect.TypeOf((*MockJWTService)(nil).GetJWKS))
+}
+
+// RevokeAllTokens mocks base method.
+func (m *MockJWTService) RevokeAllTokens(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAllTokens", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeAllTokens indicates an expected call of RevokeAllTokens.
+func (mr *MockJWTServiceMockRecorder) RevokeAllTokens(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAllTokens", reflect.TypeOf((*MockJWTService)(nil).RevokeAllTokens), arg0, arg1)
+}
+
+// RevokeToken mocks base method.
+func (m *MockJWTService) RevokeToken(arg0 context.Context, arg1 *jwt.Token) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeToken", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeToken indicates an expected call of RevokeToken.
+func (mr *MockJWTServiceMockRecorder) RevokeToken(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeToken", reflect.TypeOf((*MockJWTService)(nil).RevokeToken), arg0, arg1)
 }
 
 // ValidateToken mocks base method.
-func (m *MockJWTService) ValidateToken(arg0 string) (*jwt.Token, error) {
+func (m *MockJWTService) ValidateToken(arg0 context.Context, arg1 string) (*jwt.Token, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ValidateToken", arg0)
+	ret := m.ctrl.Call(m, "ValidateToken", arg0, arg1)
 	ret0, _ := ret[0].(*jwt.Token)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ValidateToken indicates an expected call of ValidateToken.
-func (mr *MockJWTServiceMockRecorder) ValidateToken(arg0 interface{}) *gomock.Call {
+func (mr *MockJWTServiceMockRecorder) ValidateToken(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateToken", reflect.TypeOf((*MockJWTService)(nil).ValidateToken), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateToken", reflect.TypeOf((*MockJWTService)(nil).ValidateToken), arg0, arg1)
 }
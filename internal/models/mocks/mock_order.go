@@ -49,19 +49,50 @@ func (mr *MockOrderServiceMockRecorder) CreateOrder(arg0, arg1, arg2 interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrder", reflect.TypeOf((*MockOrderService)(nil).CreateOrder), arg0, arg1, arg2)
 }
 
-// GetOrders mocks base method.
-func (m *MockOrderService) GetOrders(arg0 context.Context, arg1 string) ([]models.Order, error) {
+// GetOrderDetails mocks base method.
+func (m *MockOrderService) GetOrderDetails(arg0 context.Context, arg1 string) (models.OrderDetails, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetOrders", arg0, arg1)
-	ret0, _ := ret[0].([]models.Order)
+	ret := m.ctrl.Call(m, "GetOrderDetails", arg0, arg1)
+	ret0, _ := ret[0].(models.OrderDetails)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrderDetails indicates an expected call of GetOrderDetails.
+func (mr *MockOrderServiceMockRecorder) GetOrderDetails(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderDetails", reflect.TypeOf((*MockOrderService)(nil).GetOrderDetails), arg0, arg1)
+}
+
+// GetOrderHistory mocks base method.
+func (m *MockOrderService) GetOrderHistory(arg0 context.Context, arg1, arg2 string) ([]models.OrderStatusChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrderHistory", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]models.OrderStatusChange)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
+// GetOrderHistory indicates an expected call of GetOrderHistory.
+func (mr *MockOrderServiceMockRecorder) GetOrderHistory(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderHistory", reflect.TypeOf((*MockOrderService)(nil).GetOrderHistory), arg0, arg1, arg2)
+}
+
+// GetOrders mocks base method.
+func (m *MockOrderService) GetOrders(arg0 context.Context, arg1 string, arg2 models.ListFilter) ([]models.Order, *models.Cursor, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrders", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]models.Order)
+	ret1, _ := ret[1].(*models.Cursor)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
 // GetOrders indicates an expected call of GetOrders.
-func (mr *MockOrderServiceMockRecorder) GetOrders(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockOrderServiceMockRecorder) GetOrders(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrders", reflect.TypeOf((*MockOrderService)(nil).GetOrders), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrders", reflect.TypeOf((*MockOrderService)(nil).GetOrders), arg0, arg1, arg2)
 }
 
 // VerifyOrderID mocks base method.
@@ -13,9 +13,46 @@ const (
 	StatusProcessed  OrderStatus = "PROCESSED"
 )
 
+// orderStatusTransitions lists the statuses an order may move to from each status.
+// PROCESSED and INVALID are final.
+var orderStatusTransitions = map[OrderStatus][]OrderStatus{
+	StatusNew:        {StatusProcessing, StatusInvalid, StatusProcessed},
+	StatusProcessing: {StatusInvalid, StatusProcessed},
+}
+
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, status := range orderStatusTransitions[s] {
+		if status == next {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s OrderStatus) IsFinal() bool {
+	return len(orderStatusTransitions[s]) == 0
+}
+
+type OrderStatusSource string
+
+const (
+	StatusSourceUpload OrderStatusSource = "upload"
+	StatusSourcePoll   OrderStatusSource = "poll"
+	StatusSourcePush   OrderStatusSource = "push"
+	StatusSourceAdmin  OrderStatusSource = "admin"
+)
+
 type Order struct {
 	ID         string            `json:"number"`
 	Status     OrderStatus       `json:"status"`
-	Accrual    *float64          `json:"accrual,omitempty"`
+	Accrual    *utils.Money      `json:"accrual,omitempty"`
 	UploadedAt utils.RFC3339Date `json:"uploaded_at"`
 }
+
+type OrderStatusChange struct {
+	From      *OrderStatus      `json:"from,omitempty"`
+	To        OrderStatus       `json:"to"`
+	Source    OrderStatusSource `json:"source"`
+	ChangedAt utils.RFC3339Date `json:"changed_at"`
+}
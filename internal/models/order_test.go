@@ -0,0 +1,31 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderStatusCanTransitionTo(t *testing.T) {
+	testCases := []struct {
+		from     OrderStatus
+		to       OrderStatus
+		expected bool
+	}{
+		{from: StatusNew, to: StatusProcessing, expected: true},
+		{from: StatusNew, to: StatusProcessed, expected: true},
+		{from: StatusNew, to: StatusInvalid, expected: true},
+		{from: StatusProcessing, to: StatusProcessed, expected: true},
+		{from: StatusProcessing, to: StatusInvalid, expected: true},
+		{from: StatusProcessing, to: StatusNew, expected: false},
+		{from: StatusProcessed, to: StatusNew, expected: false},
+		{from: StatusProcessed, to: StatusProcessing, expected: false},
+		{from: StatusInvalid, to: StatusProcessed, expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.from)+"->"+string(tc.to), func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.from.CanTransitionTo(tc.to))
+		})
+	}
+}
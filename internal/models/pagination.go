@@ -0,0 +1,60 @@
+package models
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrCursorIsInvalid = errors.New("cursor is invalid")
+
+type SortDirection string
+
+const (
+	SortAsc  SortDirection = "asc"
+	SortDesc SortDirection = "desc"
+)
+
+// Cursor points to the last item of a page. Items are ordered by time and then by ID.
+type Cursor struct {
+	Time time.Time
+	ID   string
+}
+
+func (c Cursor) Encode() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(c.Time.UnixMicro(), 10) + ":" + c.ID))
+}
+
+func DecodeCursor(value string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+
+	if err != nil {
+		return nil, ErrCursorIsInvalid
+	}
+
+	timestamp, id, ok := strings.Cut(string(data), ":")
+
+	if !ok || id == "" {
+		return nil, ErrCursorIsInvalid
+	}
+
+	micro, err := strconv.ParseInt(timestamp, 10, 64)
+
+	if err != nil {
+		return nil, ErrCursorIsInvalid
+	}
+
+	return &Cursor{Time: time.UnixMicro(micro).UTC(), ID: id}, nil
+}
+
+// ListFilter narrows down a list of user items. Zero value means all items in ascending order.
+type ListFilter struct {
+	Limit    int
+	After    *Cursor
+	Statuses []OrderStatus
+	From     *time.Time
+	To       *time.Time
+	Sort     SortDirection
+}
@@ -0,0 +1,26 @@
+package models
+
+type Role string
+
+const (
+	RoleUser    Role = "user"
+	RoleSupport Role = "support"
+	RoleAdmin   Role = "admin"
+)
+
+var roleLevels = map[Role]int{
+	RoleUser:    1,
+	RoleSupport: 2,
+	RoleAdmin:   3,
+}
+
+func (r Role) IsValid() bool {
+	_, ok := roleLevels[r]
+	return ok
+}
+
+// Includes reports whether the role grants everything the other role does,
+// e.g. admins can do whatever support can.
+func (r Role) Includes(other Role) bool {
+	return r.IsValid() && roleLevels[r] >= roleLevels[other]
+}
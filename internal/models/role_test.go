@@ -0,0 +1,30 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoleIncludes(t *testing.T) {
+	testCases := []struct {
+		role     Role
+		other    Role
+		expected bool
+	}{
+		{role: RoleAdmin, other: RoleAdmin, expected: true},
+		{role: RoleAdmin, other: RoleSupport, expected: true},
+		{role: RoleAdmin, other: RoleUser, expected: true},
+		{role: RoleSupport, other: RoleAdmin, expected: false},
+		{role: RoleSupport, other: RoleUser, expected: true},
+		{role: RoleUser, other: RoleSupport, expected: false},
+		{role: "", other: RoleUser, expected: false},
+		{role: "root", other: RoleUser, expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.role)+">="+string(tc.other), func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.role.Includes(tc.other))
+		})
+	}
+}
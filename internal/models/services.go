@@ -2,7 +2,9 @@ package models
 
 import (
 	"context"
+	"time"
 
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/utils"
 	"github.com/golang-jwt/jwt/v5"
 )
 
@@ -13,13 +15,32 @@ type AuthService interface {
 	Login(ctx context.Context, user UnknownUser) error
 
 	GetUser(ctx context.Context, login string) (*User, error)
+
+	GetUsers(ctx context.Context, search string, filter ListFilter) ([]UserSummary, *Cursor, error)
 }
 
 //go:generate mockgen -destination=mocks/mock_jwt.go . JWTService
 type JWTService interface {
-	GenerateJWT(subject string) (string, error)
+	GenerateJWT(subject string, role Role) (string, error)
+
+	ValidateToken(ctx context.Context, token string) (*jwt.Token, error)
+
+	RevokeToken(ctx context.Context, token *jwt.Token) error
+
+	RevokeAllTokens(ctx context.Context, subject string) error
+
+	GetJWKS() JWKS
+}
+
+//go:generate mockgen -destination=mocks/mock_refresh_token.go . RefreshTokenService
+type RefreshTokenService interface {
+	IssueRefreshToken(ctx context.Context, login string) (string, error)
+
+	RotateRefreshToken(ctx context.Context, refreshToken string) (string, string, error)
+
+	RevokeRefreshToken(ctx context.Context, login, refreshToken string) error
 
-	ValidateToken(token string) (*jwt.Token, error)
+	RevokeAllRefreshTokens(ctx context.Context, login string) error
 }
 
 //go:generate mockgen -destination=mocks/mock_order.go . OrderService
@@ -28,7 +49,11 @@ type OrderService interface {
 
 	CreateOrder(ctx context.Context, orderID, userID string) error
 
-	GetOrders(ctx context.Context, userID string) ([]Order, error)
+	GetOrders(ctx context.Context, userID string, filter ListFilter) ([]Order, *Cursor, error)
+
+	GetOrderHistory(ctx context.Context, orderID, userID string) ([]OrderStatusChange, error)
+
+	GetOrderDetails(ctx context.Context, orderID string) (OrderDetails, error)
 }
 
 //go:generate mockgen -destination=mocks/mock_accrual.go . AccrualService
@@ -36,13 +61,67 @@ type AccrualService interface {
 	CalculateAccrual(orderID string)
 
 	StartCalculationAccruals(ctx context.Context) error
+
+	ApplyAccrualUpdate(ctx context.Context, orderID, status string, accrual *utils.Money) error
+
+	CircuitBreakerState() string
+
+	GetDeadLetters(ctx context.Context) ([]DeadLetter, error)
+
+	RetryDeadLetter(ctx context.Context, orderID string) error
+
+	RetryDeadLetters(ctx context.Context) (int, error)
+
+	DiscardDeadLetter(ctx context.Context, orderID string) error
 }
 
 //go:generate mockgen -destination=mocks/mock_balance.go . BalanceService
 type BalanceService interface {
 	GetUserBalance(ctx context.Context, userID string) (Balance, error)
 
-	CreateWithdrawal(ctx context.Context, orderID, userID string, amount float64) error
+	CreateWithdrawal(ctx context.Context, orderID, userID string, amount utils.Money) error
+
+	GetWithdrawalFlow(ctx context.Context, userID string, filter ListFilter) ([]WithdrawalFlowItem, *Cursor, error)
+
+	GetWithdrawal(ctx context.Context, orderID, userID string) (WithdrawalFlowItem, error)
+}
+
+//go:generate mockgen -destination=mocks/mock_idempotency.go . IdempotencyService
+type IdempotencyService interface {
+	Begin(ctx context.Context, userID, key, requestHash string) (*IdempotentResponse, error)
+
+	Complete(ctx context.Context, userID, key string, response IdempotentResponse) error
+
+	Release(ctx context.Context, userID, key string) error
+}
+
+//go:generate mockgen -destination=mocks/mock_event.go . EventService
+type EventService interface {
+	Subscribe(userID string) (<-chan struct{}, func())
+
+	GetEvents(ctx context.Context, userID string, afterID int64) ([]UserEvent, error)
+
+	LastEventID(ctx context.Context, userID string) (int64, error)
+}
+
+//go:generate mockgen -destination=mocks/mock_webhook.go . WebhookService
+type WebhookService interface {
+	CreateWebhook(ctx context.Context, userID, url string, events []WebhookEvent) (Webhook, error)
+
+	GetWebhooks(ctx context.Context, userID string) ([]Webhook, error)
+
+	DeleteWebhook(ctx context.Context, webhookID, userID string) error
+
+	GetWebhookDeliveries(ctx context.Context, webhookID, userID string) ([]WebhookDelivery, error)
+}
+
+//go:generate mockgen -destination=mocks/mock_api_key.go . APIKeyService
+type APIKeyService interface {
+	CreateAPIKey(ctx context.Context, userID, name string, scopes []APIKeyScope, expiresAt *time.Time) (APIKey, error)
+
+	GetAPIKeys(ctx context.Context, userID string) ([]APIKey, error)
+
+	RevokeAPIKey(ctx context.Context, keyID, userID string) error
 
-	GetWithdrawalFlow(ctx context.Context, userID string) ([]WithdrawalFlowItem, error)
+	AuthenticateAPIKey(ctx context.Context, key string) (*APIKeyPrincipal, error)
 }
@@ -9,4 +9,14 @@ type User struct {
 	ID    string
 	Login string
 	Hash  string
+	Role  Role
+}
+
+type Tokens struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken *string `json:"refresh_token"`
 }
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"encoding/json"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/utils"
+)
+
+type WebhookEvent string
+
+const (
+	WebhookEventOrderAccrued     WebhookEvent = "order_accrued"
+	WebhookEventBalanceWithdrawn WebhookEvent = "balance_withdrawn"
+)
+
+func (e WebhookEvent) IsValid() bool {
+	return e == WebhookEventOrderAccrued || e == WebhookEventBalanceWithdrawn
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliveryDelivered WebhookDeliveryStatus = "delivered"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+type WebhookRegistration struct {
+	URL    *string        `json:"url"`
+	Events []WebhookEvent `json:"events"`
+}
+
+// Webhook contains the signing secret only in the response to the registration.
+type Webhook struct {
+	ID        string            `json:"id"`
+	URL       string            `json:"url"`
+	Events    []WebhookEvent    `json:"events"`
+	Secret    string            `json:"secret,omitempty"`
+	CreatedAt utils.RFC3339Date `json:"created_at"`
+}
+
+type WebhookDelivery struct {
+	ID           string                `json:"id"`
+	Event        WebhookEvent          `json:"event"`
+	Status       WebhookDeliveryStatus `json:"status"`
+	Attempts     int                   `json:"attempts"`
+	ResponseCode *int                  `json:"response_code,omitempty"`
+	LastError    *string               `json:"last_error,omitempty"`
+	CreatedAt    utils.RFC3339Date     `json:"created_at"`
+	DeliveredAt  *utils.RFC3339Date    `json:"delivered_at,omitempty"`
+}
+
+// WebhookPayload is the signed body that is posted to the webhook URL.
+type WebhookPayload struct {
+	ID        string            `json:"id"`
+	Event     WebhookEvent      `json:"event"`
+	CreatedAt utils.RFC3339Date `json:"created_at"`
+	Data      json.RawMessage   `json:"data"`
+}
+
+type OrderAccruedEvent struct {
+	OrderID string      `json:"order"`
+	Accrual utils.Money `json:"accrual"`
+}
+
+type BalanceWithdrawnEvent struct {
+	OrderID string      `json:"order"`
+	Sum     utils.Money `json:"sum"`
+}
@@ -1,36 +1,55 @@
 package services
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"net/http"
-	"strconv"
 	"time"
 
 	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/database"
 	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/logger"
 	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/utils"
 	"go.uber.org/zap"
 )
 
+var (
+	ErrDeadLetterIsNotExist = errors.New("dead letter is not exist")
+	ErrOrderIsNotExist      = errors.New("order is not exist")
+	ErrUnknownAccrualStatus = errors.New("accrual status is unknown")
+)
+
 type AccrualService struct {
-	storage          accrualStorage
-	jobQueueService  accrualJobQueueService
-	externalEndpoint string
+	storage         accrualStorage
+	jobQueueService accrualJobQueueService
+	client          accrualClient
+	retryPolicy     RetryPolicy
+	pollInterval    time.Duration
 }
 
 type accrualStorage interface {
-	UpdateOrderStatus(ctx context.Context, orderID string, status database.OrderStatusDB) error
+	FindOrder(ctx context.Context, orderID string) (*database.OrderDB, error)
 
-	CreateAccrual(ctx context.Context, orderID string, amount float64) error
+	UpdateOrderStatus(ctx context.Context, orderID string, status database.OrderStatusDB, accrual *utils.Money, source models.OrderStatusSource) error
 
 	FindAllUnprocessedOrders(ctx context.Context) (*[]database.OrderDB, error)
+
+	IncrementAccrualAttempts(ctx context.Context, orderID, lastError string) (int, error)
+
+	ResetAccrualAttempts(ctx context.Context, orderID string) error
+
+	MarkAccrualGaveUp(ctx context.Context, orderID, lastError string) error
+
+	FindDeadLetters(ctx context.Context) (*[]database.DeadLetterDB, error)
+
+	ReviveDeadLetter(ctx context.Context, orderID string) (bool, error)
+
+	DeleteDeadLetter(ctx context.Context, orderID string) (bool, error)
 }
 
 type accrualJobQueueService interface {
+	RegisterHandler(kind string, handler JobHandler)
+
 	Enqueue(job Job)
 
 	ScheduleJob(job Job, delay time.Duration)
@@ -38,89 +57,209 @@ type accrualJobQueueService interface {
 	PauseAndResume(delay time.Duration)
 }
 
-func NewAccrualService(storage accrualStorage, jobQueueService accrualJobQueueService, externalEndpoint string) *AccrualService {
-	return &AccrualService{
-		storage:          storage,
-		jobQueueService:  jobQueueService,
-		externalEndpoint: externalEndpoint,
+type accrualClient interface {
+	FetchAccrualData(ctx context.Context, orderID string) (*accrualDataResponse, time.Duration, error)
+
+	CircuitBreakerState() CircuitBreakerState
+
+	CircuitBreakerRemainingOpen() time.Duration
+}
+
+const calculateAccrualJobKind = "calculate_accrual"
+
+func NewAccrualService(
+	storage accrualStorage,
+	jobQueueService accrualJobQueueService,
+	client accrualClient,
+	retryPolicy RetryPolicy,
+	pollInterval time.Duration,
+) *AccrualService {
+	service := &AccrualService{
+		storage:         storage,
+		jobQueueService: jobQueueService,
+		client:          client,
+		retryPolicy:     retryPolicy,
+		pollInterval:    pollInterval,
 	}
+	jobQueueService.RegisterHandler(calculateAccrualJobKind, service.handleCalculateAccrual)
+
+	return service
 }
 
 func (as *AccrualService) CalculateAccrual(orderID string) {
-	as.jobQueueService.Enqueue(func(ctx context.Context) {
-		data, retryAfter, err := as.fetchAccrualData(orderID)
-
-		if err != nil {
-			if errors.Is(err, errNoOrder) {
-				logger.Log.Info("order isn't registered", zap.String("orderID", orderID))
-				// todo maybe need to enqueue
-				return
+	as.jobQueueService.Enqueue(Job{Kind: calculateAccrualJobKind, Payload: orderID})
+}
+
+func (as *AccrualService) handleCalculateAccrual(ctx context.Context, job Job) {
+	orderID := job.Payload
+	data, retryAfter, err := as.client.FetchAccrualData(ctx, orderID)
+
+	if err != nil {
+		if errors.Is(err, ErrCircuitIsOpen) {
+			// A half-open circuit has nothing left to wait for, but its probe is still in flight,
+			// so the job is never retried sooner than the poll interval.
+			delay := as.client.CircuitBreakerRemainingOpen()
+
+			if delay < as.pollInterval {
+				delay = as.pollInterval
 			}
 
-			logger.Log.Error("failed to fetch accrual data", zap.Error(err))
-			// todo add timeout enqueue
+			as.jobQueueService.PauseAndResume(delay)
+			as.jobQueueService.ScheduleJob(Job{Kind: calculateAccrualJobKind, Payload: orderID}, delay)
+			logger.Log.Warn("accrual system is unavailable, suspended queue",
+				zap.String("orderID", orderID),
+				zap.Duration("delay", delay),
+			)
 			return
 		}
 
-		if retryAfter > 0 {
-			logger.Log.Info("got retryAfter", zap.Int("retryAfter", retryAfter), zap.String("orderID", orderID))
-			as.jobQueueService.PauseAndResume(time.Second * time.Duration(retryAfter))
-			as.jobQueueService.Enqueue(func(ctx context.Context) {
-				as.CalculateAccrual(orderID)
-			})
-			logger.Log.Info("enqueued new job after pausing", zap.Int("retryAfter", retryAfter), zap.String("orderID", orderID))
+		if errors.Is(err, errNoOrder) {
+			logger.Log.Info("order isn't registered", zap.String("orderID", orderID))
+			as.retry(ctx, orderID, err)
 			return
 		}
 
-		logger.Log.Info("got accrual data",
-			zap.String("orderID", orderID),
-			zap.String("status", string(data.Status)),
-		)
+		logger.Log.Error("failed to fetch accrual data", zap.String("orderID", orderID), zap.Error(err))
+		as.retry(ctx, orderID, err)
+		return
+	}
 
-		if data.Status == AccrualStatusRegistered {
-			as.jobQueueService.ScheduleJob(func(ctx context.Context) {
-				as.CalculateAccrual(orderID)
-			}, time.Minute)
-			logger.Log.Info("enqueued new schedule job", zap.String("orderID", orderID))
+	if data == nil {
+		as.jobQueueService.ScheduleJob(Job{Kind: calculateAccrualJobKind, Payload: orderID}, retryAfter)
+		logger.Log.Info("got retryAfter, enqueued new schedule job", zap.Duration("retryAfter", retryAfter), zap.String("orderID", orderID))
+		return
+	}
+
+	logger.Log.Info("got accrual data",
+		zap.String("orderID", orderID),
+		zap.String("status", string(data.Status)),
+	)
 
+	if data.Status == AccrualStatusRegistered {
+		as.resetAttempts(ctx, orderID)
+		as.jobQueueService.ScheduleJob(Job{Kind: calculateAccrualJobKind, Payload: orderID}, as.pollInterval)
+		logger.Log.Info("enqueued new schedule job", zap.String("orderID", orderID))
+
+		return
+	}
+
+	if data.Status == AccrualStatusProcessed ||
+		data.Status == AccrualStatusProcessing ||
+		data.Status == AccrualStatusInvalid {
+		if err := as.applyAccrualData(ctx, orderID, data.Status, (*utils.Money)(data.Accrual), models.StatusSourcePoll); err != nil {
+			logger.Log.Error("failed to apply accrual data", zap.String("orderID", orderID), zap.Error(err))
+			as.retry(ctx, orderID, err)
 			return
 		}
 
-		if data.Status == AccrualStatusProcessed ||
-			data.Status == AccrualStatusProcessing ||
-			data.Status == AccrualStatusInvalid {
-			if err := as.storage.UpdateOrderStatus(ctx, orderID, database.OrderStatusDB{OrderStatus: models.OrderStatus(data.Status)}); err != nil {
-				logger.Log.Error("failed to update status", zap.Error(err))
-				return
-			}
+		as.resetAttempts(ctx, orderID)
 
-			logger.Log.Info("updated order status",
-				zap.String("orderID", orderID),
-				zap.String("status", string(data.Status)),
-			)
+		if data.Status == AccrualStatusProcessing {
+			as.jobQueueService.ScheduleJob(Job{Kind: calculateAccrualJobKind, Payload: orderID}, as.pollInterval)
+			logger.Log.Info("enqueued new schedule job", zap.String("orderID", orderID))
+		}
 
-			if data.Accrual == nil {
-				return
-			}
+		return
+	}
 
-			if err := as.storage.CreateAccrual(ctx, orderID, *data.Accrual); err != nil {
-				logger.Log.Error("failed to create accrual", zap.Error(err))
-				return
-			}
+	logger.Log.Error("status isn't defined", zap.String("status", string(data.Status)))
+	as.giveUp(ctx, orderID, fmt.Errorf("status %q isn't defined", data.Status))
+}
 
-			logger.Log.Info("saved accrual value",
-				zap.String("orderID", orderID),
-				zap.String("status", string(data.Status)),
-				zap.Float64("accrual", *data.Accrual),
-			)
+// ApplyAccrualUpdate stores an accrual result pushed by the accrual system.
+func (as *AccrualService) ApplyAccrualUpdate(ctx context.Context, orderID, status string, accrual *utils.Money) error {
+	switch accrualOrderStatus(status) {
+	case AccrualStatusRegistered:
+		return nil
+	case AccrualStatusProcessing, AccrualStatusProcessed, AccrualStatusInvalid:
+		return as.applyAccrualData(ctx, orderID, accrualOrderStatus(status), accrual, models.StatusSourcePush)
+	default:
+		return ErrUnknownAccrualStatus
+	}
+}
 
-			return
-		}
+func (as *AccrualService) applyAccrualData(ctx context.Context, orderID string, status accrualOrderStatus, accrual *utils.Money, source models.OrderStatusSource) error {
+	order, err := as.storage.FindOrder(ctx, orderID)
 
-		logger.Log.Error("status isn't defined", zap.String("status", string(data.Status)))
-	})
+	if err != nil {
+		return err
+	}
+
+	if order == nil {
+		return ErrOrderIsNotExist
+	}
+
+	if order.Status.IsFinal() {
+		logger.Log.Info("order is already finalized",
+			zap.String("orderID", orderID),
+			zap.String("status", string(order.Status.OrderStatus)),
+		)
+
+		return nil
+	}
+
+	if err := as.storage.UpdateOrderStatus(ctx, orderID, database.OrderStatusDB{OrderStatus: models.OrderStatus(status)}, accrual, source); err != nil {
+		return fmt.Errorf("failed to update status: %w", err)
+	}
+
+	logger.Log.Info("updated order status",
+		zap.String("orderID", orderID),
+		zap.String("status", string(status)),
+	)
+
+	if accrual != nil {
+		logger.Log.Info("saved accrual value",
+			zap.String("orderID", orderID),
+			zap.String("status", string(status)),
+			zap.Stringer("accrual", *accrual),
+		)
+	}
+
+	return nil
+}
+
+func (as *AccrualService) retry(ctx context.Context, orderID string, reason error) {
+	attempts, err := as.storage.IncrementAccrualAttempts(ctx, orderID, reason.Error())
+
+	if err != nil {
+		logger.Log.Error("failed to increment accrual attempts", zap.String("orderID", orderID), zap.Error(err))
+		return
+	}
+
+	if as.retryPolicy.IsExhausted(attempts) {
+		as.giveUp(ctx, orderID, reason)
+		return
+	}
+
+	delay := as.retryPolicy.Delay(attempts)
+	as.jobQueueService.ScheduleJob(Job{Kind: calculateAccrualJobKind, Payload: orderID}, delay)
+	logger.Log.Info("enqueued retry job",
+		zap.String("orderID", orderID),
+		zap.Int("attempts", attempts),
+		zap.Duration("delay", delay),
+	)
+}
+
+func (as *AccrualService) resetAttempts(ctx context.Context, orderID string) {
+	if err := as.storage.ResetAccrualAttempts(ctx, orderID); err != nil {
+		logger.Log.Error("failed to reset accrual attempts", zap.String("orderID", orderID), zap.Error(err))
+	}
+}
+
+func (as *AccrualService) giveUp(ctx context.Context, orderID string, reason error) {
+	if err := as.storage.MarkAccrualGaveUp(ctx, orderID, reason.Error()); err != nil {
+		logger.Log.Error("failed to mark accrual as given up", zap.String("orderID", orderID), zap.Error(err))
+		return
+	}
+
+	logger.Log.Error("gave up fetching accrual data, moved order to dead letters",
+		zap.String("orderID", orderID),
+		zap.Error(reason),
+	)
 }
 
+// StartCalculationAccruals enqueues every unprocessed order. Orders that already wait
+// for their next poll or retry keep their schedule.
 func (as *AccrualService) StartCalculationAccruals(ctx context.Context) error {
 	orders, err := as.storage.FindAllUnprocessedOrders(ctx)
 
@@ -139,72 +278,86 @@ func (as *AccrualService) StartCalculationAccruals(ctx context.Context) error {
 	return nil
 }
 
-type accrualOrderStatus string
-
-const (
-	AccrualStatusRegistered accrualOrderStatus = "REGISTERED"
-	AccrualStatusInvalid    accrualOrderStatus = "INVALID"
-	AccrualStatusProcessing accrualOrderStatus = "PROCESSING"
-	AccrualStatusProcessed  accrualOrderStatus = "PROCESSED"
-)
-
-type accrualDataResponse struct {
-	ID      string             `json:"order"`
-	Status  accrualOrderStatus `json:"status"`
-	Accrual *float64           `json:"accrual,omitempty"`
+func (as *AccrualService) CircuitBreakerState() string {
+	return string(as.client.CircuitBreakerState())
 }
 
-var (
-	errNoOrder = errors.New("order isn't registered")
-	errServer  = errors.New("internal server error")
-)
+func (as *AccrualService) GetDeadLetters(ctx context.Context) ([]models.DeadLetter, error) {
+	deadLetters, err := as.storage.FindDeadLetters(ctx)
+
+	if err != nil {
+		return []models.DeadLetter{}, err
+	}
 
-const defaultRetryAfterDuration = 60
+	if deadLetters == nil {
+		return []models.DeadLetter{}, nil
+	}
 
-func (as *AccrualService) fetchAccrualData(orderID string) (data *accrualDataResponse, retryAfter int, err error) {
-	client := &http.Client{}
-	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/api/orders/%s", as.externalEndpoint, orderID), nil)
+	result := make([]models.DeadLetter, len(*deadLetters))
 
-	if err != nil {
-		return nil, 0, fmt.Errorf("failed to create request: %w", err)
+	for i, item := range *deadLetters {
+		result[i] = models.DeadLetter{
+			OrderID:   item.OrderID,
+			LastError: item.LastError,
+			Attempts:  item.Attempts,
+			CreatedAt: utils.RFC3339Date{Time: item.CreatedAt},
+			UpdatedAt: utils.RFC3339Date{Time: item.UpdatedAt},
+		}
 	}
 
-	res, err := client.Do(req)
+	return result, nil
+}
+
+func (as *AccrualService) RetryDeadLetter(ctx context.Context, orderID string) error {
+	ok, err := as.storage.ReviveDeadLetter(ctx, orderID)
 
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to send data by using GET method: %w", err)
+		return err
 	}
 
-	defer res.Body.Close()
+	if !ok {
+		return ErrDeadLetterIsNotExist
+	}
+
+	as.CalculateAccrual(orderID)
 
-	if res.StatusCode == http.StatusNoContent {
-		return nil, 0, errNoOrder
+	return nil
+}
+
+func (as *AccrualService) RetryDeadLetters(ctx context.Context) (int, error) {
+	deadLetters, err := as.storage.FindDeadLetters(ctx)
+
+	if err != nil || deadLetters == nil {
+		return 0, err
 	}
 
-	if res.StatusCode == http.StatusTooManyRequests {
-		retryAfter, err := strconv.Atoi(res.Header.Get("Retry-After"))
+	retried := 0
 
-		if err != nil {
-			retryAfter = defaultRetryAfterDuration
+	for _, item := range *deadLetters {
+		if err := as.RetryDeadLetter(ctx, item.OrderID); err != nil {
+			if errors.Is(err, ErrDeadLetterIsNotExist) {
+				continue
+			}
+
+			return retried, err
 		}
 
-		return nil, retryAfter, nil
+		retried++
 	}
 
-	if res.StatusCode == http.StatusInternalServerError {
-		return nil, 0, errServer
-	}
+	return retried, nil
+}
 
-	var parsedData accrualDataResponse
-	var buf bytes.Buffer
+func (as *AccrualService) DiscardDeadLetter(ctx context.Context, orderID string) error {
+	ok, err := as.storage.DeleteDeadLetter(ctx, orderID)
 
-	if _, err := buf.ReadFrom(res.Body); err != nil {
-		return nil, 0, fmt.Errorf("failed to read from response body: %w", err)
+	if err != nil {
+		return err
 	}
 
-	if err := json.Unmarshal(buf.Bytes(), &parsedData); err != nil {
-		return nil, 0, fmt.Errorf("failed to unmarshal data: %w", err)
+	if !ok {
+		return ErrDeadLetterIsNotExist
 	}
 
-	return &parsedData, 0, nil
+	return nil
 }
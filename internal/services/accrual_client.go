@@ -0,0 +1,169 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/utils"
+)
+
+type accrualOrderStatus string
+
+const (
+	AccrualStatusRegistered accrualOrderStatus = "REGISTERED"
+	AccrualStatusInvalid    accrualOrderStatus = "INVALID"
+	AccrualStatusProcessing accrualOrderStatus = "PROCESSING"
+	AccrualStatusProcessed  accrualOrderStatus = "PROCESSED"
+)
+
+type accrualDataResponse struct {
+	ID      string              `json:"order"`
+	Status  accrualOrderStatus  `json:"status"`
+	Accrual *utils.RoundedMoney `json:"accrual,omitempty"`
+}
+
+var (
+	errNoOrder           = errors.New("order isn't registered")
+	errServer            = errors.New("internal server error")
+	errMalformedResponse = errors.New("malformed response")
+)
+
+const (
+	defaultRetryAfterDuration = 60 * time.Second
+	defaultAccrualTimeout     = 10 * time.Second
+)
+
+var rateLimitPattern = regexp.MustCompile(`(\d+) requests per (second|minute|hour)`)
+
+type AccrualClient struct {
+	externalEndpoint string
+	client           *http.Client
+	breaker          *CircuitBreaker
+	limiter          *RateLimiter
+}
+
+func NewAccrualClient(externalEndpoint string, breaker *CircuitBreaker, limiter *RateLimiter) *AccrualClient {
+	return &AccrualClient{
+		externalEndpoint: externalEndpoint,
+		client:           &http.Client{Timeout: defaultAccrualTimeout},
+		breaker:          breaker,
+		limiter:          limiter,
+	}
+}
+
+func (ac *AccrualClient) CircuitBreakerState() CircuitBreakerState {
+	return ac.breaker.State()
+}
+
+func (ac *AccrualClient) CircuitBreakerRemainingOpen() time.Duration {
+	return ac.breaker.RemainingOpen()
+}
+
+func (ac *AccrualClient) FetchAccrualData(ctx context.Context, orderID string) (data *accrualDataResponse, retryAfter time.Duration, err error) {
+	if err := ac.limiter.Wait(ctx); err != nil {
+		return nil, 0, err
+	}
+
+	if err := ac.breaker.Allow(); err != nil {
+		return nil, 0, err
+	}
+
+	data, retryAfter, err = ac.fetchAccrualData(ctx, orderID)
+
+	if err != nil && !errors.Is(err, errNoOrder) && !errors.Is(err, errMalformedResponse) {
+		ac.breaker.Failure()
+	} else {
+		ac.breaker.Success()
+	}
+
+	return data, retryAfter, err
+}
+
+func (ac *AccrualClient) fetchAccrualData(ctx context.Context, orderID string) (data *accrualDataResponse, retryAfter time.Duration, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/api/orders/%s", ac.externalEndpoint, orderID), nil)
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	res, err := ac.client.Do(req)
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to send data by using GET method: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNoContent {
+		return nil, 0, errNoOrder
+	}
+
+	if res.StatusCode == http.StatusTooManyRequests {
+		retryAfter := ac.learnRateLimit(res)
+		ac.limiter.BlockFor(retryAfter)
+
+		return nil, retryAfter, nil
+	}
+
+	if res.StatusCode >= http.StatusInternalServerError {
+		return nil, 0, errServer
+	}
+
+	var parsedData accrualDataResponse
+	var buf bytes.Buffer
+
+	if _, err := buf.ReadFrom(res.Body); err != nil {
+		return nil, 0, fmt.Errorf("failed to read from response body: %w", err)
+	}
+
+	if err := json.Unmarshal(buf.Bytes(), &parsedData); err != nil {
+		return nil, 0, fmt.Errorf("%w: failed to unmarshal data: %s", errMalformedResponse, err)
+	}
+
+	return &parsedData, 0, nil
+}
+
+// learnRateLimit adjusts the limiter to the limit stated in the 429 response body
+// and returns how long to wait before the next request.
+func (ac *AccrualClient) learnRateLimit(res *http.Response) time.Duration {
+	var buf bytes.Buffer
+
+	if _, err := buf.ReadFrom(res.Body); err == nil {
+		if match := rateLimitPattern.FindStringSubmatch(buf.String()); match != nil {
+			limit, err := strconv.Atoi(match[1])
+
+			if err == nil && limit > 0 {
+				period := map[string]time.Duration{
+					"second": time.Second,
+					"minute": time.Minute,
+					"hour":   time.Hour,
+				}[match[2]]
+
+				ac.limiter.SetRate(float64(limit) / period.Seconds())
+			}
+		}
+	}
+
+	header := res.Header.Get("Retry-After")
+
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(header); err == nil {
+		return time.Until(date)
+	}
+
+	if rate := ac.limiter.Rate(); rate > 0 {
+		return time.Duration(float64(time.Second) / rate)
+	}
+
+	return defaultRetryAfterDuration
+}
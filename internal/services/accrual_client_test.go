@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccrualClientLearnsRateLimit(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "2")
+		http.Error(w, "No more than 120 requests per minute allowed", http.StatusTooManyRequests)
+	}))
+	defer testServer.Close()
+
+	limiter := NewRateLimiter(0, 1)
+	client := NewAccrualClient(testServer.URL, NewCircuitBreaker(DefaultCircuitBreakerConfig()), limiter)
+
+	data, retryAfter, err := client.FetchAccrualData(context.Background(), "12345678903")
+	require.NoError(t, err)
+
+	assert.Nil(t, data)
+	assert.Equal(t, 2*time.Second, retryAfter)
+	assert.Equal(t, float64(2), limiter.Rate())
+	assert.Greater(t, limiter.reserve(), time.Second)
+}
+
+func TestAccrualClientOpensCircuit(t *testing.T) {
+	requests := 0
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer testServer.Close()
+
+	client := NewAccrualClient(
+		testServer.URL,
+		NewCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 2, OpenTimeout: time.Minute, SuccessThreshold: 1}),
+		NewRateLimiter(0, 1),
+	)
+
+	for i := 0; i < 3; i++ {
+		_, _, err := client.FetchAccrualData(context.Background(), "12345678903")
+		assert.Error(t, err)
+	}
+
+	_, _, err := client.FetchAccrualData(context.Background(), "12345678903")
+
+	assert.ErrorIs(t, err, ErrCircuitIsOpen)
+	assert.Equal(t, CircuitBreakerOpen, client.CircuitBreakerState())
+	assert.Equal(t, 2, requests)
+}
+
+func TestAccrualClientRoundsAccrual(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"order":"12345678903","status":"PROCESSED","accrual":1e2}`))
+	}))
+	defer testServer.Close()
+
+	client := NewAccrualClient(testServer.URL, NewCircuitBreaker(DefaultCircuitBreakerConfig()), NewRateLimiter(0, 1))
+
+	data, _, err := client.FetchAccrualData(context.Background(), "12345678903")
+	require.NoError(t, err)
+	require.NotNil(t, data.Accrual)
+
+	assert.Equal(t, utils.RoundedMoney(10000), *data.Accrual)
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/database"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAccrualStorage struct {
+	accrualStorage
+	order     *database.OrderDB
+	updateErr error
+	attempts  int
+	resets    int
+}
+
+func (s *fakeAccrualStorage) FindOrder(ctx context.Context, orderID string) (*database.OrderDB, error) {
+	return s.order, nil
+}
+
+func (s *fakeAccrualStorage) UpdateOrderStatus(ctx context.Context, orderID string, status database.OrderStatusDB, accrual *utils.Money, source models.OrderStatusSource) error {
+	return s.updateErr
+}
+
+func (s *fakeAccrualStorage) IncrementAccrualAttempts(ctx context.Context, orderID, lastError string) (int, error) {
+	s.attempts++
+	return s.attempts, nil
+}
+
+func (s *fakeAccrualStorage) ResetAccrualAttempts(ctx context.Context, orderID string) error {
+	s.attempts = 0
+	s.resets++
+	return nil
+}
+
+type scheduledJob struct {
+	job   Job
+	delay time.Duration
+}
+
+type fakeAccrualJobQueue struct {
+	scheduled []scheduledJob
+	paused    []time.Duration
+}
+
+func (q *fakeAccrualJobQueue) RegisterHandler(kind string, handler JobHandler) {}
+
+func (q *fakeAccrualJobQueue) Enqueue(job Job) {}
+
+func (q *fakeAccrualJobQueue) ScheduleJob(job Job, delay time.Duration) {
+	q.scheduled = append(q.scheduled, scheduledJob{job, delay})
+}
+
+func (q *fakeAccrualJobQueue) PauseAndResume(delay time.Duration) {
+	q.paused = append(q.paused, delay)
+}
+
+type fakeAccrualClient struct {
+	accrualClient
+	data          *accrualDataResponse
+	err           error
+	remainingOpen time.Duration
+}
+
+func (c *fakeAccrualClient) FetchAccrualData(ctx context.Context, orderID string) (*accrualDataResponse, time.Duration, error) {
+	return c.data, 0, c.err
+}
+
+func TestHandleCalculateAccrualRetriesStorageErrors(t *testing.T) {
+	accrual := utils.Money(50000)
+	storage := &fakeAccrualStorage{
+		order:     &database.OrderDB{ID: "12345678903", Status: database.OrderStatusDB{OrderStatus: models.StatusNew}},
+		updateErr: errors.New("connection reset"),
+	}
+	queue := &fakeAccrualJobQueue{}
+	client := &fakeAccrualClient{data: &accrualDataResponse{ID: "12345678903", Status: AccrualStatusProcessed, Accrual: (*utils.RoundedMoney)(&accrual)}}
+	retryPolicy := RetryPolicy{InitialInterval: time.Second, MaxInterval: time.Minute, Multiplier: 2, MaxAttempts: 5}
+	service := NewAccrualService(storage, queue, client, retryPolicy, time.Second)
+
+	service.handleCalculateAccrual(context.Background(), Job{Kind: calculateAccrualJobKind, Payload: "12345678903"})
+	service.handleCalculateAccrual(context.Background(), Job{Kind: calculateAccrualJobKind, Payload: "12345678903"})
+
+	require.Len(t, queue.scheduled, 2)
+	assert.Equal(t, "12345678903", queue.scheduled[0].job.Payload)
+	assert.Equal(t, time.Second, queue.scheduled[0].delay)
+	assert.Equal(t, 2*time.Second, queue.scheduled[1].delay)
+	assert.Equal(t, 2, storage.attempts)
+	assert.Zero(t, storage.resets)
+
+	storage.updateErr = nil
+
+	service.handleCalculateAccrual(context.Background(), Job{Kind: calculateAccrualJobKind, Payload: "12345678903"})
+
+	assert.Len(t, queue.scheduled, 2)
+	assert.Zero(t, storage.attempts)
+}
+
+func (c *fakeAccrualClient) CircuitBreakerRemainingOpen() time.Duration {
+	return c.remainingOpen
+}
+
+func TestHandleCalculateAccrualWaitsForOpenCircuit(t *testing.T) {
+	testCases := []struct {
+		testName      string
+		remainingOpen time.Duration
+		expectedDelay time.Duration
+	}{
+		{testName: "Should wait until circuit is half-open", remainingOpen: 20 * time.Second, expectedDelay: 20 * time.Second},
+		{testName: "Should wait at least poll interval while probe is in flight", remainingOpen: 0, expectedDelay: 5 * time.Second},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			queue := &fakeAccrualJobQueue{}
+			client := &fakeAccrualClient{err: ErrCircuitIsOpen, remainingOpen: tc.remainingOpen}
+			service := NewAccrualService(&fakeAccrualStorage{}, queue, client, DefaultRetryPolicy(), 5*time.Second)
+
+			service.handleCalculateAccrual(context.Background(), Job{Kind: calculateAccrualJobKind, Payload: "12345678903"})
+
+			require.Len(t, queue.scheduled, 1)
+			assert.Equal(t, tc.expectedDelay, queue.scheduled[0].delay)
+			assert.Equal(t, []time.Duration{tc.expectedDelay}, queue.paused)
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/database"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/utils"
+)
+
+var (
+	ErrAPIKeyNameIsInvalid    = errors.New("api key name is invalid")
+	ErrAPIKeyScopesAreInvalid = errors.New("api key scopes are invalid")
+	ErrAPIKeyExpiryIsInvalid  = errors.New("api key expiry is invalid")
+	ErrAPIKeyIsNotExist       = errors.New("api key is not exist")
+	ErrAPIKeyIsInvalid        = errors.New("api key is invalid")
+	ErrAPIKeyIsExpired        = errors.New("api key is expired")
+)
+
+const (
+	APIKeyPrefix = "gmk_"
+
+	apiKeyLength        = 32
+	apiKeyDisplayLength = len(APIKeyPrefix) + 8
+	apiKeyMaxNameLength = 100
+)
+
+// APIKeyService manages API keys that let machine clients act on behalf of a user.
+// Only the hashes of the keys are stored.
+type APIKeyService struct {
+	storage apiKeyStorage
+}
+
+type apiKeyStorage interface {
+	CreateAPIKey(ctx context.Context, userID, name, prefix, keyHash string, scopes []string, expiresAt *time.Time) (*database.APIKeyDB, error)
+
+	FindAPIKeys(ctx context.Context, userID string) (*[]database.APIKeyDB, error)
+
+	RevokeAPIKey(ctx context.Context, keyID, userID string) (bool, error)
+
+	UseAPIKey(ctx context.Context, keyHash string) (*database.UsedAPIKeyDB, error)
+}
+
+func NewAPIKeyService(storage apiKeyStorage) *APIKeyService {
+	return &APIKeyService{storage}
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func (aks *APIKeyService) CreateAPIKey(
+	ctx context.Context,
+	userID, name string,
+	scopes []models.APIKeyScope,
+	expiresAt *time.Time,
+) (models.APIKey, error) {
+	name = strings.TrimSpace(name)
+
+	if name == "" || len(name) > apiKeyMaxNameLength {
+		return models.APIKey{}, ErrAPIKeyNameIsInvalid
+	}
+
+	if len(scopes) == 0 {
+		return models.APIKey{}, ErrAPIKeyScopesAreInvalid
+	}
+
+	var filter []string
+	seen := make(map[models.APIKeyScope]bool)
+
+	for _, scope := range scopes {
+		if !scope.IsValid() {
+			return models.APIKey{}, fmt.Errorf("%w: unknown scope %q", ErrAPIKeyScopesAreInvalid, scope)
+		}
+
+		if !seen[scope] {
+			seen[scope] = true
+			filter = append(filter, string(scope))
+		}
+	}
+
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return models.APIKey{}, ErrAPIKeyExpiryIsInvalid
+	}
+
+	b := make([]byte, apiKeyLength)
+
+	if _, err := rand.Read(b); err != nil {
+		return models.APIKey{}, err
+	}
+
+	key := APIKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+	apiKey, err := aks.storage.CreateAPIKey(ctx, userID, name, key[:apiKeyDisplayLength], hashAPIKey(key), filter, expiresAt)
+
+	if err != nil {
+		return models.APIKey{}, err
+	}
+
+	result := toAPIKey(*apiKey)
+	result.Key = key
+
+	return result, nil
+}
+
+func (aks *APIKeyService) GetAPIKeys(ctx context.Context, userID string) ([]models.APIKey, error) {
+	apiKeys, err := aks.storage.FindAPIKeys(ctx, userID)
+
+	if err != nil {
+		return []models.APIKey{}, err
+	}
+
+	if apiKeys == nil {
+		return []models.APIKey{}, nil
+	}
+
+	result := make([]models.APIKey, len(*apiKeys))
+
+	for i, apiKey := range *apiKeys {
+		result[i] = toAPIKey(apiKey)
+	}
+
+	return result, nil
+}
+
+func (aks *APIKeyService) RevokeAPIKey(ctx context.Context, keyID, userID string) error {
+	ok, err := aks.storage.RevokeAPIKey(ctx, keyID, userID)
+
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return ErrAPIKeyIsNotExist
+	}
+
+	return nil
+}
+
+// AuthenticateAPIKey returns the owner and the scopes of the active key and records its usage.
+func (aks *APIKeyService) AuthenticateAPIKey(ctx context.Context, key string) (*models.APIKeyPrincipal, error) {
+	if !strings.HasPrefix(key, APIKeyPrefix) {
+		return nil, ErrAPIKeyIsInvalid
+	}
+
+	apiKey, err := aks.storage.UseAPIKey(ctx, hashAPIKey(key))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if apiKey == nil {
+		return nil, ErrAPIKeyIsInvalid
+	}
+
+	if apiKey.ExpiresAt != nil && !apiKey.ExpiresAt.After(time.Now()) {
+		return nil, ErrAPIKeyIsExpired
+	}
+
+	scopes := make([]models.APIKeyScope, len(apiKey.Scopes))
+
+	for i, scope := range apiKey.Scopes {
+		scopes[i] = models.APIKeyScope(scope)
+	}
+
+	return &models.APIKeyPrincipal{KeyID: apiKey.ID, Login: apiKey.Login, Scopes: scopes}, nil
+}
+
+func toAPIKey(apiKey database.APIKeyDB) models.APIKey {
+	result := models.APIKey{
+		ID:        apiKey.ID,
+		Name:      apiKey.Name,
+		Prefix:    apiKey.Prefix,
+		Scopes:    make([]models.APIKeyScope, len(apiKey.Scopes)),
+		CreatedAt: utils.RFC3339Date{Time: apiKey.CreatedAt},
+	}
+
+	for i, scope := range apiKey.Scopes {
+		result.Scopes[i] = models.APIKeyScope(scope)
+	}
+
+	if apiKey.ExpiresAt != nil {
+		result.ExpiresAt = &utils.RFC3339Date{Time: *apiKey.ExpiresAt}
+	}
+
+	if apiKey.LastUsedAt != nil {
+		result.LastUsedAt = &utils.RFC3339Date{Time: *apiKey.LastUsedAt}
+	}
+
+	return result
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/database"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAPIKeyStorage struct {
+	apiKeyStorage
+	keys map[string]database.UsedAPIKeyDB
+}
+
+func (s *fakeAPIKeyStorage) CreateAPIKey(
+	ctx context.Context,
+	userID, name, prefix, keyHash string,
+	scopes []string,
+	expiresAt *time.Time,
+) (*database.APIKeyDB, error) {
+	s.keys[keyHash] = database.UsedAPIKeyDB{ID: "key-id", UserID: userID, Login: "login", Scopes: scopes, ExpiresAt: expiresAt}
+
+	return &database.APIKeyDB{
+		ID:        "key-id",
+		UserID:    userID,
+		Name:      name,
+		Prefix:    prefix,
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+func (s *fakeAPIKeyStorage) UseAPIKey(ctx context.Context, keyHash string) (*database.UsedAPIKeyDB, error) {
+	apiKey, ok := s.keys[keyHash]
+
+	if !ok {
+		return nil, nil
+	}
+
+	return &apiKey, nil
+}
+
+func TestCreateAPIKeyIsValidated(t *testing.T) {
+	service := NewAPIKeyService(&fakeAPIKeyStorage{keys: map[string]database.UsedAPIKeyDB{}})
+	past := time.Now().Add(-time.Minute)
+
+	_, err := service.CreateAPIKey(context.Background(), "user-id", " ", []models.APIKeyScope{models.APIKeyScopeWithdraw}, nil)
+	assert.ErrorIs(t, err, ErrAPIKeyNameIsInvalid)
+
+	_, err = service.CreateAPIKey(context.Background(), "user-id", "pos", nil, nil)
+	assert.ErrorIs(t, err, ErrAPIKeyScopesAreInvalid)
+
+	_, err = service.CreateAPIKey(context.Background(), "user-id", "pos", []models.APIKeyScope{"admin"}, nil)
+	assert.ErrorIs(t, err, ErrAPIKeyScopesAreInvalid)
+
+	_, err = service.CreateAPIKey(context.Background(), "user-id", "pos", []models.APIKeyScope{models.APIKeyScopeWithdraw}, &past)
+	assert.ErrorIs(t, err, ErrAPIKeyExpiryIsInvalid)
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	ctx := context.Background()
+	storage := &fakeAPIKeyStorage{keys: map[string]database.UsedAPIKeyDB{}}
+	service := NewAPIKeyService(storage)
+
+	apiKey, err := service.CreateAPIKey(
+		ctx,
+		"user-id",
+		"pos",
+		[]models.APIKeyScope{models.APIKeyScopeOrdersWrite, models.APIKeyScopeOrdersWrite, models.APIKeyScopeBalanceRead},
+		nil,
+	)
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(apiKey.Key, APIKeyPrefix))
+	assert.True(t, strings.HasPrefix(apiKey.Key, apiKey.Prefix))
+	assert.Equal(t, []models.APIKeyScope{models.APIKeyScopeOrdersWrite, models.APIKeyScopeBalanceRead}, apiKey.Scopes)
+	assert.NotContains(t, storage.keys, apiKey.Key)
+
+	principal, err := service.AuthenticateAPIKey(ctx, apiKey.Key)
+	require.NoError(t, err)
+
+	assert.Equal(t, "login", principal.Login)
+	assert.True(t, principal.HasScope(models.APIKeyScopeOrdersWrite))
+	assert.False(t, principal.HasScope(models.APIKeyScopeWithdraw))
+
+	_, err = service.AuthenticateAPIKey(ctx, "gmk_unknown")
+	assert.ErrorIs(t, err, ErrAPIKeyIsInvalid)
+
+	_, err = service.AuthenticateAPIKey(ctx, "unknown")
+	assert.ErrorIs(t, err, ErrAPIKeyIsInvalid)
+
+	expired := storage.keys[hashAPIKey(apiKey.Key)]
+	past := time.Now().Add(-time.Minute)
+	expired.ExpiresAt = &past
+	storage.keys[hashAPIKey(apiKey.Key)] = expired
+
+	_, err = service.AuthenticateAPIKey(ctx, apiKey.Key)
+	assert.ErrorIs(t, err, ErrAPIKeyIsExpired)
+}
@@ -13,6 +13,7 @@ var (
 	ErrUserIsAlreadyRegistered = errors.New("user is already registered")
 	ErrUserIsNotExist          = errors.New("user is not exist")
 	ErrPasswordIsIncorrect     = errors.New("password is incorrect")
+	ErrRoleIsInvalid           = errors.New("role is invalid")
 )
 
 type AuthService struct {
@@ -23,6 +24,10 @@ type AuthStorage interface {
 	CreateUser(ctx context.Context, user database.UserDB) error
 
 	FindUser(ctx context.Context, login string) (*database.UserDB, error)
+
+	UpdateUserRole(ctx context.Context, login string, role models.Role) error
+
+	FindUsers(ctx context.Context, search, after string, limit int) (*[]database.UserDB, error)
 }
 
 func NewAuthService(storage AuthStorage) *AuthService {
@@ -36,7 +41,7 @@ func (auth *AuthService) Register(ctx context.Context, user models.UnknownUser)
 		return err
 	}
 
-	if err := auth.storage.CreateUser(ctx, database.UserDB{User: models.User{Login: *user.Login, Hash: string(hashedPassword)}}); err != nil {
+	if err := auth.storage.CreateUser(ctx, database.UserDB{User: models.User{Login: *user.Login, Hash: string(hashedPassword), Role: models.RoleUser}}); err != nil {
 		if errors.Is(err, database.ErrDuplicateUser) {
 			return ErrUserIsAlreadyRegistered
 		}
@@ -82,3 +87,59 @@ func (auth *AuthService) GetUser(ctx context.Context, login string) (*models.Use
 
 	return &user.User, nil
 }
+
+func (auth *AuthService) SetUserRole(ctx context.Context, login string, role models.Role) error {
+	if !role.IsValid() {
+		return ErrRoleIsInvalid
+	}
+
+	if err := auth.storage.UpdateUserRole(ctx, login, role); err != nil {
+		if errors.Is(err, database.ErrUserIsNotExist) {
+			return ErrUserIsNotExist
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// GetUsers returns a page of users whose login contains the search string, sorted by login.
+// Only the limit and the cursor of the filter apply, and the cursor holds the last login.
+func (auth *AuthService) GetUsers(ctx context.Context, search string, filter models.ListFilter) ([]models.UserSummary, *models.Cursor, error) {
+	after := ""
+
+	if filter.After != nil {
+		after = filter.After.ID
+	}
+
+	limit := filter.Limit
+
+	if limit > 0 {
+		limit++
+	}
+
+	users, err := auth.storage.FindUsers(ctx, search, after, limit)
+
+	if err != nil {
+		return []models.UserSummary{}, nil, err
+	}
+
+	if users == nil {
+		return []models.UserSummary{}, nil, nil
+	}
+
+	result := make([]models.UserSummary, len(*users))
+
+	for i, user := range *users {
+		result[i] = models.UserSummary{ID: user.ID, Login: user.Login, Role: user.Role}
+	}
+
+	if filter.Limit > 0 && len(result) > filter.Limit {
+		result = result[:filter.Limit]
+
+		return result, &models.Cursor{ID: result[len(result)-1].Login}, nil
+	}
+
+	return result, nil, nil
+}
@@ -2,23 +2,32 @@ package services
 
 import (
 	"context"
-	"sort"
+	"errors"
 
 	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/database"
 	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
 	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/utils"
 )
 
+var (
+	ErrInsufficientFunds                 = errors.New("there are insufficient funds")
+	ErrDuplicateWithdrawal               = errors.New("withdrawal is duplicated")
+	ErrDuplicateWithdrawalByOriginalUser = errors.New("withdrawal is duplicated by the same user")
+	ErrWithdrawalIsNotExist              = errors.New("withdrawal is not exist")
+)
+
 type BalanceService struct {
 	storage balanceStorage
 }
 
 type balanceStorage interface {
-	FindAccrualFlow(ctx context.Context, userID string) (*[]database.AccrualFlowItemDB, error)
+	FindBalance(ctx context.Context, userID string) (*database.BalanceDB, error)
 
-	CreateWithdrawal(ctx context.Context, orderID, userID string, amount float64) error
+	CreateWithdrawal(ctx context.Context, orderID, userID string, amount utils.Money) error
 
-	FindWithdrawalFlow(ctx context.Context, userID string) (*[]database.WithdrawalFlowItemDB, error)
+	FindWithdrawalFlow(ctx context.Context, userID string, filter models.ListFilter) (*[]database.WithdrawalFlowItemDB, error)
+
+	FindWithdrawal(ctx context.Context, orderID string) (*database.WithdrawalDB, error)
 }
 
 func NewBalanceService(storage balanceStorage) *BalanceService {
@@ -26,53 +35,62 @@ func NewBalanceService(storage balanceStorage) *BalanceService {
 }
 
 func (b *BalanceService) GetUserBalance(ctx context.Context, userID string) (models.Balance, error) {
-	accrualFlow, err := b.storage.FindAccrualFlow(ctx, userID)
+	balance, err := b.storage.FindBalance(ctx, userID)
 
 	if err != nil {
 		return models.Balance{}, err
 	}
 
-	withdrawalFlow, err := b.storage.FindWithdrawalFlow(ctx, userID)
-
-	if err != nil {
-		return models.Balance{}, err
+	if balance == nil {
+		return models.Balance{}, nil
 	}
 
-	var current float64 = 0
-	var withdrawn float64 = 0
+	return models.Balance{Current: balance.Current, Withdrawn: balance.Withdrawn}, nil
+}
 
-	if accrualFlow != nil {
-		for _, item := range *accrualFlow {
-			current += item.Amount
+func (b *BalanceService) CreateWithdrawal(ctx context.Context, orderID, userID string, amount utils.Money) error {
+	if err := b.storage.CreateWithdrawal(ctx, orderID, userID, amount); err != nil {
+		if errors.Is(err, database.ErrInsufficientFunds) {
+			return ErrInsufficientFunds
 		}
-	}
 
-	if withdrawalFlow != nil {
-		for _, item := range *withdrawalFlow {
-			withdrawn += item.Amount
+		if !errors.Is(err, database.ErrDuplicateWithdrawal) {
+			return err
 		}
-	}
 
-	return models.Balance{Current: current - withdrawn, Withdrawn: withdrawn}, nil
-}
+		withdrawal, errWithdrawal := b.storage.FindWithdrawal(ctx, orderID)
 
-func (b *BalanceService) CreateWithdrawal(ctx context.Context, orderID, userID string, amount float64) error {
-	if err := b.storage.CreateWithdrawal(ctx, orderID, userID, amount); err != nil {
-		return err
+		if errWithdrawal != nil {
+			return errWithdrawal
+		}
+
+		if withdrawal != nil && withdrawal.UserID == userID {
+			return ErrDuplicateWithdrawalByOriginalUser
+		}
+
+		return ErrDuplicateWithdrawal
 	}
 
 	return nil
 }
 
-func (b *BalanceService) GetWithdrawalFlow(ctx context.Context, userID string) ([]models.WithdrawalFlowItem, error) {
-	withdrawalFlow, err := b.storage.FindWithdrawalFlow(ctx, userID)
+// GetWithdrawalFlow returns a page of user withdrawals sorted in SQL. The cursor of the next page
+// is nil when there are no more withdrawals or the filter has no limit.
+func (b *BalanceService) GetWithdrawalFlow(ctx context.Context, userID string, filter models.ListFilter) ([]models.WithdrawalFlowItem, *models.Cursor, error) {
+	query := filter
+
+	if filter.Limit > 0 {
+		query.Limit = filter.Limit + 1
+	}
+
+	withdrawalFlow, err := b.storage.FindWithdrawalFlow(ctx, userID, query)
 
 	if err != nil {
-		return []models.WithdrawalFlowItem{}, err
+		return []models.WithdrawalFlowItem{}, nil, err
 	}
 
 	if withdrawalFlow == nil {
-		return []models.WithdrawalFlowItem{}, nil
+		return []models.WithdrawalFlowItem{}, nil, nil
 	}
 
 	result := make([]models.WithdrawalFlowItem, len(*withdrawalFlow))
@@ -85,9 +103,31 @@ func (b *BalanceService) GetWithdrawalFlow(ctx context.Context, userID string) (
 		}
 	}
 
-	sort.Slice(result, func(i, j int) bool {
-		return result[i].ProcessedAt.Time.Before(result[j].ProcessedAt.Time)
-	})
+	if filter.Limit > 0 && len(result) > filter.Limit {
+		result = result[:filter.Limit]
+		last := result[len(result)-1]
+
+		return result, &models.Cursor{Time: last.ProcessedAt.Time, ID: last.OrderID}, nil
+	}
+
+	return result, nil, nil
+}
+
+// GetWithdrawal returns the withdrawal only to its owner.
+func (b *BalanceService) GetWithdrawal(ctx context.Context, orderID, userID string) (models.WithdrawalFlowItem, error) {
+	withdrawal, err := b.storage.FindWithdrawal(ctx, orderID)
+
+	if err != nil {
+		return models.WithdrawalFlowItem{}, err
+	}
+
+	if withdrawal == nil || withdrawal.UserID != userID {
+		return models.WithdrawalFlowItem{}, ErrWithdrawalIsNotExist
+	}
 
-	return result, nil
+	return models.WithdrawalFlowItem{
+		OrderID:     withdrawal.OrderID,
+		Sum:         withdrawal.Amount,
+		ProcessedAt: utils.RFC3339Date{Time: withdrawal.ProcessedAt},
+	}, nil
 }
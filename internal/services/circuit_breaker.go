@@ -0,0 +1,150 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	ErrCircuitIsOpen = errors.New("circuit breaker is open")
+)
+
+type CircuitBreakerState string
+
+const (
+	CircuitBreakerClosed   CircuitBreakerState = "closed"
+	CircuitBreakerOpen     CircuitBreakerState = "open"
+	CircuitBreakerHalfOpen CircuitBreakerState = "half-open"
+)
+
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures that opens the circuit.
+	FailureThreshold int
+	// OpenTimeout is how long the circuit stays open before a probe is let through.
+	OpenTimeout time.Duration
+	// SuccessThreshold is the number of successful probes that closes a half-open circuit.
+	SuccessThreshold int
+}
+
+func DefaultCircuitBreakerConfig() CircuitBreakerConfig {
+	return CircuitBreakerConfig{
+		FailureThreshold: 5,
+		OpenTimeout:      30 * time.Second,
+		SuccessThreshold: 1,
+	}
+}
+
+type CircuitBreaker struct {
+	mu            sync.Mutex
+	config        CircuitBreakerConfig
+	state         CircuitBreakerState
+	failures      int
+	successes     int
+	openedAt      time.Time
+	probeInFlight bool
+	now           func() time.Time
+}
+
+func NewCircuitBreaker(config CircuitBreakerConfig) *CircuitBreaker {
+	return &CircuitBreaker{
+		config: config,
+		state:  CircuitBreakerClosed,
+		now:    time.Now,
+	}
+}
+
+// Allow reports whether a call may be made. Every allowed call must be followed by Success or Failure.
+func (cb *CircuitBreaker) Allow() error {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case CircuitBreakerOpen:
+		if cb.now().Sub(cb.openedAt) < cb.config.OpenTimeout {
+			return ErrCircuitIsOpen
+		}
+
+		cb.state = CircuitBreakerHalfOpen
+		cb.successes = 0
+		cb.probeInFlight = true
+
+		return nil
+	case CircuitBreakerHalfOpen:
+		if cb.probeInFlight {
+			return ErrCircuitIsOpen
+		}
+
+		cb.probeInFlight = true
+
+		return nil
+	default:
+		return nil
+	}
+}
+
+func (cb *CircuitBreaker) Success() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.failures = 0
+
+	if cb.state != CircuitBreakerHalfOpen {
+		return
+	}
+
+	cb.probeInFlight = false
+	cb.successes++
+
+	if cb.successes >= cb.config.SuccessThreshold {
+		cb.state = CircuitBreakerClosed
+	}
+}
+
+func (cb *CircuitBreaker) Failure() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state == CircuitBreakerHalfOpen {
+		cb.open()
+		return
+	}
+
+	cb.failures++
+
+	if cb.state == CircuitBreakerClosed && cb.failures >= cb.config.FailureThreshold {
+		cb.open()
+	}
+}
+
+func (cb *CircuitBreaker) open() {
+	cb.state = CircuitBreakerOpen
+	cb.openedAt = cb.now()
+	cb.failures = 0
+	cb.probeInFlight = false
+}
+
+func (cb *CircuitBreaker) State() CircuitBreakerState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	return cb.state
+}
+
+// RemainingOpen returns how long the circuit stays open before the next probe is allowed.
+func (cb *CircuitBreaker) RemainingOpen() time.Duration {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state != CircuitBreakerOpen {
+		return 0
+	}
+
+	remaining := cb.config.OpenTimeout - cb.now().Sub(cb.openedAt)
+
+	if remaining < 0 {
+		return 0
+	}
+
+	return remaining
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func newTestCircuitBreaker(config CircuitBreakerConfig) (*CircuitBreaker, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	cb := NewCircuitBreaker(config)
+	cb.now = clock.Now
+
+	return cb, clock
+}
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+	config := CircuitBreakerConfig{FailureThreshold: 3, OpenTimeout: time.Minute, SuccessThreshold: 2}
+
+	type step struct {
+		advance  time.Duration
+		call     func(cb *CircuitBreaker)
+		allowErr error
+		expected CircuitBreakerState
+	}
+
+	allow := func(cb *CircuitBreaker) {}
+	success := (*CircuitBreaker).Success
+	failure := (*CircuitBreaker).Failure
+
+	testCases := []struct {
+		testName string
+		steps    []step
+	}{
+		{
+			testName: "Should stay closed below failure threshold",
+			steps: []step{
+				{call: failure, expected: CircuitBreakerClosed},
+				{call: failure, expected: CircuitBreakerClosed},
+				{call: success, expected: CircuitBreakerClosed},
+				{call: failure, expected: CircuitBreakerClosed},
+				{call: failure, expected: CircuitBreakerClosed},
+			},
+		},
+		{
+			testName: "Should open at failure threshold",
+			steps: []step{
+				{call: failure, expected: CircuitBreakerClosed},
+				{call: failure, expected: CircuitBreakerClosed},
+				{call: failure, expected: CircuitBreakerOpen},
+				{advance: time.Minute - time.Second, call: allow, allowErr: ErrCircuitIsOpen, expected: CircuitBreakerOpen},
+			},
+		},
+		{
+			testName: "Should become half-open after open timeout",
+			steps: []step{
+				{call: failure},
+				{call: failure},
+				{call: failure, expected: CircuitBreakerOpen},
+				{advance: time.Minute, call: allow, expected: CircuitBreakerHalfOpen},
+			},
+		},
+		{
+			testName: "Should close after successful probes",
+			steps: []step{
+				{call: failure},
+				{call: failure},
+				{call: failure, expected: CircuitBreakerOpen},
+				{advance: time.Minute, call: success, expected: CircuitBreakerHalfOpen},
+				{call: success, expected: CircuitBreakerClosed},
+			},
+		},
+		{
+			testName: "Should open again after failed probe",
+			steps: []step{
+				{call: failure},
+				{call: failure},
+				{call: failure, expected: CircuitBreakerOpen},
+				{advance: time.Minute, call: failure, expected: CircuitBreakerOpen},
+				{advance: time.Minute - time.Second, call: allow, allowErr: ErrCircuitIsOpen, expected: CircuitBreakerOpen},
+				{advance: time.Second, call: allow, expected: CircuitBreakerHalfOpen},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			cb, clock := newTestCircuitBreaker(config)
+
+			for i, s := range tc.steps {
+				clock.Advance(s.advance)
+
+				// Every call goes through Allow, like the client does.
+				err := cb.Allow()
+
+				if s.allowErr != nil {
+					require.ErrorIs(t, err, s.allowErr, "step %d", i)
+					continue
+				}
+
+				require.NoError(t, err, "step %d", i)
+				s.call(cb)
+
+				if s.expected != "" {
+					assert.Equal(t, s.expected, cb.State(), "step %d", i)
+				}
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerRemainingOpen(t *testing.T) {
+	cb, clock := newTestCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Minute, SuccessThreshold: 1})
+
+	assert.Zero(t, cb.RemainingOpen())
+
+	require.NoError(t, cb.Allow())
+	cb.Failure()
+	clock.Advance(20 * time.Second)
+
+	assert.Equal(t, 40*time.Second, cb.RemainingOpen())
+
+	clock.Advance(40 * time.Second)
+	require.NoError(t, cb.Allow())
+
+	assert.Zero(t, cb.RemainingOpen())
+}
+
+func TestCircuitBreakerLetsOneProbeThroughWhenHalfOpen(t *testing.T) {
+	cb, clock := newTestCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Minute, SuccessThreshold: 1})
+
+	require.NoError(t, cb.Allow())
+	cb.Failure()
+	clock.Advance(time.Minute)
+
+	const callers = 50
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var allowed, rejected int
+
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			err := cb.Allow()
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err == nil {
+				allowed++
+			} else {
+				assert.ErrorIs(t, err, ErrCircuitIsOpen)
+				rejected++
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, 1, allowed)
+	assert.Equal(t, callers-1, rejected)
+	assert.Equal(t, CircuitBreakerHalfOpen, cb.State())
+
+	cb.Success()
+
+	assert.Equal(t, CircuitBreakerClosed, cb.State())
+	assert.NoError(t, cb.Allow())
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/database"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/logger"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
+	"go.uber.org/zap"
+)
+
+const (
+	userEventsPageSize      = 100
+	userEventsRelistenDelay = time.Second
+	userEventsCleanupPeriod = time.Hour
+)
+
+// EventService delivers user events stored by other instances as well, using Postgres LISTEN/NOTIFY.
+// Subscribers are only woken up and read the events from the storage themselves.
+type EventService struct {
+	storage     eventStorage
+	retention   time.Duration
+	mu          sync.Mutex
+	subscribers map[string]map[chan struct{}]struct{}
+	cancel      context.CancelFunc
+	wg          sync.WaitGroup
+}
+
+type eventStorage interface {
+	ListenUserEvents(ctx context.Context, notify func(userID string)) error
+
+	FindUserEvents(ctx context.Context, userID string, afterID int64, limit int) (*[]database.UserEventDB, error)
+
+	FindLastUserEventID(ctx context.Context, userID string) (int64, error)
+
+	DeleteUserEvents(ctx context.Context, olderThan time.Duration) (int64, error)
+}
+
+func NewEventService(storage eventStorage, retention time.Duration) *EventService {
+	return &EventService{
+		storage:     storage,
+		retention:   retention,
+		subscribers: make(map[string]map[chan struct{}]struct{}),
+	}
+}
+
+func (es *EventService) Start(ctx context.Context) {
+	ctx, es.cancel = context.WithCancel(ctx)
+
+	es.wg.Add(2)
+
+	go func() {
+		defer es.wg.Done()
+
+		for {
+			err := es.storage.ListenUserEvents(ctx, es.notify)
+
+			if ctx.Err() != nil {
+				return
+			}
+
+			logger.Log.Error("user events listener has stopped", zap.Error(err))
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(userEventsRelistenDelay):
+			}
+
+			// Notifications sent while the listener was down are lost, so everyone rereads the storage.
+			es.notifyAll()
+		}
+	}()
+
+	go func() {
+		defer es.wg.Done()
+
+		ticker := time.NewTicker(userEventsCleanupPeriod)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := es.storage.DeleteUserEvents(ctx, es.retention); err != nil {
+					logger.Log.Error("failed to delete old user events", zap.Error(err))
+				}
+			}
+		}
+	}()
+}
+
+func (es *EventService) notify(userID string) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
+	for ch := range es.subscribers[userID] {
+		wake(ch)
+	}
+}
+
+func (es *EventService) notifyAll() {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
+	for _, subscribers := range es.subscribers {
+		for ch := range subscribers {
+			wake(ch)
+		}
+	}
+}
+
+func wake(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// Subscribe returns a channel that receives a signal when new events of the user may be available
+// and a function that cancels the subscription.
+func (es *EventService) Subscribe(userID string) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
+	if es.subscribers[userID] == nil {
+		es.subscribers[userID] = make(map[chan struct{}]struct{})
+	}
+
+	es.subscribers[userID][ch] = struct{}{}
+
+	return ch, func() {
+		es.mu.Lock()
+		defer es.mu.Unlock()
+
+		delete(es.subscribers[userID], ch)
+
+		if len(es.subscribers[userID]) == 0 {
+			delete(es.subscribers, userID)
+		}
+	}
+}
+
+// GetEvents returns the next page of user events that follow the event with afterID.
+func (es *EventService) GetEvents(ctx context.Context, userID string, afterID int64) ([]models.UserEvent, error) {
+	events, err := es.storage.FindUserEvents(ctx, userID, afterID, userEventsPageSize)
+
+	if err != nil {
+		return []models.UserEvent{}, err
+	}
+
+	if events == nil {
+		return []models.UserEvent{}, nil
+	}
+
+	result := make([]models.UserEvent, len(*events))
+
+	for i, event := range *events {
+		result[i] = models.UserEvent{ID: event.ID, Type: event.Type, Data: event.Payload}
+	}
+
+	return result, nil
+}
+
+func (es *EventService) LastEventID(ctx context.Context, userID string) (int64, error) {
+	return es.storage.FindLastUserEventID(ctx, userID)
+}
+
+func (es *EventService) Shutdown() {
+	if es.cancel != nil {
+		es.cancel()
+	}
+
+	es.wg.Wait()
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/database"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
+)
+
+var (
+	ErrIdempotencyKeyIsReused     = errors.New("idempotency key is reused with a different request")
+	ErrIdempotencyKeyIsInProgress = errors.New("idempotency key is in progress")
+)
+
+// idempotencyLockTimeout bounds how long a key stays reserved when the first request
+// never completes, e.g. because the instance died.
+const idempotencyLockTimeout = time.Minute
+
+type IdempotencyService struct {
+	storage idempotencyStorage
+	ttl     time.Duration
+}
+
+type idempotencyStorage interface {
+	ClaimIdempotencyKey(ctx context.Context, userID, key, requestHash string, lockTimeout time.Duration) (*database.IdempotencyKeyDB, error)
+
+	SaveIdempotencyKeyResponse(ctx context.Context, userID, key string, statusCode int, contentType string, body []byte, ttl time.Duration) error
+
+	DeleteIdempotencyKey(ctx context.Context, userID, key string) error
+}
+
+func NewIdempotencyService(storage idempotencyStorage, ttl time.Duration) *IdempotencyService {
+	return &IdempotencyService{storage, ttl}
+}
+
+// Begin reserves the key for the request. It returns the stored response when the key
+// has already been used for the same request.
+func (is *IdempotencyService) Begin(ctx context.Context, userID, key, requestHash string) (*models.IdempotentResponse, error) {
+	item, err := is.storage.ClaimIdempotencyKey(ctx, userID, key, requestHash, idempotencyLockTimeout)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if item == nil {
+		return nil, nil
+	}
+
+	if item.RequestHash != requestHash {
+		return nil, ErrIdempotencyKeyIsReused
+	}
+
+	if item.StatusCode == nil {
+		return nil, ErrIdempotencyKeyIsInProgress
+	}
+
+	response := &models.IdempotentResponse{StatusCode: *item.StatusCode, Body: item.ResponseBody}
+
+	if item.ContentType != nil {
+		response.ContentType = *item.ContentType
+	}
+
+	return response, nil
+}
+
+func (is *IdempotencyService) Complete(ctx context.Context, userID, key string, response models.IdempotentResponse) error {
+	return is.storage.SaveIdempotencyKeyResponse(ctx, userID, key, response.StatusCode, response.ContentType, response.Body, is.ttl)
+}
+
+// Release frees the key so that the request can be executed again.
+func (is *IdempotencyService) Release(ctx context.Context, userID, key string) error {
+	return is.storage.DeleteIdempotencyKey(ctx, userID, key)
+}
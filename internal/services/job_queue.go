@@ -2,99 +2,177 @@ package services
 
 import (
 	"context"
-	"errors"
 	"sync"
 	"sync/atomic"
 	"time"
-)
 
-var (
-	ErrJobQueueIsFull = errors.New("job queue is full")
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/database"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/logger"
+	"go.uber.org/zap"
 )
 
-type Job func(ctx context.Context)
+const defaultJobLease = 5 * time.Minute
+
+type Job struct {
+	ID       string
+	Kind     string
+	Payload  string
+	Attempts int
+}
+
+type JobHandler func(ctx context.Context, job Job)
 
 type JobQueueService struct {
-	jobs   chan Job
-	resume chan struct{}
-	paused int32
-	wg     sync.WaitGroup
+	ctx          context.Context
+	storage      jobQueueStorage
+	handlers     map[string]JobHandler
+	mu           sync.RWMutex
+	pausedUntil  int64
+	standby      int32
+	pollInterval time.Duration
+	done         chan struct{}
+	wg           sync.WaitGroup
 }
 
-func NewJobQueueService(ctx context.Context, capacity, workers int) *JobQueueService {
+type jobQueueStorage interface {
+	CreateJob(ctx context.Context, kind, payload string, delay time.Duration) error
+
+	ClaimJob(ctx context.Context, kinds []string, lease time.Duration) (*database.JobDB, error)
+
+	DeleteJob(ctx context.Context, jobID string) error
+}
+
+func NewJobQueueService(ctx context.Context, storage jobQueueStorage, workers int, pollInterval time.Duration) *JobQueueService {
 	service := &JobQueueService{
-		jobs:   make(chan Job, capacity),
-		resume: make(chan struct{}),
-		wg:     sync.WaitGroup{},
+		ctx:          ctx,
+		storage:      storage,
+		handlers:     make(map[string]JobHandler),
+		pollInterval: pollInterval,
+		done:         make(chan struct{}),
 	}
-	service.start(ctx, workers)
+	service.start(workers)
 
 	return service
 }
 
-func (jqs *JobQueueService) start(ctx context.Context, workers int) {
+func (jqs *JobQueueService) start(workers int) {
 	for i := 0; i < workers; i++ {
 		jqs.wg.Add(1)
 
 		go func() {
 			defer jqs.wg.Done()
 
+			wait := jqs.pollInterval
+
 			for {
 				select {
-				case job, ok := <-jqs.jobs:
-					if !ok {
-						return
-					}
+				case <-jqs.done:
+					return
+				case <-jqs.ctx.Done():
+					return
+				case <-time.After(wait):
+				}
 
-					if atomic.LoadInt32(&jqs.paused) == 1 {
-						<-jqs.resume
-					}
+				wait = jqs.pollInterval
 
-					job(ctx)
-				case <-ctx.Done():
-					return
+				if atomic.LoadInt32(&jqs.standby) == 1 {
+					continue
+				}
+
+				if pausedFor := time.Until(time.Unix(0, atomic.LoadInt64(&jqs.pausedUntil))); pausedFor > 0 {
+					wait = pausedFor
+				} else if jqs.runNext() {
+					wait = 0
 				}
 			}
 		}()
 	}
 }
 
-func (jqs *JobQueueService) Enqueue(job Job) {
-	jqs.jobs <- job
-	// todo think about handling overflow capacity
-	//select {
-	//case jqs.jobs <- job:
-	//	return nil
-	//default:
-	//	return ErrJobQueueIsFull
-	//}
-}
+func (jqs *JobQueueService) runNext() bool {
+	kinds := jqs.kinds()
 
-func (jqs *JobQueueService) ScheduleJob(job Job, delay time.Duration) {
-	time.AfterFunc(delay, func() {
-		jqs.jobs <- job
+	if len(kinds) == 0 {
+		return false
+	}
+
+	claimed, err := jqs.storage.ClaimJob(jqs.ctx, kinds, defaultJobLease)
+
+	if err != nil {
+		logger.Log.Error("failed to claim job", zap.Error(err))
+		return false
+	}
+
+	if claimed == nil {
+		return false
+	}
+
+	jqs.mu.RLock()
+	handler := jqs.handlers[claimed.Kind]
+	jqs.mu.RUnlock()
+
+	handler(jqs.ctx, Job{
+		ID:       claimed.ID,
+		Kind:     claimed.Kind,
+		Payload:  claimed.Payload,
+		Attempts: claimed.Attempts,
 	})
+
+	if err := jqs.storage.DeleteJob(jqs.ctx, claimed.ID); err != nil {
+		logger.Log.Error("failed to delete job", zap.String("jobID", claimed.ID), zap.Error(err))
+	}
+
+	return true
+}
+
+func (jqs *JobQueueService) kinds() []string {
+	jqs.mu.RLock()
+	defer jqs.mu.RUnlock()
+
+	result := make([]string, 0, len(jqs.handlers))
+
+	for kind := range jqs.handlers {
+		result = append(result, kind)
+	}
+
+	return result
+}
+
+func (jqs *JobQueueService) RegisterHandler(kind string, handler JobHandler) {
+	jqs.mu.Lock()
+	defer jqs.mu.Unlock()
+
+	jqs.handlers[kind] = handler
 }
 
-func (jqs *JobQueueService) Pause() {
-	atomic.StoreInt32(&jqs.paused, 1)
+func (jqs *JobQueueService) Enqueue(job Job) {
+	jqs.ScheduleJob(job, 0)
 }
 
-func (jqs *JobQueueService) Resume() {
-	if atomic.CompareAndSwapInt32(&jqs.paused, 1, 0) {
-		close(jqs.resume)
-		jqs.resume = make(chan struct{})
+func (jqs *JobQueueService) ScheduleJob(job Job, delay time.Duration) {
+	if err := jqs.storage.CreateJob(jqs.ctx, job.Kind, job.Payload, delay); err != nil {
+		logger.Log.Error("failed to enqueue job",
+			zap.String("kind", job.Kind),
+			zap.String("payload", job.Payload),
+			zap.Error(err),
+		)
 	}
 }
 
 func (jqs *JobQueueService) PauseAndResume(delay time.Duration) {
-	jqs.Pause()
-	time.AfterFunc(delay, func() {
-		jqs.Resume()
-	})
+	atomic.StoreInt64(&jqs.pausedUntil, time.Now().Add(delay).UnixNano())
+}
+
+// SetStandby stops or resumes claiming jobs, e.g. while another instance is the leader.
+func (jqs *JobQueueService) SetStandby(standby bool) {
+	if standby {
+		atomic.StoreInt32(&jqs.standby, 1)
+	} else {
+		atomic.StoreInt32(&jqs.standby, 0)
+	}
 }
 
 func (jqs *JobQueueService) Shutdown() {
-	close(jqs.jobs)
+	close(jqs.done)
 	jqs.wg.Wait()
 }
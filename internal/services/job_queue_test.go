@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type createdJob struct {
+	kind    string
+	payload string
+	delay   time.Duration
+}
+
+type fakeJobQueueStorage struct {
+	mu      sync.Mutex
+	jobs    []database.JobDB
+	created []createdJob
+	deleted []string
+	calls   []string
+}
+
+func (s *fakeJobQueueStorage) CreateJob(ctx context.Context, kind, payload string, delay time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.created = append(s.created, createdJob{kind, payload, delay})
+	s.calls = append(s.calls, "create")
+
+	return nil
+}
+
+func (s *fakeJobQueueStorage) ClaimJob(ctx context.Context, kinds []string, lease time.Duration) (*database.JobDB, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.jobs) == 0 {
+		return nil, nil
+	}
+
+	job := s.jobs[0]
+	s.jobs = s.jobs[1:]
+	s.calls = append(s.calls, "claim")
+
+	return &job, nil
+}
+
+func (s *fakeJobQueueStorage) DeleteJob(ctx context.Context, jobID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deleted = append(s.deleted, jobID)
+	s.calls = append(s.calls, "delete")
+
+	return nil
+}
+
+func TestJobQueueRunsAndDeletesJob(t *testing.T) {
+	storage := &fakeJobQueueStorage{jobs: []database.JobDB{{ID: "job-id", Kind: "kind", Payload: "payload", Attempts: 1}}}
+	service := NewJobQueueService(context.Background(), storage, 0, time.Second)
+
+	var handled []Job
+
+	service.RegisterHandler("kind", func(ctx context.Context, job Job) {
+		handled = append(handled, job)
+	})
+
+	assert.True(t, service.runNext())
+	assert.Equal(t, []Job{{ID: "job-id", Kind: "kind", Payload: "payload", Attempts: 1}}, handled)
+	assert.Equal(t, []string{"job-id"}, storage.deleted)
+
+	assert.False(t, service.runNext())
+}
+
+func TestJobQueueReschedulesBeforeDeletingClaimedJob(t *testing.T) {
+	storage := &fakeJobQueueStorage{jobs: []database.JobDB{{ID: "job-id", Kind: "kind", Payload: "payload", Attempts: 1}}}
+	service := NewJobQueueService(context.Background(), storage, 0, time.Second)
+
+	service.RegisterHandler("kind", func(ctx context.Context, job Job) {
+		service.ScheduleJob(job, time.Minute)
+	})
+
+	assert.True(t, service.runNext())
+	assert.Equal(t, []createdJob{{"kind", "payload", time.Minute}}, storage.created)
+	assert.Equal(t, []string{"job-id"}, storage.deleted)
+	assert.Equal(t, []string{"claim", "create", "delete"}, storage.calls)
+}
+
+func TestJobQueueDoesNotClaimWithoutHandlers(t *testing.T) {
+	storage := &fakeJobQueueStorage{jobs: []database.JobDB{{ID: "job-id", Kind: "kind"}}}
+	service := NewJobQueueService(context.Background(), storage, 0, time.Second)
+
+	assert.False(t, service.runNext())
+	assert.Empty(t, storage.calls)
+}
+
+func TestJobQueueWorkers(t *testing.T) {
+	testCases := []struct {
+		testName        string
+		standby         bool
+		pause           time.Duration
+		expectedHandled int
+	}{
+		{testName: "Should run queued jobs", expectedHandled: 2},
+		{testName: "Should not claim jobs in standby", standby: true},
+		{testName: "Should not claim jobs while paused", pause: time.Minute},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			storage := &fakeJobQueueStorage{jobs: []database.JobDB{{ID: "1", Kind: "kind"}, {ID: "2", Kind: "kind"}}}
+			service := NewJobQueueService(context.Background(), storage, 0, 10*time.Millisecond)
+			service.SetStandby(tc.standby)
+
+			if tc.pause > 0 {
+				service.PauseAndResume(tc.pause)
+			}
+
+			var mu sync.Mutex
+			handled := 0
+
+			service.RegisterHandler("kind", func(ctx context.Context, job Job) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				handled++
+			})
+
+			service.start(2)
+			time.Sleep(100 * time.Millisecond)
+			service.Shutdown()
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			require.Equal(t, tc.expectedHandled, handled)
+		})
+	}
+}
@@ -1,35 +1,82 @@
 package services
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
-	"fmt"
 	"time"
 
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
 	"github.com/golang-jwt/jwt/v5"
 )
 
 var (
 	ErrTokenIsInvalid = errors.New("token is invalid")
 	ErrTokenIsExpired = errors.New("token is expired")
+	ErrTokenIsRevoked = errors.New("token is revoked")
 )
 
+const tokenIDLength = 16
+
 type JWTService struct {
-	authSecretKey string
+	keys           JWTKeys
+	accessTokenTTL time.Duration
+	revocations    tokenRevocations
+}
+
+// JWTKeys provides the key that signs new tokens and the keys that verify them.
+type JWTKeys interface {
+	SigningKey() (string, jwt.SigningMethod, interface{}, error)
+
+	VerificationKey(token *jwt.Token) (interface{}, error)
+
+	JWKS() models.JWKS
+}
+
+type tokenRevocations interface {
+	IsRevoked(ctx context.Context, login, tokenID string, issuedAt time.Time) (bool, error)
+
+	Revoke(ctx context.Context, login, tokenID string, issuedAt, expiresAt time.Time) error
+
+	RevokeAll(ctx context.Context, login string) error
 }
 
-func NewJWTService(authSecretKey string) *JWTService {
-	return &JWTService{authSecretKey}
+func NewJWTService(keys JWTKeys, accessTokenTTL time.Duration, revocations tokenRevocations) *JWTService {
+	return &JWTService{keys, accessTokenTTL, revocations}
 }
 
-func (j *JWTService) GenerateJWT(subject string) (string, error) {
+// GenerateJWT issues an access token for the subject. The role is informational for clients:
+// routes check the role stored on the user, so demoting a user takes effect immediately.
+func (j *JWTService) GenerateJWT(subject string, role models.Role) (string, error) {
+	id := make([]byte, tokenIDLength)
+
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+
+	keyID, method, key, err := j.keys.SigningKey()
+
+	if err != nil {
+		return "", err
+	}
+
 	now := time.Now()
-	tokenString, err := jwt.NewWithClaims(
-		jwt.SigningMethodHS256,
+	token := jwt.NewWithClaims(
+		method,
 		jwt.MapClaims{
-			"sub": subject,
-			"iat": now.Unix(),
-			"exp": now.Add(24 * time.Hour).Unix(),
-		}).SignedString([]byte(j.authSecretKey))
+			"jti":  hex.EncodeToString(id),
+			"sub":  subject,
+			"role": role,
+			"iat":  now.Unix(),
+			"exp":  now.Add(j.accessTokenTTL).Unix(),
+		})
+
+	if keyID != "" {
+		token.Header["kid"] = keyID
+	}
+
+	tokenString, err := token.SignedString(key)
 
 	if err != nil {
 		return "", err
@@ -38,15 +85,9 @@ func (j *JWTService) GenerateJWT(subject string) (string, error) {
 	return tokenString, nil
 }
 
-func (j *JWTService) ValidateToken(token string) (*jwt.Token, error) {
+func (j *JWTService) ValidateToken(ctx context.Context, token string) (*jwt.Token, error) {
 	claims := &jwt.RegisteredClaims{}
-	parsedToken, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-
-		return []byte(j.authSecretKey), nil
-	})
+	parsedToken, err := jwt.ParseWithClaims(token, claims, j.keys.VerificationKey)
 
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenExpired) {
@@ -60,5 +101,48 @@ func (j *JWTService) ValidateToken(token string) (*jwt.Token, error) {
 		return nil, ErrTokenIsInvalid
 	}
 
+	revoked, err := j.revocations.IsRevoked(ctx, claims.Subject, claims.ID, numericDateTime(claims.IssuedAt))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if revoked {
+		return nil, ErrTokenIsRevoked
+	}
+
 	return parsedToken, nil
 }
+
+// RevokeToken revokes the validated token. Tokens issued without an id can't be revoked
+// one by one, so all tokens of their subject are revoked instead.
+func (j *JWTService) RevokeToken(ctx context.Context, token *jwt.Token) error {
+	claims, ok := token.Claims.(*jwt.RegisteredClaims)
+
+	if !ok {
+		return ErrTokenIsInvalid
+	}
+
+	if claims.ID == "" {
+		return j.revocations.RevokeAll(ctx, claims.Subject)
+	}
+
+	return j.revocations.Revoke(ctx, claims.Subject, claims.ID, numericDateTime(claims.IssuedAt), numericDateTime(claims.ExpiresAt))
+}
+
+func (j *JWTService) RevokeAllTokens(ctx context.Context, subject string) error {
+	return j.revocations.RevokeAll(ctx, subject)
+}
+
+// GetJWKS returns the public keys that verify the issued tokens.
+func (j *JWTService) GetJWKS() models.JWKS {
+	return j.keys.JWKS()
+}
+
+func numericDateTime(date *jwt.NumericDate) time.Time {
+	if date == nil {
+		return time.Unix(0, 0)
+	}
+
+	return date.Time
+}
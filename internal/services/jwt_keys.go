@@ -0,0 +1,458 @@
+package services
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/logger"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrNoSigningKey      = errors.New("there is no signing key")
+	ErrKeyIsNotExist     = errors.New("key is not exist")
+	ErrKeyIsNotSupported = errors.New("key type is not supported")
+	ErrNoActiveKey       = errors.New("there are several private keys, but the active one isn't set")
+)
+
+const (
+	keyFileExtension = ".pem"
+	// activeKeyFile holds the id of the key that signs new tokens.
+	activeKeyFile = "active"
+	// scheduledKeyIDLayout is the id of a key that starts signing at the given UTC time.
+	scheduledKeyIDLayout = "20060102T150405Z"
+)
+
+type jwtKey struct {
+	id      string
+	method  jwt.SigningMethod
+	private crypto.PrivateKey
+	public  crypto.PublicKey
+}
+
+// SecretKeys signs and verifies tokens with a shared HS256 secret.
+type SecretKeys struct {
+	secret []byte
+}
+
+func NewSecretKeys(secret string) *SecretKeys {
+	return &SecretKeys{[]byte(secret)}
+}
+
+func (sk *SecretKeys) SigningKey() (string, jwt.SigningMethod, interface{}, error) {
+	return "", jwt.SigningMethodHS256, sk.secret, nil
+}
+
+func (sk *SecretKeys) VerificationKey(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+
+	return sk.secret, nil
+}
+
+// JWKS is empty because a shared secret can't be published.
+func (sk *SecretKeys) JWKS() models.JWKS {
+	return models.JWKS{Keys: []models.JWK{}}
+}
+
+// KeySet holds RS256 and EdDSA keys loaded from the PEM files of a directory. The file name
+// without the extension is the key id. Every key, including public-only ones, verifies tokens
+// and is published in the JWKS until its file is removed.
+//
+// New tokens are signed with the key whose id is written in the "active" file of the directory.
+// Without that file the key is chosen by its id: an id like 20261018T120000Z is the UTC time
+// the key starts signing at, and the latest started key signs. A single private key signs
+// whatever its id is.
+//
+// With a rotation interval the set generates such keys by itself, see KeyRotation.
+type KeySet struct {
+	dir      string
+	rotation KeyRotation
+	now      func() time.Time
+	mu       sync.RWMutex
+	signing  *jwtKey
+	keys     map[string]*jwtKey
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+// KeyRotation schedules new signing keys. A key is generated Grace before the current one
+// has signed for Interval, so every instance has published it by the time it starts signing.
+// A replaced key keeps verifying for Retention, which must outlive the access tokens.
+// Rotation is disabled when Interval is zero.
+type KeyRotation struct {
+	Interval  time.Duration
+	Grace     time.Duration
+	Retention time.Duration
+}
+
+func LoadKeySet(dir string, rotation KeyRotation) (*KeySet, error) {
+	ks := &KeySet{dir: dir, rotation: rotation, now: time.Now}
+
+	if err := ks.Rotate(); err != nil {
+		return nil, err
+	}
+
+	if err := ks.Reload(); err != nil {
+		return nil, err
+	}
+
+	return ks, nil
+}
+
+// Reload reads the directory again. The current keys are kept when it fails.
+func (ks *KeySet) Reload() error {
+	paths, err := filepath.Glob(filepath.Join(ks.dir, "*"+keyFileExtension))
+
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[string]*jwtKey, len(paths))
+	var private []*jwtKey
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+
+		if err != nil {
+			return err
+		}
+
+		key, err := parseJWTKey(strings.TrimSuffix(filepath.Base(path), keyFileExtension), data)
+
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		keys[key.id] = key
+
+		if key.private != nil {
+			private = append(private, key)
+		}
+	}
+
+	signing, err := ks.activeKey(keys, private, ks.now().UTC())
+
+	if err != nil {
+		return err
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	ks.keys = keys
+	ks.signing = signing
+
+	return nil
+}
+
+func (ks *KeySet) activeKey(keys map[string]*jwtKey, private []*jwtKey, now time.Time) (*jwtKey, error) {
+	data, err := os.ReadFile(filepath.Join(ks.dir, activeKeyFile))
+
+	if errors.Is(err, os.ErrNotExist) {
+		var latest *jwtKey
+		var latestStart time.Time
+
+		for _, key := range private {
+			start, err := time.Parse(scheduledKeyIDLayout, key.id)
+
+			if err != nil || start.After(now) {
+				continue
+			}
+
+			if latest == nil || start.After(latestStart) {
+				latest, latestStart = key, start
+			}
+		}
+
+		if latest != nil {
+			return latest, nil
+		}
+
+		switch len(private) {
+		case 0:
+			return nil, fmt.Errorf("%w in %s", ErrNoSigningKey, ks.dir)
+		case 1:
+			return private[0], nil
+		default:
+			return nil, fmt.Errorf("%w in %s", ErrNoActiveKey, ks.dir)
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	id := strings.TrimSpace(string(data))
+	key, ok := keys[id]
+
+	if !ok {
+		return nil, fmt.Errorf("active %w: %q", ErrKeyIsNotExist, id)
+	}
+
+	if key.private == nil {
+		return nil, fmt.Errorf("active key %q: %w", id, ErrNoSigningKey)
+	}
+
+	return key, nil
+}
+
+// Rotate generates the next signing key when it's due and removes the scheduled keys
+// that were replaced more than Retention ago. Files are created exclusively, so instances
+// sharing the directory don't overwrite each other's keys.
+func (ks *KeySet) Rotate() error {
+	if ks.rotation.Interval <= 0 {
+		return nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(ks.dir, "*"+keyFileExtension))
+
+	if err != nil {
+		return err
+	}
+
+	var starts []time.Time
+
+	for _, path := range paths {
+		if start, err := time.Parse(scheduledKeyIDLayout, strings.TrimSuffix(filepath.Base(path), keyFileExtension)); err == nil {
+			starts = append(starts, start)
+		}
+	}
+
+	sort.Slice(starts, func(i, j int) bool {
+		return starts[i].Before(starts[j])
+	})
+
+	now := ks.now().UTC()
+	var current time.Time
+	pending := false
+
+	for _, start := range starts {
+		if start.After(now) {
+			pending = true
+		} else {
+			current = start
+		}
+	}
+
+	if !pending {
+		var next time.Time
+
+		switch {
+		case len(paths) == 0:
+			// Nobody can hold tokens of an empty set, so the first key signs right away.
+			next = now
+		case current.IsZero():
+			next = now.Add(ks.rotation.Grace)
+		case !now.Before(current.Add(ks.rotation.Interval - ks.rotation.Grace)):
+			next = current.Add(ks.rotation.Interval)
+
+			if earliest := now.Add(ks.rotation.Grace); next.Before(earliest) {
+				next = earliest
+			}
+		}
+
+		if !next.IsZero() {
+			if err := ks.generateKey(next.Truncate(time.Second).Format(scheduledKeyIDLayout)); err != nil {
+				return err
+			}
+		}
+	}
+
+	for i := 0; i < len(starts)-1; i++ {
+		replacedAt := starts[i+1]
+
+		if replacedAt.After(now) || now.Sub(replacedAt) < ks.rotation.Retention {
+			continue
+		}
+
+		path := filepath.Join(ks.dir, starts[i].Format(scheduledKeyIDLayout)+keyFileExtension)
+
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		logger.Log.Info("retired jwt key", zap.String("kid", starts[i].Format(scheduledKeyIDLayout)))
+	}
+
+	return nil
+}
+
+// generateKey writes a new Ed25519 key. The file is written aside and linked into place,
+// so readers never see a partial key and an existing key with the same id is kept.
+func (ks *KeySet) generateKey(id string) error {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+
+	if err != nil {
+		return err
+	}
+
+	data, err := x509.MarshalPKCS8PrivateKey(private)
+
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(ks.dir, id+".*.tmp")
+
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name())
+
+	if err := pem.Encode(tmp, &pem.Block{Type: "PRIVATE KEY", Bytes: data}); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Link(tmp.Name(), filepath.Join(ks.dir, id+keyFileExtension)); err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return nil
+		}
+
+		return err
+	}
+
+	logger.Log.Info("generated jwt key", zap.String("kid", id))
+
+	return nil
+}
+
+func parseJWTKey(id string, data []byte) (*jwtKey, error) {
+	if key, err := jwt.ParseRSAPrivateKeyFromPEM(data); err == nil {
+		return &jwtKey{id: id, method: jwt.SigningMethodRS256, private: key, public: &key.PublicKey}, nil
+	}
+
+	if key, err := jwt.ParseEdPrivateKeyFromPEM(data); err == nil {
+		return &jwtKey{id: id, method: jwt.SigningMethodEdDSA, private: key, public: key.(ed25519.PrivateKey).Public()}, nil
+	}
+
+	if key, err := jwt.ParseRSAPublicKeyFromPEM(data); err == nil {
+		return &jwtKey{id: id, method: jwt.SigningMethodRS256, public: key}, nil
+	}
+
+	if key, err := jwt.ParseEdPublicKeyFromPEM(data); err == nil {
+		return &jwtKey{id: id, method: jwt.SigningMethodEdDSA, public: key}, nil
+	}
+
+	return nil, ErrKeyIsNotSupported
+}
+
+// StartReload rotates and reloads the keys with the interval, so new key files are picked up without a restart.
+func (ks *KeySet) StartReload(ctx context.Context, interval time.Duration) {
+	ctx, ks.cancel = context.WithCancel(ctx)
+
+	ks.wg.Add(1)
+
+	go func() {
+		defer ks.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := ks.Rotate(); err != nil {
+					logger.Log.Error("failed to rotate jwt keys", zap.String("dir", ks.dir), zap.Error(err))
+				}
+
+				if err := ks.Reload(); err != nil {
+					logger.Log.Error("failed to reload jwt keys", zap.String("dir", ks.dir), zap.Error(err))
+				}
+			}
+		}
+	}()
+}
+
+func (ks *KeySet) Shutdown() {
+	if ks.cancel != nil {
+		ks.cancel()
+	}
+
+	ks.wg.Wait()
+}
+
+func (ks *KeySet) SigningKey() (string, jwt.SigningMethod, interface{}, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	if ks.signing == nil {
+		return "", nil, nil, ErrNoSigningKey
+	}
+
+	return ks.signing.id, ks.signing.method, ks.signing.private, nil
+}
+
+func (ks *KeySet) VerificationKey(token *jwt.Token) (interface{}, error) {
+	id, _ := token.Header["kid"].(string)
+
+	ks.mu.RLock()
+	key, ok := ks.keys[id]
+	ks.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrKeyIsNotExist, id)
+	}
+
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+
+	return key.public, nil
+}
+
+func (ks *KeySet) JWKS() models.JWKS {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	result := models.JWKS{Keys: make([]models.JWK, 0, len(ks.keys))}
+
+	for _, key := range ks.keys {
+		jwk := models.JWK{KeyID: key.id, Use: "sig", Algorithm: key.method.Alg()}
+
+		switch public := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		}
+
+		result.Keys = append(result.Keys, jwk)
+	}
+
+	sort.Slice(result.Keys, func(i, j int) bool {
+		return result.Keys[i].KeyID < result.Keys[j].KeyID
+	})
+
+	return result
+}
@@ -0,0 +1,256 @@
+package services
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestKey(t *testing.T, dir, id string, key interface{}) {
+	data, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(
+		filepath.Join(dir, id+keyFileExtension),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: data}),
+		0o600,
+	))
+}
+
+func writeActiveKey(t *testing.T, dir, id string) {
+	require.NoError(t, os.WriteFile(filepath.Join(dir, activeKeyFile), []byte(id+"\n"), 0o600))
+}
+
+func TestKeySetRotation(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	writeTestKey(t, dir, "2026-01", rsaKey)
+
+	keySet, err := LoadKeySet(dir, KeyRotation{})
+	require.NoError(t, err)
+
+	storage := &fakeTokenRevocationStorage{revokedTokens: map[string]bool{}, revokedUsers: map[string]int64{}}
+	service := NewJWTService(keySet, time.Minute, NewTokenRevocationService(storage, time.Minute))
+
+	oldToken, err := service.GenerateJWT("user", models.RoleUser)
+	require.NoError(t, err)
+
+	token, err := service.ValidateToken(ctx, oldToken)
+	require.NoError(t, err)
+	assert.Equal(t, "2026-01", token.Header["kid"])
+	assert.Equal(t, jwt.SigningMethodRS256.Alg(), token.Method.Alg())
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	// The new key is published first and keeps verifying only until it's activated.
+	writeActiveKey(t, dir, "2026-01")
+	writeTestKey(t, dir, "2026-02", edKey)
+	require.NoError(t, keySet.Reload())
+
+	kid, _, _, err := keySet.SigningKey()
+	require.NoError(t, err)
+	assert.Equal(t, "2026-01", kid)
+
+	jwks := service.GetJWKS()
+	require.Len(t, jwks.Keys, 2)
+	assert.Equal(t, "RSA", jwks.Keys[0].KeyType)
+	assert.Equal(t, "AQAB", jwks.Keys[0].E)
+	assert.Equal(t, "OKP", jwks.Keys[1].KeyType)
+	assert.Equal(t, "Ed25519", jwks.Keys[1].Curve)
+
+	writeActiveKey(t, dir, "2026-02")
+	require.NoError(t, keySet.Reload())
+
+	newToken, err := service.GenerateJWT("user", models.RoleUser)
+	require.NoError(t, err)
+
+	token, err = service.ValidateToken(ctx, newToken)
+	require.NoError(t, err)
+	assert.Equal(t, "2026-02", token.Header["kid"])
+	assert.Equal(t, jwt.SigningMethodEdDSA.Alg(), token.Method.Alg())
+
+	_, err = service.ValidateToken(ctx, oldToken)
+	require.NoError(t, err)
+
+	require.NoError(t, os.Remove(filepath.Join(dir, "2026-01"+keyFileExtension)))
+	require.NoError(t, keySet.Reload())
+
+	_, err = service.ValidateToken(ctx, oldToken)
+	assert.ErrorIs(t, err, ErrKeyIsNotExist)
+}
+
+func TestKeySetRejectsForeignAlgorithm(t *testing.T) {
+	dir := t.TempDir()
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	writeTestKey(t, dir, "key", edKey)
+
+	keySet, err := LoadKeySet(dir, KeyRotation{})
+	require.NoError(t, err)
+
+	forged, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "user"}).SignedString([]byte("secret"))
+	require.NoError(t, err)
+
+	_, err = jwt.Parse(forged, func(token *jwt.Token) (interface{}, error) {
+		token.Header["kid"] = "key"
+		return keySet.VerificationKey(token)
+	})
+	assert.Error(t, err)
+
+	_, err = LoadKeySet(t.TempDir(), KeyRotation{})
+	assert.ErrorIs(t, err, ErrNoSigningKey)
+}
+
+func TestKeySetActiveKey(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, id := range []string{"9", "10"} {
+		_, edKey, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		writeTestKey(t, dir, id, edKey)
+	}
+
+	_, err := LoadKeySet(dir, KeyRotation{})
+	assert.ErrorIs(t, err, ErrNoActiveKey)
+
+	writeActiveKey(t, dir, "9")
+
+	keySet, err := LoadKeySet(dir, KeyRotation{})
+	require.NoError(t, err)
+
+	kid, _, _, err := keySet.SigningKey()
+	require.NoError(t, err)
+	assert.Equal(t, "9", kid)
+
+	// A broken marker keeps the keys that were loaded before.
+	writeActiveKey(t, dir, "11")
+	assert.ErrorIs(t, keySet.Reload(), ErrKeyIsNotExist)
+
+	require.NoError(t, os.Remove(filepath.Join(dir, activeKeyFile)))
+	assert.ErrorIs(t, keySet.Reload(), ErrNoActiveKey)
+
+	kid, _, _, err = keySet.SigningKey()
+	require.NoError(t, err)
+	assert.Equal(t, "9", kid)
+}
+
+func TestKeySetScheduledRotation(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	clock := &fakeClock{now: time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)}
+	rotation := KeyRotation{Interval: 24 * time.Hour, Grace: time.Hour, Retention: 2 * time.Hour}
+
+	// Two instances share the directory.
+	keySet := &KeySet{dir: dir, rotation: rotation, now: clock.Now}
+	replica := &KeySet{dir: dir, rotation: rotation, now: clock.Now}
+
+	rotate := func() {
+		require.NoError(t, keySet.Rotate())
+		require.NoError(t, replica.Rotate())
+		require.NoError(t, keySet.Reload())
+		require.NoError(t, replica.Reload())
+	}
+
+	signingKey := func() string {
+		kid, _, _, err := keySet.SigningKey()
+		require.NoError(t, err)
+
+		return kid
+	}
+
+	rotate()
+
+	assert.Equal(t, "20261018T120000Z", signingKey())
+	assert.Len(t, keySet.JWKS().Keys, 1)
+
+	storage := &fakeTokenRevocationStorage{revokedTokens: map[string]bool{}, revokedUsers: map[string]int64{}}
+	service := NewJWTService(keySet, time.Minute, NewTokenRevocationService(storage, time.Minute))
+
+	oldToken, err := service.GenerateJWT("user", models.RoleUser)
+	require.NoError(t, err)
+
+	clock.Advance(22 * time.Hour)
+	rotate()
+
+	assert.Len(t, keySet.JWKS().Keys, 1)
+
+	// The next key is published a grace period before it signs.
+	clock.Advance(time.Hour)
+	rotate()
+
+	assert.Equal(t, "20261018T120000Z", signingKey())
+	require.Len(t, keySet.JWKS().Keys, 2)
+	assert.Equal(t, "20261019T120000Z", replica.JWKS().Keys[1].KeyID)
+
+	clock.Advance(time.Hour)
+	rotate()
+
+	assert.Equal(t, "20261019T120000Z", signingKey())
+	assert.Len(t, keySet.JWKS().Keys, 2)
+
+	_, err = service.ValidateToken(ctx, oldToken)
+	require.NoError(t, err)
+
+	// The replaced key is retired once its tokens can't be alive anymore.
+	clock.Advance(2 * time.Hour)
+	rotate()
+
+	assert.Equal(t, "20261019T120000Z", signingKey())
+	assert.Len(t, keySet.JWKS().Keys, 1)
+
+	_, err = service.ValidateToken(ctx, oldToken)
+	assert.ErrorIs(t, err, ErrKeyIsNotExist)
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "20261019T120000Z"+keyFileExtension)}, paths)
+}
+
+func TestKeySetScheduledKeyWaitsForItsTime(t *testing.T) {
+	dir := t.TempDir()
+
+	_, current, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	_, next, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	writeTestKey(t, dir, "20261018T120000Z", current)
+	writeTestKey(t, dir, "20261019T120000Z", next)
+
+	clock := &fakeClock{now: time.Date(2026, 10, 19, 11, 59, 59, 0, time.UTC)}
+	keySet := &KeySet{dir: dir, now: clock.Now}
+
+	require.NoError(t, keySet.Reload())
+
+	kid, _, _, err := keySet.SigningKey()
+	require.NoError(t, err)
+	assert.Equal(t, "20261018T120000Z", kid)
+
+	clock.Advance(time.Second)
+	require.NoError(t, keySet.Reload())
+
+	kid, _, _, err = keySet.SigningKey()
+	require.NoError(t, err)
+	assert.Equal(t, "20261019T120000Z", kid)
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTokenRevocationStorage struct {
+	revokedTokens map[string]bool
+	revokedUsers  map[string]int64
+	checks        int
+}
+
+func (s *fakeTokenRevocationStorage) IsTokenRevoked(ctx context.Context, login, tokenID string, issuedAt int64) (bool, error) {
+	s.checks++
+
+	before, ok := s.revokedUsers[login]
+
+	return s.revokedTokens[tokenID] || (ok && before >= issuedAt), nil
+}
+
+func (s *fakeTokenRevocationStorage) RevokeToken(ctx context.Context, tokenID string, expiresAt int64) error {
+	s.revokedTokens[tokenID] = true
+
+	return nil
+}
+
+func (s *fakeTokenRevocationStorage) RevokeUserTokens(ctx context.Context, login string) error {
+	s.revokedUsers[login] = time.Now().Unix()
+
+	return nil
+}
+
+func TestJWTServiceRevokesToken(t *testing.T) {
+	ctx := context.Background()
+	storage := &fakeTokenRevocationStorage{revokedTokens: map[string]bool{}, revokedUsers: map[string]int64{}}
+	service := NewJWTService(NewSecretKeys("secret"), time.Minute, NewTokenRevocationService(storage, time.Minute))
+
+	first, err := service.GenerateJWT("user", models.RoleUser)
+	require.NoError(t, err)
+
+	second, err := service.GenerateJWT("user", models.RoleUser)
+	require.NoError(t, err)
+
+	token, err := service.ValidateToken(ctx, first)
+	require.NoError(t, err)
+
+	_, err = service.ValidateToken(ctx, first)
+	require.NoError(t, err)
+	assert.Equal(t, 1, storage.checks)
+
+	claims := token.Claims.(*jwt.RegisteredClaims)
+	assert.NotEmpty(t, claims.ID)
+
+	require.NoError(t, service.RevokeToken(ctx, token))
+
+	_, err = service.ValidateToken(ctx, first)
+	assert.ErrorIs(t, err, ErrTokenIsRevoked)
+
+	_, err = service.ValidateToken(ctx, second)
+	require.NoError(t, err)
+
+	require.NoError(t, service.RevokeAllTokens(ctx, "user"))
+
+	_, err = service.ValidateToken(ctx, second)
+	assert.ErrorIs(t, err, ErrTokenIsRevoked)
+}
+
+func TestJWTCarriesRole(t *testing.T) {
+	service := NewJWTService(NewSecretKeys("secret"), time.Minute, nil)
+
+	token, err := service.GenerateJWT("admin", models.RoleAdmin)
+	require.NoError(t, err)
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte("secret"), nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "admin", claims["role"])
+}
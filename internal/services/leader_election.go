@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/database"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/logger"
+	"go.uber.org/zap"
+)
+
+// AccrualLeaderLockKey is the advisory lock key that guards accrual polling.
+const AccrualLeaderLockKey int64 = 0x67_6d_61_63_63_72
+
+type LeaderElectionService struct {
+	tryLock  func(ctx context.Context, key int64) (leaderLock, error)
+	key      int64
+	interval time.Duration
+	lock     leaderLock
+	leader   int32
+	done     chan struct{}
+	wg       sync.WaitGroup
+}
+
+type leaderElectionStorage interface {
+	TryAdvisoryLock(ctx context.Context, key int64) (*database.AdvisoryLock, error)
+}
+
+// leaderLock is a held lock that is lost together with its connection.
+type leaderLock interface {
+	Ping(ctx context.Context) error
+
+	Release(ctx context.Context) error
+}
+
+func NewLeaderElectionService(storage leaderElectionStorage, key int64, interval time.Duration) *LeaderElectionService {
+	return newLeaderElectionService(func(ctx context.Context, key int64) (leaderLock, error) {
+		lock, err := storage.TryAdvisoryLock(ctx, key)
+
+		if lock == nil {
+			return nil, err
+		}
+
+		return lock, nil
+	}, key, interval)
+}
+
+func newLeaderElectionService(tryLock func(ctx context.Context, key int64) (leaderLock, error), key int64, interval time.Duration) *LeaderElectionService {
+	return &LeaderElectionService{
+		tryLock:  tryLock,
+		key:      key,
+		interval: interval,
+		done:     make(chan struct{}),
+	}
+}
+
+// Start tries to become the leader right away and then keeps checking the lock
+// in the background. onElected and onDemoted are called on every change of leadership.
+func (les *LeaderElectionService) Start(ctx context.Context, onElected func(ctx context.Context), onDemoted func()) {
+	les.elect(ctx, onElected, onDemoted)
+
+	les.wg.Add(1)
+
+	go func() {
+		defer les.wg.Done()
+
+		ticker := time.NewTicker(les.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-les.done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				les.elect(ctx, onElected, onDemoted)
+			}
+		}
+	}()
+}
+
+func (les *LeaderElectionService) elect(ctx context.Context, onElected func(ctx context.Context), onDemoted func()) {
+	if les.lock != nil {
+		if err := les.lock.Ping(ctx); err == nil {
+			return
+		}
+
+		logger.Log.Warn("lost leadership", zap.Int64("key", les.key))
+
+		if err := les.lock.Release(ctx); err != nil {
+			logger.Log.Error("failed to release leader lock", zap.Error(err))
+		}
+
+		les.lock = nil
+		atomic.StoreInt32(&les.leader, 0)
+		onDemoted()
+	}
+
+	lock, err := les.tryLock(ctx, les.key)
+
+	if err != nil {
+		logger.Log.Error("failed to acquire leader lock", zap.Error(err))
+		return
+	}
+
+	if lock == nil {
+		return
+	}
+
+	logger.Log.Info("became leader", zap.Int64("key", les.key))
+
+	les.lock = lock
+	atomic.StoreInt32(&les.leader, 1)
+	onElected(ctx)
+}
+
+func (les *LeaderElectionService) IsLeader() bool {
+	return atomic.LoadInt32(&les.leader) == 1
+}
+
+func (les *LeaderElectionService) Shutdown() {
+	close(les.done)
+	les.wg.Wait()
+
+	if les.lock != nil {
+		if err := les.lock.Release(context.Background()); err != nil {
+			logger.Log.Error("failed to release leader lock", zap.Error(err))
+		}
+
+		les.lock = nil
+		atomic.StoreInt32(&les.leader, 0)
+	}
+}
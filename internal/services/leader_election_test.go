@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeLockServer stands for Postgres: it grants the lock to one holder at a time
+// and frees it when the connection of the holder is lost.
+type fakeLockServer struct {
+	mu     sync.Mutex
+	holder *fakeLeaderLock
+}
+
+type fakeLeaderLock struct {
+	server   *fakeLockServer
+	lost     bool
+	released bool
+}
+
+func (s *fakeLockServer) tryLock(ctx context.Context, key int64) (leaderLock, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.holder != nil && !s.holder.lost && !s.holder.released {
+		return nil, nil
+	}
+
+	s.holder = &fakeLeaderLock{server: s}
+
+	return s.holder, nil
+}
+
+func (l *fakeLeaderLock) Ping(ctx context.Context) error {
+	l.server.mu.Lock()
+	defer l.server.mu.Unlock()
+
+	if l.lost {
+		return errors.New("connection is lost")
+	}
+
+	return nil
+}
+
+func (l *fakeLeaderLock) Release(ctx context.Context) error {
+	l.server.mu.Lock()
+	defer l.server.mu.Unlock()
+
+	l.released = true
+
+	return nil
+}
+
+type leadershipChanges struct {
+	elected int
+	demoted int
+}
+
+func (c *leadershipChanges) onElected(ctx context.Context) {
+	c.elected++
+}
+
+func (c *leadershipChanges) onDemoted() {
+	c.demoted++
+}
+
+func TestLeaderElectionAcquiresLock(t *testing.T) {
+	ctx := context.Background()
+	server := &fakeLockServer{}
+	service := newLeaderElectionService(server.tryLock, AccrualLeaderLockKey, 0)
+	changes := &leadershipChanges{}
+
+	service.elect(ctx, changes.onElected, changes.onDemoted)
+
+	assert.True(t, service.IsLeader())
+	assert.Equal(t, leadershipChanges{elected: 1}, *changes)
+
+	service.elect(ctx, changes.onElected, changes.onDemoted)
+
+	assert.True(t, service.IsLeader())
+	assert.Equal(t, leadershipChanges{elected: 1}, *changes)
+}
+
+func TestLeaderElectionKeepsFollowerWhenLockIsHeld(t *testing.T) {
+	ctx := context.Background()
+	server := &fakeLockServer{}
+	leader := newLeaderElectionService(server.tryLock, AccrualLeaderLockKey, 0)
+	follower := newLeaderElectionService(server.tryLock, AccrualLeaderLockKey, 0)
+	changes := &leadershipChanges{}
+
+	leader.elect(ctx, func(ctx context.Context) {}, func() {})
+	follower.elect(ctx, changes.onElected, changes.onDemoted)
+
+	assert.True(t, leader.IsLeader())
+	assert.False(t, follower.IsLeader())
+	assert.Equal(t, leadershipChanges{}, *changes)
+}
+
+func TestLeaderElectionLosesLock(t *testing.T) {
+	ctx := context.Background()
+	server := &fakeLockServer{}
+	service := newLeaderElectionService(server.tryLock, AccrualLeaderLockKey, 0)
+	changes := &leadershipChanges{}
+
+	service.elect(ctx, changes.onElected, changes.onDemoted)
+
+	lock := server.holder
+	lock.lost = true
+
+	// The lost lock is released and, as nobody else holds it, acquired again on a new connection.
+	service.elect(ctx, changes.onElected, changes.onDemoted)
+
+	assert.True(t, lock.released)
+	assert.True(t, service.IsLeader())
+	assert.NotSame(t, lock, server.holder)
+	assert.Equal(t, leadershipChanges{elected: 2, demoted: 1}, *changes)
+}
+
+func TestLeaderElectionFailsOver(t *testing.T) {
+	ctx := context.Background()
+	server := &fakeLockServer{}
+	first := newLeaderElectionService(server.tryLock, AccrualLeaderLockKey, 0)
+	second := newLeaderElectionService(server.tryLock, AccrualLeaderLockKey, 0)
+	firstChanges := &leadershipChanges{}
+	secondChanges := &leadershipChanges{}
+
+	first.elect(ctx, firstChanges.onElected, firstChanges.onDemoted)
+	second.elect(ctx, secondChanges.onElected, secondChanges.onDemoted)
+
+	assert.True(t, first.IsLeader())
+	assert.False(t, second.IsLeader())
+
+	server.holder.lost = true
+
+	second.elect(ctx, secondChanges.onElected, secondChanges.onDemoted)
+	first.elect(ctx, firstChanges.onElected, firstChanges.onDemoted)
+
+	assert.False(t, first.IsLeader())
+	assert.True(t, second.IsLeader())
+	assert.Equal(t, leadershipChanges{elected: 1, demoted: 1}, *firstChanges)
+	assert.Equal(t, leadershipChanges{elected: 1}, *secondChanges)
+}
+
+func TestLeaderElectionReleasesLockOnShutdown(t *testing.T) {
+	ctx := context.Background()
+	server := &fakeLockServer{}
+	first := newLeaderElectionService(server.tryLock, AccrualLeaderLockKey, 0)
+	second := newLeaderElectionService(server.tryLock, AccrualLeaderLockKey, 0)
+
+	first.elect(ctx, func(ctx context.Context) {}, func() {})
+	first.Shutdown()
+
+	assert.False(t, first.IsLeader())
+
+	second.elect(ctx, func(ctx context.Context) {}, func() {})
+
+	assert.True(t, second.IsLeader())
+}
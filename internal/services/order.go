@@ -3,8 +3,6 @@ package services
 import (
 	"context"
 	"errors"
-	"sort"
-	"strconv"
 
 	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/database"
 	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
@@ -25,7 +23,11 @@ type orderStorage interface {
 
 	FindOrder(ctx context.Context, orderID string) (*database.OrderDB, error)
 
-	FindOrdersWithAccrual(ctx context.Context, userID string) (*[]database.OrderWithAccrualDB, error)
+	FindOrderWithOwner(ctx context.Context, orderID string) (*database.OrderWithOwnerDB, error)
+
+	FindOrdersWithAccrual(ctx context.Context, userID string, filter models.ListFilter) (*[]database.OrderWithAccrualDB, error)
+
+	FindOrderStatusHistory(ctx context.Context, orderID string) (*[]database.OrderStatusChangeDB, error)
 }
 
 func NewOrderService(storage orderStorage) *OrderService {
@@ -33,28 +35,7 @@ func NewOrderService(storage orderStorage) *OrderService {
 }
 
 func (o *OrderService) VerifyOrderID(orderID string) bool {
-	var sum int
-	var alternate bool
-
-	for i := len(orderID) - 1; i >= 0; i-- {
-		digit, err := strconv.Atoi(string(orderID[i]))
-		if err != nil {
-			return false
-		}
-
-		if alternate {
-			digit *= 2
-
-			if digit > 9 {
-				digit -= 9
-			}
-		}
-
-		sum += digit
-		alternate = !alternate
-	}
-
-	return sum%10 == 0
+	return utils.IsLuhnValid(orderID)
 }
 
 func (o *OrderService) CreateOrder(ctx context.Context, orderID, userID string) error {
@@ -79,15 +60,23 @@ func (o *OrderService) CreateOrder(ctx context.Context, orderID, userID string)
 	return nil
 }
 
-func (o *OrderService) GetOrders(ctx context.Context, userID string) ([]models.Order, error) {
-	orders, err := o.storage.FindOrdersWithAccrual(ctx, userID)
+// GetOrders returns a page of user orders sorted in SQL. The cursor of the next page is nil
+// when there are no more orders or the filter has no limit.
+func (o *OrderService) GetOrders(ctx context.Context, userID string, filter models.ListFilter) ([]models.Order, *models.Cursor, error) {
+	query := filter
+
+	if filter.Limit > 0 {
+		query.Limit = filter.Limit + 1
+	}
+
+	orders, err := o.storage.FindOrdersWithAccrual(ctx, userID, query)
 
 	if err != nil {
-		return []models.Order{}, err
+		return []models.Order{}, nil, err
 	}
 
 	if orders == nil {
-		return []models.Order{}, nil
+		return []models.Order{}, nil, nil
 	}
 
 	result := make([]models.Order, len(*orders))
@@ -102,9 +91,86 @@ func (o *OrderService) GetOrders(ctx context.Context, userID string) ([]models.O
 		}
 	}
 
-	sort.Slice(result, func(i, j int) bool {
-		return result[i].UploadedAt.Time.Before(result[j].UploadedAt.Time)
-	})
+	if filter.Limit > 0 && len(result) > filter.Limit {
+		result = result[:filter.Limit]
+		last := result[len(result)-1]
+
+		return result, &models.Cursor{Time: last.UploadedAt.Time, ID: last.ID}, nil
+	}
+
+	return result, nil, nil
+}
+
+// GetOrderDetails returns the order of any user with its owner and status history, e.g. for support.
+func (o *OrderService) GetOrderDetails(ctx context.Context, orderID string) (models.OrderDetails, error) {
+	order, err := o.storage.FindOrderWithOwner(ctx, orderID)
+
+	if err != nil {
+		return models.OrderDetails{}, err
+	}
+
+	if order == nil {
+		return models.OrderDetails{}, ErrOrderIsNotExist
+	}
+
+	history, err := o.GetOrderHistory(ctx, orderID, order.UserID)
+
+	if err != nil {
+		return models.OrderDetails{}, err
+	}
+
+	accrual := order.Accrual
+
+	return models.OrderDetails{
+		Order: models.Order{
+			ID:         order.ID,
+			Status:     order.Status.OrderStatus,
+			UploadedAt: utils.RFC3339Date{Time: order.UploadedAt},
+			Accrual:    &accrual,
+		},
+		UserID:  order.UserID,
+		Login:   order.Login,
+		History: history,
+	}, nil
+}
+
+// GetOrderHistory returns status transitions of the order in chronological order.
+// Orders of other users are reported as not existing.
+func (o *OrderService) GetOrderHistory(ctx context.Context, orderID, userID string) ([]models.OrderStatusChange, error) {
+	order, err := o.storage.FindOrder(ctx, orderID)
+
+	if err != nil {
+		return []models.OrderStatusChange{}, err
+	}
+
+	if order == nil || order.UserID != userID {
+		return []models.OrderStatusChange{}, ErrOrderIsNotExist
+	}
+
+	history, err := o.storage.FindOrderStatusHistory(ctx, orderID)
+
+	if err != nil {
+		return []models.OrderStatusChange{}, err
+	}
+
+	if history == nil {
+		return []models.OrderStatusChange{}, nil
+	}
+
+	result := make([]models.OrderStatusChange, len(*history))
+
+	for i, item := range *history {
+		result[i] = models.OrderStatusChange{
+			To:        item.To.OrderStatus,
+			Source:    item.Source,
+			ChangedAt: utils.RFC3339Date{Time: item.CreatedAt},
+		}
+
+		if item.From != nil {
+			from := item.From.OrderStatus
+			result[i].From = &from
+		}
+	}
 
 	return result, nil
 }
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket limiter whose rate can be adjusted at runtime.
+// A zero rate means that requests are not limited until a limit is learned.
+type RateLimiter struct {
+	mu           sync.Mutex
+	rate         float64
+	burst        float64
+	tokens       float64
+	last         time.Time
+	blockedUntil time.Time
+	now          func() time.Time
+}
+
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &RateLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+		now:    time.Now,
+	}
+}
+
+func (rl *RateLimiter) Wait(ctx context.Context) error {
+	for {
+		delay := rl.reserve()
+
+		if delay <= 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(delay)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (rl *RateLimiter) reserve() time.Duration {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := rl.now()
+
+	if now.Before(rl.blockedUntil) {
+		return rl.blockedUntil.Sub(now)
+	}
+
+	if rl.rate <= 0 {
+		return 0
+	}
+
+	if elapsed := now.Sub(rl.last); elapsed > 0 {
+		rl.tokens += elapsed.Seconds() * rl.rate
+
+		if rl.tokens > rl.burst {
+			rl.tokens = rl.burst
+		}
+
+		rl.last = now
+	}
+
+	if rl.tokens >= 1 {
+		rl.tokens--
+		return 0
+	}
+
+	return time.Duration((1 - rl.tokens) / rl.rate * float64(time.Second))
+}
+
+// SetRate changes the number of allowed requests per second.
+func (rl *RateLimiter) SetRate(rate float64) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	rl.rate = rate
+
+	if rl.tokens > rl.burst {
+		rl.tokens = rl.burst
+	}
+}
+
+func (rl *RateLimiter) Rate() float64 {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	return rl.rate
+}
+
+// BlockFor rejects all requests for the given duration and drains the bucket.
+func (rl *RateLimiter) BlockFor(delay time.Duration) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	until := rl.now().Add(delay)
+
+	if until.After(rl.blockedUntil) {
+		rl.blockedUntil = until
+	}
+
+	rl.tokens = 0
+	rl.last = rl.blockedUntil
+}
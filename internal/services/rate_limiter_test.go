@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRateLimiter(rate float64, burst int) (*RateLimiter, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	rl := NewRateLimiter(rate, burst)
+	rl.now = clock.Now
+	rl.last = clock.Now()
+
+	return rl, clock
+}
+
+func TestRateLimiterReserve(t *testing.T) {
+	type step struct {
+		advance  time.Duration
+		call     func(rl *RateLimiter)
+		expected time.Duration
+	}
+
+	testCases := []struct {
+		testName string
+		rate     float64
+		burst    int
+		steps    []step
+	}{
+		{
+			testName: "Should not limit without rate",
+			rate:     0,
+			burst:    1,
+			steps:    []step{{expected: 0}, {expected: 0}, {expected: 0}},
+		},
+		{
+			testName: "Should refill with rate",
+			rate:     2,
+			burst:    1,
+			steps: []step{
+				{expected: 0},
+				{expected: 500 * time.Millisecond},
+				{advance: 250 * time.Millisecond, expected: 250 * time.Millisecond},
+				{advance: 250 * time.Millisecond, expected: 0},
+				{expected: 500 * time.Millisecond},
+			},
+		},
+		{
+			testName: "Should cap tokens with burst",
+			rate:     1,
+			burst:    3,
+			steps: []step{
+				{advance: time.Minute, expected: 0},
+				{expected: 0},
+				{expected: 0},
+				{expected: time.Second},
+			},
+		},
+		{
+			testName: "Should limit with learned rate",
+			rate:     0,
+			burst:    1,
+			steps: []step{
+				{expected: 0},
+				{call: func(rl *RateLimiter) { rl.SetRate(4) }, expected: 0},
+				{expected: 250 * time.Millisecond},
+			},
+		},
+		{
+			testName: "Should block over learned rate",
+			rate:     10,
+			burst:    5,
+			steps: []step{
+				{call: func(rl *RateLimiter) { rl.BlockFor(2 * time.Second) }, expected: 2 * time.Second},
+				{advance: time.Second, expected: time.Second},
+				// A shorter block doesn't cut the current one short.
+				{call: func(rl *RateLimiter) { rl.BlockFor(500 * time.Millisecond) }, expected: time.Second},
+				// The bucket is drained, so requests resume at the learned rate instead of a burst.
+				{advance: time.Second, expected: 100 * time.Millisecond},
+				{advance: 100 * time.Millisecond, expected: 0},
+				{expected: 100 * time.Millisecond},
+			},
+		},
+		{
+			testName: "Should block without learned rate",
+			rate:     0,
+			burst:    1,
+			steps: []step{
+				{call: func(rl *RateLimiter) { rl.BlockFor(time.Second) }, expected: time.Second},
+				{advance: time.Second, expected: 0},
+				{expected: 0},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			rl, clock := newTestRateLimiter(tc.rate, tc.burst)
+
+			for i, s := range tc.steps {
+				clock.Advance(s.advance)
+
+				if s.call != nil {
+					s.call(rl)
+				}
+
+				assert.Equal(t, s.expected, rl.reserve(), "step %d", i)
+			}
+		})
+	}
+}
+
+func TestRateLimiterWaitIsCanceled(t *testing.T) {
+	rl, _ := newTestRateLimiter(1, 1)
+	rl.BlockFor(time.Hour)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, rl.Wait(ctx), context.DeadlineExceeded)
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/database"
+)
+
+var (
+	ErrRefreshTokenIsInvalid = errors.New("refresh token is invalid")
+	ErrRefreshTokenIsExpired = errors.New("refresh token is expired")
+	ErrRefreshTokenIsReused  = errors.New("refresh token is reused")
+)
+
+const refreshTokenLength = 32
+
+// RefreshTokenService issues opaque refresh tokens. Only their hashes are stored, and every token
+// can be exchanged once: the successor belongs to the same family, which is revoked on reuse.
+type RefreshTokenService struct {
+	storage refreshTokenStorage
+	ttl     time.Duration
+}
+
+type refreshTokenStorage interface {
+	CreateRefreshToken(ctx context.Context, login, tokenHash string, ttl time.Duration) error
+
+	RotateRefreshToken(ctx context.Context, tokenHash, newTokenHash string, ttl time.Duration) (string, error)
+
+	RevokeRefreshTokenFamily(ctx context.Context, login, tokenHash string) error
+
+	RevokeUserRefreshTokens(ctx context.Context, login string) error
+}
+
+func NewRefreshTokenService(storage refreshTokenStorage, ttl time.Duration) *RefreshTokenService {
+	return &RefreshTokenService{storage: storage, ttl: ttl}
+}
+
+func generateRefreshToken() (string, error) {
+	b := make([]byte, refreshTokenLength)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// IssueRefreshToken starts a new token family, e.g. on login.
+func (rts *RefreshTokenService) IssueRefreshToken(ctx context.Context, login string) (string, error) {
+	token, err := generateRefreshToken()
+
+	if err != nil {
+		return "", err
+	}
+
+	if err := rts.storage.CreateRefreshToken(ctx, login, hashRefreshToken(token), rts.ttl); err != nil {
+		if errors.Is(err, database.ErrUserIsNotExist) {
+			return "", ErrUserIsNotExist
+		}
+
+		return "", err
+	}
+
+	return token, nil
+}
+
+// RotateRefreshToken exchanges the refresh token for a new one and returns the login of its owner.
+func (rts *RefreshTokenService) RotateRefreshToken(ctx context.Context, refreshToken string) (string, string, error) {
+	token, err := generateRefreshToken()
+
+	if err != nil {
+		return "", "", err
+	}
+
+	login, err := rts.storage.RotateRefreshToken(ctx, hashRefreshToken(refreshToken), hashRefreshToken(token), rts.ttl)
+
+	if err != nil {
+		if errors.Is(err, database.ErrRefreshTokenIsNotExist) {
+			return "", "", ErrRefreshTokenIsInvalid
+		}
+
+		if errors.Is(err, database.ErrRefreshTokenIsExpired) {
+			return "", "", ErrRefreshTokenIsExpired
+		}
+
+		if errors.Is(err, database.ErrRefreshTokenIsReused) {
+			return "", "", ErrRefreshTokenIsReused
+		}
+
+		return "", "", err
+	}
+
+	return login, token, nil
+}
+
+// RevokeRefreshToken revokes the family of the user's refresh token, e.g. on logout.
+func (rts *RefreshTokenService) RevokeRefreshToken(ctx context.Context, login, refreshToken string) error {
+	return rts.storage.RevokeRefreshTokenFamily(ctx, login, hashRefreshToken(refreshToken))
+}
+
+func (rts *RefreshTokenService) RevokeAllRefreshTokens(ctx context.Context, login string) error {
+	return rts.storage.RevokeUserRefreshTokens(ctx, login)
+}
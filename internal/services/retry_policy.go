@@ -0,0 +1,54 @@
+package services
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+type RetryPolicy struct {
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+	Multiplier      float64
+	Jitter          float64
+	MaxAttempts     int
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		InitialInterval: time.Second,
+		MaxInterval:     10 * time.Minute,
+		Multiplier:      2,
+		Jitter:          0.2,
+		MaxAttempts:     10,
+	}
+}
+
+// Delay returns the backoff before the next try after the given number of failed attempts.
+// It never exceeds MaxInterval.
+func (p RetryPolicy) Delay(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	delay := float64(p.InitialInterval) * math.Pow(p.Multiplier, float64(attempts-1))
+
+	if delay > float64(p.MaxInterval) {
+		delay = float64(p.MaxInterval)
+	}
+
+	if p.Jitter > 0 {
+		delay += delay * p.Jitter * (2*rand.Float64() - 1)
+	}
+
+	// The jitter must not push the delay over the cap either.
+	if delay > float64(p.MaxInterval) {
+		delay = float64(p.MaxInterval)
+	}
+
+	return time.Duration(delay)
+}
+
+func (p RetryPolicy) IsExhausted(attempts int) bool {
+	return p.MaxAttempts > 0 && attempts >= p.MaxAttempts
+}
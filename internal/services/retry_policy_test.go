@@ -0,0 +1,85 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := RetryPolicy{InitialInterval: time.Second, MaxInterval: 10 * time.Second, Multiplier: 2}
+
+	testCases := []struct {
+		testName string
+		attempts int
+		expected time.Duration
+	}{
+		{testName: "Should treat no attempts as first attempt", attempts: 0, expected: time.Second},
+		{testName: "Should start with initial interval", attempts: 1, expected: time.Second},
+		{testName: "Should grow with multiplier", attempts: 2, expected: 2 * time.Second},
+		{testName: "Should keep growing", attempts: 4, expected: 8 * time.Second},
+		{testName: "Should be capped by max interval", attempts: 5, expected: 10 * time.Second},
+		{testName: "Should stay capped for many attempts", attempts: 1000, expected: 10 * time.Second},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			assert.Equal(t, tc.expected, policy.Delay(tc.attempts))
+		})
+	}
+}
+
+func TestRetryPolicyDelayJitter(t *testing.T) {
+	policy := RetryPolicy{InitialInterval: time.Second, MaxInterval: 10 * time.Second, Multiplier: 2, Jitter: 0.2}
+
+	testCases := []struct {
+		testName string
+		attempts int
+		min      time.Duration
+		max      time.Duration
+	}{
+		{testName: "Should jitter initial interval", attempts: 1, min: 800 * time.Millisecond, max: 1200 * time.Millisecond},
+		{testName: "Should jitter grown interval", attempts: 3, min: 3200 * time.Millisecond, max: 4800 * time.Millisecond},
+		{testName: "Should keep jittered interval under cap", attempts: 10, min: 8 * time.Second, max: 10 * time.Second},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			seen := make(map[time.Duration]bool)
+
+			for i := 0; i < 1000; i++ {
+				delay := policy.Delay(tc.attempts)
+
+				assert.GreaterOrEqual(t, delay, tc.min)
+				assert.LessOrEqual(t, delay, tc.max)
+
+				seen[delay] = true
+			}
+
+			assert.Greater(t, len(seen), 1, "delay has to be randomized")
+		})
+	}
+}
+
+func TestRetryPolicyIsExhausted(t *testing.T) {
+	testCases := []struct {
+		testName    string
+		maxAttempts int
+		attempts    int
+		expected    bool
+	}{
+		{testName: "Should retry before max attempts", maxAttempts: 3, attempts: 2, expected: false},
+		{testName: "Should give up at max attempts", maxAttempts: 3, attempts: 3, expected: true},
+		{testName: "Should give up after max attempts", maxAttempts: 3, attempts: 4, expected: true},
+		{testName: "Should retry forever without max attempts", maxAttempts: 0, attempts: 1000, expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			policy := RetryPolicy{InitialInterval: time.Second, MaxInterval: time.Minute, Multiplier: 2, MaxAttempts: tc.maxAttempts}
+
+			assert.Equal(t, tc.expected, policy.IsExhausted(tc.attempts))
+		})
+	}
+}
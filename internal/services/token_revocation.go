@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const tokenRevocationCacheSize = 10000
+
+// TokenRevocationService stores revoked access tokens in the database and caches the checks in memory.
+// A revocation made by another instance is seen here once the cached result expires.
+type TokenRevocationService struct {
+	storage  tokenRevocationStorage
+	cacheTTL time.Duration
+	mu       sync.Mutex
+	cache    map[string]tokenRevocationCacheEntry
+}
+
+type tokenRevocationCacheEntry struct {
+	revoked   bool
+	expiresAt time.Time
+}
+
+type tokenRevocationStorage interface {
+	IsTokenRevoked(ctx context.Context, login, tokenID string, issuedAt int64) (bool, error)
+
+	RevokeToken(ctx context.Context, tokenID string, expiresAt int64) error
+
+	RevokeUserTokens(ctx context.Context, login string) error
+}
+
+func NewTokenRevocationService(storage tokenRevocationStorage, cacheTTL time.Duration) *TokenRevocationService {
+	return &TokenRevocationService{
+		storage:  storage,
+		cacheTTL: cacheTTL,
+		cache:    make(map[string]tokenRevocationCacheEntry),
+	}
+}
+
+func tokenRevocationCacheKey(login, tokenID string, issuedAt time.Time) string {
+	return login + ":" + tokenID + ":" + strconv.FormatInt(issuedAt.Unix(), 10)
+}
+
+// IsRevoked reports whether the token is revoked by itself or by revoking all tokens of the user.
+// Tokens issued within the same second as revoking all of them stay valid.
+func (trs *TokenRevocationService) IsRevoked(ctx context.Context, login, tokenID string, issuedAt time.Time) (bool, error) {
+	key := tokenRevocationCacheKey(login, tokenID, issuedAt)
+
+	trs.mu.Lock()
+	entry, ok := trs.cache[key]
+	trs.mu.Unlock()
+
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.revoked, nil
+	}
+
+	revoked, err := trs.storage.IsTokenRevoked(ctx, login, tokenID, issuedAt.Unix())
+
+	if err != nil {
+		return false, err
+	}
+
+	trs.remember(key, revoked)
+
+	return revoked, nil
+}
+
+func (trs *TokenRevocationService) remember(key string, revoked bool) {
+	trs.mu.Lock()
+	defer trs.mu.Unlock()
+
+	now := time.Now()
+
+	if len(trs.cache) >= tokenRevocationCacheSize {
+		for k, entry := range trs.cache {
+			if !now.Before(entry.expiresAt) {
+				delete(trs.cache, k)
+			}
+		}
+
+		if len(trs.cache) >= tokenRevocationCacheSize {
+			trs.cache = make(map[string]tokenRevocationCacheEntry)
+		}
+	}
+
+	trs.cache[key] = tokenRevocationCacheEntry{revoked: revoked, expiresAt: now.Add(trs.cacheTTL)}
+}
+
+func (trs *TokenRevocationService) Revoke(ctx context.Context, login, tokenID string, issuedAt, expiresAt time.Time) error {
+	if err := trs.storage.RevokeToken(ctx, tokenID, expiresAt.Unix()); err != nil {
+		return err
+	}
+
+	trs.remember(tokenRevocationCacheKey(login, tokenID, issuedAt), true)
+
+	return nil
+}
+
+func (trs *TokenRevocationService) RevokeAll(ctx context.Context, login string) error {
+	if err := trs.storage.RevokeUserTokens(ctx, login); err != nil {
+		return err
+	}
+
+	trs.mu.Lock()
+	defer trs.mu.Unlock()
+
+	for key := range trs.cache {
+		if strings.HasPrefix(key, login+":") {
+			delete(trs.cache, key)
+		}
+	}
+
+	return nil
+}
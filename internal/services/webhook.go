@@ -0,0 +1,432 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/database"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/logger"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/utils"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrWebhookURLIsInvalid     = errors.New("webhook url is invalid")
+	ErrWebhookEventsAreInvalid = errors.New("webhook events are invalid")
+	ErrWebhookIsNotExist       = errors.New("webhook is not exist")
+	ErrWebhookHostIsNotPublic  = errors.New("webhook host is not public")
+)
+
+const (
+	WebhookSignatureHeader          = "X-Webhook-Signature"
+	WebhookSignatureTimestampHeader = "X-Webhook-Timestamp"
+	WebhookEventHeader              = "X-Webhook-Event"
+	WebhookDeliveryHeader           = "X-Webhook-Delivery"
+)
+
+const (
+	webhookSecretLength     = 32
+	webhookDeliveriesLimit  = 100
+	webhookDeliveryLease    = time.Minute
+	webhookDeliveryTimeout  = 10 * time.Second
+	webhookMaxErrorBodySize = 512
+)
+
+// WebhookService manages user webhooks and delivers the events from the outbox.
+// Deliveries are claimed with a lease, so several instances can run it at once.
+type WebhookService struct {
+	storage      webhookStorage
+	client       *http.Client
+	retryPolicy  RetryPolicy
+	pollInterval time.Duration
+	cancel       context.CancelFunc
+	wg           sync.WaitGroup
+}
+
+type webhookStorage interface {
+	CreateWebhook(ctx context.Context, userID, url, secret string, events []string) (*database.WebhookDB, error)
+
+	FindWebhooks(ctx context.Context, userID string) (*[]database.WebhookDB, error)
+
+	FindWebhook(ctx context.Context, webhookID string) (*database.WebhookDB, error)
+
+	DeleteWebhook(ctx context.Context, webhookID, userID string) (bool, error)
+
+	FindWebhookDeliveries(ctx context.Context, webhookID string, limit int) (*[]database.WebhookDeliveryDB, error)
+
+	ClaimWebhookDelivery(ctx context.Context, lease time.Duration) (*database.ClaimedWebhookDeliveryDB, error)
+
+	UpdateWebhookDelivery(
+		ctx context.Context,
+		deliveryID string,
+		status models.WebhookDeliveryStatus,
+		responseCode *int,
+		lastError *string,
+		retryDelay time.Duration,
+	) error
+}
+
+func NewWebhookService(storage webhookStorage, retryPolicy RetryPolicy, pollInterval time.Duration) *WebhookService {
+	return &WebhookService{
+		storage:      storage,
+		client:       newWebhookClient(),
+		retryPolicy:  retryPolicy,
+		pollInterval: pollInterval,
+	}
+}
+
+// newWebhookClient returns a client that connects to public addresses only. The address is
+// checked right before connecting, so a host that resolves to an internal address after
+// the webhook was registered is refused as well. Redirects aren't followed.
+func newWebhookClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: webhookDeliveryTimeout,
+		Control: func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+
+			if err != nil {
+				return err
+			}
+
+			if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+				return fmt.Errorf("%w: %s", ErrWebhookHostIsNotPublic, host)
+			}
+
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   webhookDeliveryTimeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func isPublicIP(ip net.IP) bool {
+	return !ip.IsLoopback() &&
+		!ip.IsPrivate() &&
+		!ip.IsUnspecified() &&
+		!ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() &&
+		!ip.IsMulticast()
+}
+
+// checkWebhookHost refuses hosts that are or resolve to internal addresses.
+func checkWebhookHost(ctx context.Context, host string) error {
+	if ip := net.ParseIP(host); ip != nil {
+		if !isPublicIP(ip) {
+			return fmt.Errorf("%w: %w: %s", ErrWebhookURLIsInvalid, ErrWebhookHostIsNotPublic, host)
+		}
+
+		return nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrWebhookURLIsInvalid, err)
+	}
+
+	for _, addr := range addrs {
+		if !isPublicIP(addr.IP) {
+			return fmt.Errorf("%w: %w: %s", ErrWebhookURLIsInvalid, ErrWebhookHostIsNotPublic, host)
+		}
+	}
+
+	return nil
+}
+
+func (ws *WebhookService) CreateWebhook(ctx context.Context, userID, webhookURL string, events []models.WebhookEvent) (models.Webhook, error) {
+	parsed, err := url.Parse(webhookURL)
+
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return models.Webhook{}, ErrWebhookURLIsInvalid
+	}
+
+	if err := checkWebhookHost(ctx, parsed.Hostname()); err != nil {
+		return models.Webhook{}, err
+	}
+
+	if len(events) == 0 {
+		return models.Webhook{}, ErrWebhookEventsAreInvalid
+	}
+
+	var filter []string
+	seen := make(map[models.WebhookEvent]bool)
+
+	for _, event := range events {
+		if !event.IsValid() {
+			return models.Webhook{}, fmt.Errorf("%w: unknown event %q", ErrWebhookEventsAreInvalid, event)
+		}
+
+		if !seen[event] {
+			seen[event] = true
+			filter = append(filter, string(event))
+		}
+	}
+
+	secret := make([]byte, webhookSecretLength)
+
+	if _, err := rand.Read(secret); err != nil {
+		return models.Webhook{}, err
+	}
+
+	webhook, err := ws.storage.CreateWebhook(ctx, userID, webhookURL, hex.EncodeToString(secret), filter)
+
+	if err != nil {
+		return models.Webhook{}, err
+	}
+
+	result := toWebhook(*webhook)
+	result.Secret = webhook.Secret
+
+	return result, nil
+}
+
+func (ws *WebhookService) GetWebhooks(ctx context.Context, userID string) ([]models.Webhook, error) {
+	webhooks, err := ws.storage.FindWebhooks(ctx, userID)
+
+	if err != nil {
+		return []models.Webhook{}, err
+	}
+
+	if webhooks == nil {
+		return []models.Webhook{}, nil
+	}
+
+	result := make([]models.Webhook, len(*webhooks))
+
+	for i, webhook := range *webhooks {
+		result[i] = toWebhook(webhook)
+	}
+
+	return result, nil
+}
+
+func (ws *WebhookService) DeleteWebhook(ctx context.Context, webhookID, userID string) error {
+	ok, err := ws.storage.DeleteWebhook(ctx, webhookID, userID)
+
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return ErrWebhookIsNotExist
+	}
+
+	return nil
+}
+
+// GetWebhookDeliveries returns the latest deliveries of the user's webhook, newest first.
+func (ws *WebhookService) GetWebhookDeliveries(ctx context.Context, webhookID, userID string) ([]models.WebhookDelivery, error) {
+	webhook, err := ws.storage.FindWebhook(ctx, webhookID)
+
+	if err != nil {
+		return []models.WebhookDelivery{}, err
+	}
+
+	if webhook == nil || webhook.UserID != userID {
+		return []models.WebhookDelivery{}, ErrWebhookIsNotExist
+	}
+
+	deliveries, err := ws.storage.FindWebhookDeliveries(ctx, webhookID, webhookDeliveriesLimit)
+
+	if err != nil {
+		return []models.WebhookDelivery{}, err
+	}
+
+	if deliveries == nil {
+		return []models.WebhookDelivery{}, nil
+	}
+
+	result := make([]models.WebhookDelivery, len(*deliveries))
+
+	for i, item := range *deliveries {
+		result[i] = models.WebhookDelivery{
+			ID:           item.ID,
+			Event:        models.WebhookEvent(item.Event),
+			Status:       models.WebhookDeliveryStatus(item.Status),
+			Attempts:     item.Attempts,
+			ResponseCode: item.ResponseCode,
+			LastError:    item.LastError,
+			CreatedAt:    utils.RFC3339Date{Time: item.CreatedAt},
+		}
+
+		if item.DeliveredAt != nil {
+			result[i].DeliveredAt = &utils.RFC3339Date{Time: *item.DeliveredAt}
+		}
+	}
+
+	return result, nil
+}
+
+func toWebhook(webhook database.WebhookDB) models.Webhook {
+	events := make([]models.WebhookEvent, len(webhook.Events))
+
+	for i, event := range webhook.Events {
+		events[i] = models.WebhookEvent(event)
+	}
+
+	return models.Webhook{
+		ID:        webhook.ID,
+		URL:       webhook.URL,
+		Events:    events,
+		CreatedAt: utils.RFC3339Date{Time: webhook.CreatedAt},
+	}
+}
+
+// Start runs the given number of delivery workers. Each worker claims its own deliveries.
+func (ws *WebhookService) Start(ctx context.Context, workers int) {
+	ctx, ws.cancel = context.WithCancel(ctx)
+
+	if workers < 1 {
+		workers = 1
+	}
+
+	for i := 0; i < workers; i++ {
+		ws.wg.Add(1)
+
+		go func() {
+			defer ws.wg.Done()
+
+			wait := ws.pollInterval
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(wait):
+				}
+
+				wait = ws.pollInterval
+
+				if ws.deliverNext(ctx) {
+					wait = 0
+				}
+			}
+		}()
+	}
+}
+
+func (ws *WebhookService) deliverNext(ctx context.Context) bool {
+	delivery, err := ws.storage.ClaimWebhookDelivery(ctx, webhookDeliveryLease)
+
+	if err != nil {
+		if ctx.Err() == nil {
+			logger.Log.Error("failed to claim webhook delivery", zap.Error(err))
+		}
+
+		return false
+	}
+
+	if delivery == nil {
+		return false
+	}
+
+	responseCode, err := ws.deliver(ctx, *delivery)
+
+	status := models.WebhookDeliveryDelivered
+	var lastError *string
+	var retryDelay time.Duration
+
+	if err != nil {
+		message := err.Error()
+		lastError = &message
+		status = models.WebhookDeliveryPending
+
+		if ws.retryPolicy.IsExhausted(delivery.Attempts) {
+			status = models.WebhookDeliveryFailed
+		} else {
+			retryDelay = ws.retryPolicy.Delay(delivery.Attempts)
+		}
+
+		logger.Log.Warn("webhook delivery failed",
+			zap.String("deliveryID", delivery.ID),
+			zap.Int("attempts", delivery.Attempts),
+			zap.String("status", string(status)),
+			zap.Error(err),
+		)
+	}
+
+	if err := ws.storage.UpdateWebhookDelivery(ctx, delivery.ID, status, responseCode, lastError, retryDelay); err != nil {
+		logger.Log.Error("failed to update webhook delivery", zap.String("deliveryID", delivery.ID), zap.Error(err))
+	}
+
+	return true
+}
+
+// deliver posts the signed event to the webhook URL. Any response other than 2xx is a failure.
+func (ws *WebhookService) deliver(ctx context.Context, delivery database.ClaimedWebhookDeliveryDB) (*int, error) {
+	body, err := json.Marshal(&models.WebhookPayload{
+		ID:        delivery.ID,
+		Event:     models.WebhookEvent(delivery.Event),
+		CreatedAt: utils.RFC3339Date{Time: delivery.CreatedAt},
+		Data:      delivery.Payload,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(body))
+
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp := time.Now().Unix()
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, delivery.Event)
+	req.Header.Set(WebhookDeliveryHeader, delivery.ID)
+	req.Header.Set(WebhookSignatureTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookSignatureHeader, utils.SignPayload(delivery.Secret, timestamp, body))
+
+	res, err := ws.client.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	code := res.StatusCode
+
+	if code < 200 || code > 299 {
+		message, _ := io.ReadAll(io.LimitReader(res.Body, webhookMaxErrorBodySize))
+
+		return &code, fmt.Errorf("webhook responded with status %d: %s", code, message)
+	}
+
+	return &code, nil
+}
+
+func (ws *WebhookService) Shutdown() {
+	if ws.cancel != nil {
+		ws.cancel()
+	}
+
+	ws.wg.Wait()
+}
@@ -0,0 +1,317 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/database"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/models"
+	"github.com/daremove/go-musthave-diploma-tpl/tree/master/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type webhookDeliveryResult struct {
+	status       models.WebhookDeliveryStatus
+	responseCode *int
+	lastError    *string
+	retryDelay   time.Duration
+}
+
+type fakeWebhookStorage struct {
+	webhookStorage
+	delivery *database.ClaimedWebhookDeliveryDB
+	result   *webhookDeliveryResult
+}
+
+func (s *fakeWebhookStorage) CreateWebhook(ctx context.Context, userID, url, secret string, events []string) (*database.WebhookDB, error) {
+	return &database.WebhookDB{ID: "webhook-id", UserID: userID, URL: url, Secret: secret, Events: events}, nil
+}
+
+func (s *fakeWebhookStorage) ClaimWebhookDelivery(ctx context.Context, lease time.Duration) (*database.ClaimedWebhookDeliveryDB, error) {
+	delivery := s.delivery
+	s.delivery = nil
+
+	return delivery, nil
+}
+
+func (s *fakeWebhookStorage) UpdateWebhookDelivery(
+	ctx context.Context,
+	deliveryID string,
+	status models.WebhookDeliveryStatus,
+	responseCode *int,
+	lastError *string,
+	retryDelay time.Duration,
+) error {
+	s.result = &webhookDeliveryResult{status, responseCode, lastError, retryDelay}
+
+	return nil
+}
+
+func TestWebhookDeliveryIsSigned(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		timestamp, err := strconv.ParseInt(r.Header.Get(WebhookSignatureTimestampHeader), 10, 64)
+		require.NoError(t, err)
+
+		assert.True(t, utils.IsSignatureValid("secret", timestamp, body, r.Header.Get(WebhookSignatureHeader)))
+		assert.Equal(t, "order_accrued", r.Header.Get(WebhookEventHeader))
+		assert.Equal(t, "delivery-id", r.Header.Get(WebhookDeliveryHeader))
+
+		var payload map[string]interface{}
+		require.NoError(t, json.Unmarshal(body, &payload))
+
+		assert.Equal(t, "delivery-id", payload["id"])
+		assert.Equal(t, "order_accrued", payload["event"])
+		assert.Equal(t, "2009-11-17T00:00:00Z", payload["created_at"])
+		assert.Equal(t, map[string]interface{}{"order": "12345678903", "accrual": 500.5}, payload["data"])
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer testServer.Close()
+
+	storage := &fakeWebhookStorage{delivery: &database.ClaimedWebhookDeliveryDB{
+		ID:        "delivery-id",
+		Event:     "order_accrued",
+		Payload:   []byte(`{"order":"12345678903","accrual":500.5}`),
+		Attempts:  1,
+		CreatedAt: time.Date(2009, 11, 17, 0, 0, 0, 0, time.UTC),
+		URL:       testServer.URL,
+		Secret:    "secret",
+	}}
+	service := NewWebhookService(storage, DefaultRetryPolicy(), time.Second)
+	// The test server listens on loopback, which the default transport refuses to dial.
+	service.client.Transport = testServer.Client().Transport
+
+	assert.True(t, service.deliverNext(context.Background()))
+	require.NotNil(t, storage.result)
+	assert.Equal(t, models.WebhookDeliveryDelivered, storage.result.status)
+	assert.Equal(t, http.StatusNoContent, *storage.result.responseCode)
+	assert.Nil(t, storage.result.lastError)
+
+	assert.False(t, service.deliverNext(context.Background()))
+}
+
+func TestWebhookDeliveryIsRetried(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer testServer.Close()
+
+	retryPolicy := RetryPolicy{InitialInterval: time.Second, MaxInterval: time.Minute, Multiplier: 2, MaxAttempts: 3}
+
+	testCases := []struct {
+		testName           string
+		attempts           int
+		expectedStatus     models.WebhookDeliveryStatus
+		expectedRetryDelay time.Duration
+	}{
+		{
+			testName:           "Should schedule retry with backoff",
+			attempts:           2,
+			expectedStatus:     models.WebhookDeliveryPending,
+			expectedRetryDelay: 2 * time.Second,
+		},
+		{
+			testName:           "Should give up when attempts are exhausted",
+			attempts:           3,
+			expectedStatus:     models.WebhookDeliveryFailed,
+			expectedRetryDelay: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			storage := &fakeWebhookStorage{delivery: &database.ClaimedWebhookDeliveryDB{
+				ID:       "delivery-id",
+				Event:    "balance_withdrawn",
+				Payload:  []byte(`{}`),
+				Attempts: tc.attempts,
+				URL:      testServer.URL,
+				Secret:   "secret",
+			}}
+			service := NewWebhookService(storage, retryPolicy, time.Second)
+			service.client.Transport = testServer.Client().Transport
+
+			assert.True(t, service.deliverNext(context.Background()))
+			require.NotNil(t, storage.result)
+			assert.Equal(t, tc.expectedStatus, storage.result.status)
+			assert.Equal(t, tc.expectedRetryDelay, storage.result.retryDelay)
+			assert.Equal(t, http.StatusServiceUnavailable, *storage.result.responseCode)
+			assert.Equal(t, "webhook responded with status 503: unavailable\n", *storage.result.lastError)
+		})
+	}
+}
+
+func TestCreateWebhookRejectsInternalHosts(t *testing.T) {
+	service := NewWebhookService(&fakeWebhookStorage{}, DefaultRetryPolicy(), time.Second)
+	events := []models.WebhookEvent{models.WebhookEventOrderAccrued}
+
+	for _, webhookURL := range []string{
+		"http://127.0.0.1/hook",
+		"http://localhost:8080/hook",
+		"http://[::1]/hook",
+		"http://0.0.0.0/hook",
+		"http://10.0.0.5/hook",
+		"http://172.16.0.1/hook",
+		"http://192.168.1.1/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[fe80::1]/hook",
+		"http://[::ffff:127.0.0.1]/hook",
+	} {
+		t.Run(webhookURL, func(t *testing.T) {
+			_, err := service.CreateWebhook(context.Background(), "user-id", webhookURL, events)
+
+			assert.ErrorIs(t, err, ErrWebhookURLIsInvalid)
+			assert.ErrorIs(t, err, ErrWebhookHostIsNotPublic)
+		})
+	}
+
+	webhook, err := service.CreateWebhook(context.Background(), "user-id", "https://93.184.216.34/hook", events)
+	require.NoError(t, err)
+
+	assert.Equal(t, "https://93.184.216.34/hook", webhook.URL)
+}
+
+func TestWebhookDeliveryRefusesInternalAddress(t *testing.T) {
+	requests := 0
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer testServer.Close()
+
+	storage := &fakeWebhookStorage{delivery: &database.ClaimedWebhookDeliveryDB{
+		ID:       "delivery-id",
+		Event:    "balance_withdrawn",
+		Payload:  []byte(`{}`),
+		Attempts: 1,
+		URL:      testServer.URL,
+		Secret:   "secret",
+	}}
+	service := NewWebhookService(storage, DefaultRetryPolicy(), time.Second)
+
+	assert.True(t, service.deliverNext(context.Background()))
+	require.NotNil(t, storage.result)
+	assert.Equal(t, models.WebhookDeliveryPending, storage.result.status)
+	assert.Nil(t, storage.result.responseCode)
+	assert.Contains(t, *storage.result.lastError, ErrWebhookHostIsNotPublic.Error())
+	assert.Zero(t, requests)
+}
+
+func TestWebhookDeliveryDoesNotFollowRedirects(t *testing.T) {
+	redirected := false
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/internal" {
+			redirected = true
+			return
+		}
+
+		http.Redirect(w, r, "/internal", http.StatusFound)
+	}))
+	defer testServer.Close()
+
+	storage := &fakeWebhookStorage{delivery: &database.ClaimedWebhookDeliveryDB{
+		ID:       "delivery-id",
+		Event:    "balance_withdrawn",
+		Payload:  []byte(`{}`),
+		Attempts: 1,
+		URL:      testServer.URL,
+		Secret:   "secret",
+	}}
+	service := NewWebhookService(storage, DefaultRetryPolicy(), time.Second)
+	service.client.Transport = testServer.Client().Transport
+
+	assert.True(t, service.deliverNext(context.Background()))
+	require.NotNil(t, storage.result)
+	assert.Equal(t, models.WebhookDeliveryPending, storage.result.status)
+	assert.Equal(t, http.StatusFound, *storage.result.responseCode)
+	assert.False(t, redirected)
+}
+
+type queuedWebhookStorage struct {
+	webhookStorage
+	mu         sync.Mutex
+	deliveries []database.ClaimedWebhookDeliveryDB
+	delivered  int
+}
+
+func (s *queuedWebhookStorage) ClaimWebhookDelivery(ctx context.Context, lease time.Duration) (*database.ClaimedWebhookDeliveryDB, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.deliveries) == 0 {
+		return nil, nil
+	}
+
+	delivery := s.deliveries[0]
+	s.deliveries = s.deliveries[1:]
+
+	return &delivery, nil
+}
+
+func (s *queuedWebhookStorage) UpdateWebhookDelivery(
+	ctx context.Context,
+	deliveryID string,
+	status models.WebhookDeliveryStatus,
+	responseCode *int,
+	lastError *string,
+	retryDelay time.Duration,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if status == models.WebhookDeliveryDelivered {
+		s.delivered++
+	}
+
+	return nil
+}
+
+func TestWebhookServiceDeliversConcurrently(t *testing.T) {
+	const workers = 3
+
+	// Every request waits until all workers are in flight, so a single worker would time out.
+	var arrived sync.WaitGroup
+	arrived.Add(workers)
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		arrived.Done()
+		arrived.Wait()
+	}))
+	defer testServer.Close()
+
+	storage := &queuedWebhookStorage{}
+
+	for i := 0; i < workers; i++ {
+		storage.deliveries = append(storage.deliveries, database.ClaimedWebhookDeliveryDB{
+			ID:      strconv.Itoa(i),
+			Event:   "balance_withdrawn",
+			Payload: []byte(`{}`),
+			URL:     testServer.URL,
+			Secret:  "secret",
+		})
+	}
+
+	service := NewWebhookService(storage, DefaultRetryPolicy(), 10*time.Millisecond)
+	service.client.Transport = testServer.Client().Transport
+	service.client.Timeout = time.Second
+
+	service.Start(context.Background(), workers)
+	defer service.Shutdown()
+
+	assert.Eventually(t, func() bool {
+		storage.mu.Lock()
+		defer storage.mu.Unlock()
+
+		return storage.delivered == workers
+	}, 2*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,32 @@
+package utils
+
+import "strconv"
+
+func IsLuhnValid(number string) bool {
+	if len(number) == 0 {
+		return false
+	}
+
+	var sum int
+	var alternate bool
+
+	for i := len(number) - 1; i >= 0; i-- {
+		digit, err := strconv.Atoi(string(number[i]))
+		if err != nil {
+			return false
+		}
+
+		if alternate {
+			digit *= 2
+
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+
+		sum += digit
+		alternate = !alternate
+	}
+
+	return sum%10 == 0
+}
@@ -0,0 +1,183 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	ErrMoneyIsInvalid  = errors.New("money must be a number with at most two decimal places")
+	ErrMoneyIsNegative = errors.New("money must not be negative")
+)
+
+// Money is an exact amount stored in hundredths. It's read from and written to
+// numeric columns without going through float64.
+type Money int64
+
+func ParseMoney(value string) (Money, error) {
+	negative := strings.HasPrefix(value, "-")
+	value = strings.TrimPrefix(value, "-")
+
+	whole, fraction, _ := strings.Cut(value, ".")
+
+	if whole == "" || len(fraction) > 2 || strings.ContainsAny(whole+fraction, "+-eE") {
+		return 0, ErrMoneyIsInvalid
+	}
+
+	fraction += strings.Repeat("0", 2-len(fraction))
+	cents, err := strconv.ParseInt(whole+fraction, 10, 64)
+
+	if err != nil {
+		return 0, ErrMoneyIsInvalid
+	}
+
+	if negative {
+		cents = -cents
+	}
+
+	return Money(cents), nil
+}
+
+// String formats the amount the same way JSON encodes a float, e.g. 500.5 or 100.
+func (m Money) String() string {
+	sign := ""
+	cents := int64(m)
+
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+
+	if cents%100 == 0 {
+		return fmt.Sprintf("%s%d", sign, cents/100)
+	}
+
+	return strings.TrimSuffix(fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100), "0")
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	money, err := ParseMoney(string(data))
+
+	if err != nil {
+		return err
+	}
+
+	if money < 0 {
+		return ErrMoneyIsNegative
+	}
+
+	*m = money
+
+	return nil
+}
+
+// RoundedMoney is Money decoded leniently from JSON: any number, e.g. 86.415 or 1e2,
+// is accepted and rounded half away from zero to hundredths. It's meant for amounts
+// coming from other systems, user input must keep using the strict Money.
+type RoundedMoney Money
+
+func ParseRoundedMoney(value string) (Money, error) {
+	if value == "" || strings.ContainsAny(value, "/xXoObB") {
+		return 0, ErrMoneyIsInvalid
+	}
+
+	amount, ok := new(big.Rat).SetString(value)
+
+	if !ok {
+		return 0, ErrMoneyIsInvalid
+	}
+
+	amount.Mul(amount, big.NewRat(100, 1))
+
+	// Rounds |amount| as floor(|amount| + 1/2) and restores the sign afterwards.
+	num := new(big.Int).Abs(amount.Num())
+	den := amount.Denom()
+	cents := new(big.Int).Quo(
+		new(big.Int).Add(new(big.Int).Mul(num, big.NewInt(2)), den),
+		new(big.Int).Mul(den, big.NewInt(2)),
+	)
+
+	if !cents.IsInt64() {
+		return 0, ErrMoneyIsInvalid
+	}
+
+	if amount.Sign() < 0 {
+		cents.Neg(cents)
+	}
+
+	return Money(cents.Int64()), nil
+}
+
+func (m RoundedMoney) MarshalJSON() ([]byte, error) {
+	return Money(m).MarshalJSON()
+}
+
+func (m *RoundedMoney) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	money, err := ParseRoundedMoney(string(data))
+
+	if err != nil {
+		return err
+	}
+
+	if money < 0 {
+		return ErrMoneyIsNegative
+	}
+
+	*m = RoundedMoney(money)
+
+	return nil
+}
+
+func (m *Money) ScanNumeric(value pgtype.Numeric) error {
+	if !value.Valid {
+		*m = 0
+		return nil
+	}
+
+	if value.NaN || value.InfinityModifier != pgtype.Finite {
+		return fmt.Errorf("cannot scan %v into Money", value)
+	}
+
+	cents := new(big.Int).Set(value.Int)
+	exp := value.Exp + 2
+
+	if exp >= 0 {
+		cents.Mul(cents, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exp)), nil))
+	} else {
+		remainder := new(big.Int)
+		cents.QuoRem(cents, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(-exp)), nil), remainder)
+
+		if remainder.Sign() != 0 {
+			return fmt.Errorf("cannot scan %v into Money without losing precision", value)
+		}
+	}
+
+	if !cents.IsInt64() {
+		return fmt.Errorf("cannot scan %v into Money: value is out of range", value)
+	}
+
+	*m = Money(cents.Int64())
+
+	return nil
+}
+
+func (m Money) NumericValue() (pgtype.Numeric, error) {
+	return pgtype.Numeric{Int: big.NewInt(int64(m)), Exp: -2, Valid: true}, nil
+}
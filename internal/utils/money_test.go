@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoneyJSON(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected Money
+		output   string
+	}{
+		{input: "500.5", expected: 50050, output: "500.5"},
+		{input: "500.50", expected: 50050, output: "500.5"},
+		{input: "100", expected: 10000, output: "100"},
+		{input: "0.05", expected: 5, output: "0.05"},
+		{input: "729.98", expected: 72998, output: "729.98"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			var money Money
+
+			require.NoError(t, json.Unmarshal([]byte(tc.input), &money))
+			assert.Equal(t, tc.expected, money)
+
+			data, err := json.Marshal(money)
+			require.NoError(t, err)
+			assert.Equal(t, tc.output, string(data))
+		})
+	}
+
+	for _, input := range []string{"0.001", "1e2", "\"10\"", "-1"} {
+		var money Money
+
+		assert.Error(t, json.Unmarshal([]byte(input), &money), input)
+	}
+}
+
+func TestRoundedMoneyJSON(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected RoundedMoney
+	}{
+		{input: "500.5", expected: 50050},
+		{input: "86.415", expected: 8642},
+		{input: "86.414", expected: 8641},
+		{input: "1e2", expected: 10000},
+		{input: "1.2345E+1", expected: 1235},
+		{input: "0.004", expected: 0},
+		{input: "0.005", expected: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			var money RoundedMoney
+
+			require.NoError(t, json.Unmarshal([]byte(tc.input), &money))
+			assert.Equal(t, tc.expected, money)
+		})
+	}
+
+	for _, input := range []string{"\"10\"", "-1", "true", "1e30"} {
+		var money RoundedMoney
+
+		assert.Error(t, json.Unmarshal([]byte(input), &money), input)
+	}
+}
+
+func TestMoneyNumeric(t *testing.T) {
+	var money Money
+
+	require.NoError(t, money.ScanNumeric(pgtype.Numeric{Int: big.NewInt(12340), Exp: -3, Valid: true}))
+	assert.Equal(t, Money(1234), money)
+
+	require.NoError(t, money.ScanNumeric(pgtype.Numeric{Int: big.NewInt(5), Exp: 1, Valid: true}))
+	assert.Equal(t, Money(5000), money)
+
+	assert.Error(t, money.ScanNumeric(pgtype.Numeric{Int: big.NewInt(12345), Exp: -3, Valid: true}))
+
+	value, err := Money(50050).NumericValue()
+	require.NoError(t, err)
+	assert.Equal(t, pgtype.Numeric{Int: big.NewInt(50050), Exp: -2, Valid: true}, value)
+}
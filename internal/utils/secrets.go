@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SecretKeySize is the number of random bytes in a generated secret key. It matches the
+// output size of SHA-256, the minimum key size for HS256.
+const SecretKeySize = 32
+
+// MinSecretKeyLength is the length of a generated secret key once it is base64 encoded.
+var MinSecretKeyLength = base64.StdEncoding.EncodedLen(SecretKeySize)
+
+var ErrSecretIsEmpty = errors.New("secret is empty")
+
+// ReadSecret looks the secret up in the environment variable, then in the file named by the
+// variable with the _FILE suffix and then in the file named as the variable in the secretsDir,
+// e.g. a mounted Docker or Kubernetes secret. Trailing whitespace of files is trimmed.
+// An empty string is returned when the secret is defined nowhere.
+func ReadSecret(name, secretsDir string) (string, error) {
+	if value := os.Getenv(name); value != "" {
+		return value, nil
+	}
+
+	if path := os.Getenv(name + "_FILE"); path != "" {
+		return readSecretFile(path)
+	}
+
+	if secretsDir == "" {
+		return "", nil
+	}
+
+	value, err := readSecretFile(filepath.Join(secretsDir, name))
+
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+
+	return value, err
+}
+
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return "", err
+	}
+
+	value := strings.TrimRight(string(data), " \t\r\n")
+
+	if value == "" {
+		return "", ErrSecretIsEmpty
+	}
+
+	return value, nil
+}
+
+// GenerateSecretKey returns SecretKeySize random bytes encoded with base64.
+func GenerateSecretKey() (string, error) {
+	b := make([]byte, SecretKeySize)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// WriteSecretKey generates a secret key and writes it to the file, which is readable by the owner only.
+// An existing file is never overwritten.
+func WriteSecretKey(path string) error {
+	key, err := GenerateSecretKey()
+
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.WriteString(key + "\n"); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
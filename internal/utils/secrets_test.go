@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadSecret(t *testing.T) {
+	dir := t.TempDir()
+	secretsDir := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "secret"), []byte("from-file\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "empty"), []byte("\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(secretsDir, "TEST_SECRET"), []byte("from-dir"), 0o600))
+
+	testCases := []struct {
+		testName   string
+		env        map[string]string
+		secretsDir string
+		expected   string
+		err        error
+	}{
+		{
+			testName: "Should return nothing when secret is not defined",
+			expected: "",
+		},
+		{
+			testName:   "Should prefer environment variable",
+			env:        map[string]string{"TEST_SECRET": "from-env", "TEST_SECRET_FILE": filepath.Join(dir, "secret")},
+			secretsDir: secretsDir,
+			expected:   "from-env",
+		},
+		{
+			testName:   "Should read file from _FILE variable",
+			env:        map[string]string{"TEST_SECRET_FILE": filepath.Join(dir, "secret")},
+			secretsDir: secretsDir,
+			expected:   "from-file",
+		},
+		{
+			testName:   "Should read file from secrets directory",
+			secretsDir: secretsDir,
+			expected:   "from-dir",
+		},
+		{
+			testName: "Should fail when file is empty",
+			env:      map[string]string{"TEST_SECRET_FILE": filepath.Join(dir, "empty")},
+			err:      ErrSecretIsEmpty,
+		},
+		{
+			testName: "Should fail when file is not exist",
+			env:      map[string]string{"TEST_SECRET_FILE": filepath.Join(dir, "missing")},
+			err:      os.ErrNotExist,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			t.Setenv("TEST_SECRET", "")
+			t.Setenv("TEST_SECRET_FILE", "")
+
+			for name, value := range tc.env {
+				t.Setenv(name, value)
+			}
+
+			secret, err := ReadSecret("TEST_SECRET", tc.secretsDir)
+
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, secret)
+		})
+	}
+}
+
+func TestWriteSecretKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "key")
+
+	require.NoError(t, WriteSecretKey(path))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	t.Setenv("TEST_SECRET", "")
+	t.Setenv("TEST_SECRET_FILE", path)
+
+	secret, err := ReadSecret("TEST_SECRET", "")
+	require.NoError(t, err)
+	assert.Len(t, secret, MinSecretKeyLength)
+
+	assert.ErrorIs(t, WriteSecretKey(path), os.ErrExist)
+}
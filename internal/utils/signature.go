@@ -0,0 +1,22 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+// SignPayload returns a hex encoded HMAC-SHA256 of the timestamp and the body joined with a dot.
+func SignPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func IsSignatureValid(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(SignPayload(secret, timestamp, body)), []byte(signature))
+}